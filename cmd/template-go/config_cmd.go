@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"template-go/internal/config"
+)
+
+const configUsage = `usage:
+  template-go config print [--output table|json] [config flags...]
+      show the effective configuration, where each value came from, with secrets redacted
+  template-go config schema
+      print a JSON Schema of the config file`
+
+// jsonSchema builds the output of "config schema". It is a variable so tests
+// can make it fail.
+var jsonSchema = config.JSONSchema
+
+// runConfigCommand implements the "config" subcommand and returns the
+// process exit code.
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprintln(stderr, configUsage)
+		return 2
+	}
+	switch args[0] {
+	case "print":
+		return printConfig(args[1:], stdout, stderr)
+	case "schema":
+		data, err := jsonSchema()
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, err)
+			return 1
+		}
+		_, _ = fmt.Fprintln(stdout, string(data))
+		return 0
+	default:
+		_, _ = fmt.Fprintln(stderr, configUsage)
+		return 2
+	}
+}
+
+// printConfig loads the configuration exactly as the server would and prints
+// every setting with its origin. Validation problems are reported after the
+// listing and make the command fail.
+func printConfig(args []string, stdout, stderr io.Writer) int {
+	output, rest, err := splitOutputFlag(args)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	cfg, err := config.Read(config.Options{Args: rest})
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	switch output {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(struct {
+			Files    []string         `json:"files,omitempty"`
+			Settings []config.Setting `json:"settings"`
+		}{cfg.Files(), cfg.Settings()})
+	default:
+		err = printTable(stdout, cfg)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if err := cfg.Validate(); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// printTable writes the settings as aligned columns.
+func printTable(w io.Writer, cfg config.Config) error {
+	for _, file := range cfg.Files() {
+		if _, err := fmt.Fprintf(w, "# config file: %s\n", file); err != nil {
+			return err
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE\tENV")
+	for _, s := range cfg.Settings() {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Key, s.Value, s.Source, s.Env)
+	}
+	return tw.Flush()
+}
+
+// splitOutputFlag extracts --output/-o from args and returns the remaining
+// arguments, which are passed on to the config loader.
+func splitOutputFlag(args []string) (string, []string, error) {
+	output := "table"
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-o" || arg == "--output" || arg == "-output":
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("%s requires a value", arg)
+			}
+			output = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--output=") || strings.HasPrefix(arg, "-o="):
+			output = arg[strings.Index(arg, "=")+1:]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if output != "table" && output != "json" {
+		return "", nil, fmt.Errorf("unknown output format %q (want table or json)", output)
+	}
+	return output, rest, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/config"
+)
+
+// runConfig runs the config subcommand and returns its exit code and output.
+func runConfig(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = runConfigCommand(args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestConfigPrint_Table(t *testing.T) {
+	// GIVEN a config file, an environment variable and a flag
+	path := writeConfigFile(t, "server:\n  listen_addr: \":7000\"\ndb:\n  driver: postgres\n  dsn: postgres://app:hunter2@db/app\n")
+	t.Setenv("OTEL_SERVICE_NAME", "from-env")
+
+	// WHEN the configuration is printed
+	code, stdout, stderr := runConfig("print", "--config", path, "--server.admin-addr", ":9999")
+
+	// THEN every setting is listed with its origin and secrets are redacted
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "# config file: "+path+"\n")
+	lines := strings.Split(stdout, "\n")
+	assert.Regexp(t, `^KEY\s+VALUE\s+SOURCE\s+ENV$`, lines[1])
+	assert.Regexp(t, `(?m)^server\.listen_addr\s+:7000\s+file\s+LISTEN_ADDR$`, stdout)
+	assert.Regexp(t, `(?m)^server\.admin_addr\s+:9999\s+flag\s`, stdout)
+	assert.Regexp(t, `(?m)^otel\.service_name\s+from-env\s+env\s`, stdout)
+	assert.Regexp(t, `(?m)^db\.dsn\s+\[REDACTED\]\s+file\s`, stdout)
+	assert.NotContains(t, stdout, "hunter2")
+}
+
+func TestConfigPrint_JSON(t *testing.T) {
+	path := writeConfigFile(t, "db:\n  driver: postgres\n  dsn: postgres://app:hunter2@db/app\n")
+
+	for _, flag := range [][]string{{"-o", "json"}, {"--output=json"}, {"-o=json"}, {"-output", "json"}} {
+		t.Run(strings.Join(flag, " "), func(t *testing.T) {
+			code, stdout, stderr := runConfig(append([]string{"print", "--config", path}, flag...)...)
+
+			require.Equal(t, 0, code, stderr)
+			var got struct {
+				Files    []string         `json:"files"`
+				Settings []config.Setting `json:"settings"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(stdout), &got))
+			assert.Equal(t, []string{path}, got.Files)
+			i := indexSetting(got.Settings, "db.dsn")
+			require.GreaterOrEqual(t, i, 0)
+			assert.Equal(t, config.Setting{
+				Key: "db.dsn", Env: "DB_DSN", Flag: "--db.dsn", Value: config.RedactedValue,
+				Source: config.SourceFile, Secret: true, Description: got.Settings[i].Description,
+			}, got.Settings[i])
+			assert.Equal(t, config.SourceDefault, got.Settings[indexSetting(got.Settings, "env")].Source)
+		})
+	}
+}
+
+func indexSetting(settings []config.Setting, key string) int {
+	for i, s := range settings {
+		if s.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestConfigPrint_InvalidConfigIsListedThenReported(t *testing.T) {
+	code, stdout, stderr := runConfig("print", "--otel.exporter", "carrier-pigeon")
+
+	assert.Equal(t, 1, code)
+	assert.Regexp(t, `(?m)^otel\.exporter\s+carrier-pigeon\s+flag\s`, stdout)
+	assert.Contains(t, stderr, "otel.exporter")
+}
+
+func TestConfigSchema(t *testing.T) {
+	code, stdout, stderr := runConfig("schema")
+
+	require.Equal(t, 0, code, stderr)
+	want, err := config.JSONSchema()
+	require.NoError(t, err)
+	assert.Equal(t, string(want)+"\n", stdout)
+}
+
+func TestConfigSchema_Error(t *testing.T) {
+	jsonSchema = func() ([]byte, error) { return nil, errors.New("schema unavailable") }
+	t.Cleanup(func() { jsonSchema = config.JSONSchema })
+
+	code, stdout, stderr := runConfig("schema")
+
+	assert.Equal(t, 1, code)
+	assert.Empty(t, stdout)
+	assert.Equal(t, "schema unavailable\n", stderr)
+}
+
+func TestConfigCommand_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{"no subcommand", nil, 2, "usage:"},
+		{"unknown subcommand", []string{"show"}, 2, "usage:"},
+		{"unknown output", []string{"print", "--output", "xml"}, 2, `unknown output format "xml"`},
+		{"unknown inline output", []string{"print", "-o=yaml"}, 2, `unknown output format "yaml"`},
+		{"output without value", []string{"print", "-o"}, 2, "-o requires a value"},
+		{"unknown flag", []string{"print", "--no-such-flag"}, 1, "invalid flags"},
+		{"missing config file", []string{"print", "--config", "/does/not/exist.yaml"}, 1, "/does/not/exist.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runConfig(tt.args...)
+
+			assert.Equal(t, tt.code, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, tt.stderr)
+		})
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestConfigPrint_WriteError(t *testing.T) {
+	path := writeConfigFile(t, "server:\n  listen_addr: \":7000\"\n")
+
+	for _, args := range [][]string{{"print", "--config", path}, {"print"}, {"print", "-o", "json"}} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			var stderr bytes.Buffer
+
+			code := runConfigCommand(args, failingWriter{}, &stderr)
+
+			assert.Equal(t, 1, code)
+			assert.Contains(t, stderr.String(), "broken pipe")
+		})
+	}
+}
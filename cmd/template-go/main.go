@@ -2,13 +2,25 @@ package main
 
 import (
 	"context"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"template-go/internal/adapters/db"
+	"template-go/internal/app"
+	"template-go/internal/auth"
 	"template-go/internal/config"
 	delivery "template-go/internal/delivery/http"
+	"template-go/internal/delivery/http/routes"
+	"template-go/internal/feature"
+	"template-go/internal/health"
 	"template-go/internal/otel"
+	"template-go/internal/ratelimit"
+	"template-go/internal/server"
 	"template-go/pkg/logger"
 
 	_ "template-go/docs"
@@ -20,26 +32,218 @@ import (
 // @host            localhost:8080
 // @BasePath        /
 func main() {
-	ctx := context.Background()
-	cfg := config.MustLoad()
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
 
-	logger.Init()
-	defer logger.Sync()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	cfg := config.MustLoad(os.Args[1:]...)
+	feature.Set(cfg.Features)
 
-	shutdown, err := otel.InitOtel(ctx, cfg)
+	holder := config.NewHolder(cfg, func() (config.Config, error) {
+		return config.Load(os.Args[1:]...)
+	})
+	probe := health.NewProbe()
+	checks := health.NewRegistry()
+	checks.Register("server", probe.Check)
+	limiter, limiterComponents, err := rateLimiter(cfg.RateLimit, checks)
+	if err != nil {
+		log.Fatalf("rate limiting: %v", err)
+	}
+	verifier, err := authVerifier(cfg.Auth, checks)
+	if err != nil {
+		log.Fatalf("authentication: %v", err)
+	}
+	apiKeys, apiKeyComponents, err := apiKeyAuth(ctx, cfg, checks)
 	if err != nil {
-		log.Fatalf("failed to init tracing: %v", err)
+		log.Fatalf("API keys: %v", err)
 	}
+	router := delivery.NewRouter(
+		delivery.WithConfig(cfg),
+		delivery.WithRateLimiter(limiter),
+		delivery.WithVerifier(verifier),
+		delivery.WithAPIKeys(apiKeys),
+		delivery.WithHealthChecks(checks),
+		delivery.WithModules(modules()...),
+		delivery.WithVersions(versions()...),
+	)
+	// Listeners, stores and authenticators are built once; a reload only
+	// applies the settings below.
+	holder.Subscribe(func(old, new config.Config) {
+		if err := logger.SetLevel(new.Logging.Level); err != nil {
+			logger.Error(ctx, "failed to apply log level", zap.Error(err))
+		}
+		otel.SetSampleRatio(new.OTEL.SampleRatio)
+		feature.Set(new.Features)
+		router.Update(new)
+		if limiter != nil {
+			if err := limiter.Update(new.RateLimit); err != nil {
+				logger.Error(ctx, "failed to apply rate limits", zap.Error(err))
+			}
+		}
+	})
+
+	public := server.New(cfg.Server, router, probe)
+	admin := server.NewAdmin(cfg.Server, delivery.NewAdminRouter(checks, holder.Get, apiKeys, router))
+
+	a := app.New(
+		app.WithStartTimeout(cfg.Lifecycle.StartTimeout),
+		app.WithStopTimeout(cfg.Lifecycle.StopTimeout),
+	)
+	// Registered first so they start before, and stop after, the public server.
+	a.Add(limiterComponents...)
+	a.Add(apiKeyComponents...)
+	a.Add(
+		loggerComponent(cfg),
+		app.After(otel.NewComponent(cfg), "logger"),
+		app.After(watcherComponent(holder, cfg.ReloadInterval), "logger"),
+		app.After(admin, "logger", "otel"),
+		// The public server stops first so /readyz keeps reporting the drain.
+		app.After(public, "admin-server"),
+	)
+
+	log.Printf("🚀 Starting server on %s (admin on %s)\n", cfg.Server.ListenAddr, cfg.Server.AdminAddr)
+	if err := a.Run(ctx); err != nil {
+		logger.Error(context.Background(), "application error", zap.Error(err))
+		os.Exit(1)
+	}
+}
+
+// modules returns the API modules served on the public listener. Register
+// new modules here.
+func modules() []routes.Module {
+	return []routes.Module{
+		routes.Root(),
+	}
+}
+
+// versions returns the API versions served side by side under /v1, /v2...
+// on the public listener. Register new versions here.
+func versions() []delivery.Version {
+	return nil
+}
+
+// loggerComponent initialises the global logger first and syncs it last.
+func loggerComponent(cfg config.Config) app.Component {
+	return app.Func("logger",
+		func(context.Context) error {
+			logger.Init(
+				logger.WithFormat(cfg.Logging.Format),
+				logger.WithLevel(cfg.Logging.Level),
+				logger.WithDevelopment(cfg.IsDev()),
+			)
+			return nil
+		},
+		func(context.Context) error {
+			logger.Sync()
+			return nil
+		},
+	)
+}
 
-	defer func() {
-		if err := shutdown(ctx); err != nil {
-			logger.Error(ctx, "failed to shutdown tracer", zap.Error(err))
+// rateLimiter builds the limiter configured by cfg, or returns nil when rate
+// limiting is disabled. With the redis store it also returns a component
+// closing the client and registers a non-critical Redis health check.
+func rateLimiter(cfg config.RateLimitConfig, checks *health.Registry) (*ratelimit.Limiter, []app.Component, error) {
+	if !cfg.Enabled {
+		return nil, nil, nil
+	}
+	if cfg.Store != "redis" {
+		l, err := ratelimit.New(cfg, ratelimit.NewMemoryStore())
+		return l, nil, err
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: cfg.RedisAddr, Password: cfg.RedisPassword, DB: cfg.RedisDB})
+	store := ratelimit.NewRedisStore(client, "ratelimit:")
+	l, err := ratelimit.New(cfg, store)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, err
+	}
+	checks.Register("ratelimit-redis", store.Ping, health.WithCritical(false))
+	closer := app.Func("ratelimit-redis",
+		func(context.Context) error { return nil },
+		func(context.Context) error { return client.Close() },
+	)
+	return l, []app.Component{app.After(closer, "logger")}, nil
+}
+
+// authVerifier builds the bearer token verifier configured by cfg, or
+// returns nil when authentication is disabled. A key set is checked by a
+// non-critical "jwks" health check.
+func authVerifier(cfg config.AuthConfig, checks *health.Registry) (*auth.Verifier, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	v, err := auth.NewVerifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if keys := v.KeySet(); keys != nil {
+		checks.Register("jwks", keys.Check, health.WithCritical(false))
+	}
+	return v, nil
+}
+
+// apiKeyAuth builds the API key authenticator configured by cfg, or returns
+// nil when API keys are disabled. With the db store it migrates the
+// api_keys table, registers a non-critical "db" health check and returns a
+// component closing the connection pool.
+func apiKeyAuth(ctx context.Context, cfg config.Config, checks *health.Registry) (*auth.APIKeys, []app.Component, error) {
+	kc := cfg.Auth.APIKeys
+	if !kc.Enabled {
+		return nil, nil, nil
+	}
+	if kc.Store != config.APIKeyStoreDB {
+		store, err := auth.NewFileKeyStore(kc.File)
+		if err != nil {
+			return nil, nil, err
 		}
-	}()
+		return auth.NewAPIKeys(store, kc.TouchInterval), nil, nil
+	}
 
-	log.Printf("🚀 Starting server on %s\n", cfg.ListenAddr)
-	err = http.ListenAndServe(cfg.ListenAddr, delivery.NewRouter(cfg.OTELServiceName))
+	gdb, err := db.Open(cfg.DB)
 	if err != nil {
-		log.Fatalf("server error: %v", err)
+		return nil, nil, err
+	}
+	store := db.NewAPIKeyStore(gdb)
+	if err := store.Migrate(ctx); err != nil {
+		_ = db.Close(gdb)
+		return nil, nil, err
 	}
+	checks.Register("db", func(ctx context.Context) error { return db.Ping(ctx, gdb) }, health.WithCritical(false))
+	closer := app.Func("db",
+		func(context.Context) error { return nil },
+		func(context.Context) error { return db.Close(gdb) },
+	)
+	return auth.NewAPIKeys(store, kc.TouchInterval), []app.Component{app.After(closer, "logger")}, nil
+}
+
+// watcherComponent reloads the configuration in the background until stopped.
+func watcherComponent(holder *config.Holder, interval time.Duration) app.Component {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+	return app.Func("config-watcher",
+		func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				holder.Watch(ctx, interval)
+			}()
+			return nil
+		},
+		func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	)
 }
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"template-go/internal/auth"
+)
+
+// apiKeyRecord is the api_keys table. Scopes are stored space separated.
+type apiKeyRecord struct {
+	ID         string `gorm:"primaryKey;size:32"`
+	Name       string `gorm:"size:200;not null"`
+	Hash       string `gorm:"size:64;not null"`
+	Scopes     string `gorm:"size:2000;not null;default:''"`
+	TenantID   string `gorm:"size:200;index"`
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	RevokedAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func (apiKeyRecord) TableName() string { return "api_keys" }
+
+func recordOf(k auth.APIKey) apiKeyRecord {
+	return apiKeyRecord{
+		ID:         k.ID,
+		Name:       k.Name,
+		Hash:       k.Hash,
+		Scopes:     strings.Join(k.Scopes, " "),
+		TenantID:   k.TenantID,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		RevokedAt:  k.RevokedAt,
+		LastUsedAt: k.LastUsedAt,
+	}
+}
+
+func (r apiKeyRecord) key() auth.APIKey {
+	return auth.APIKey{
+		ID:         r.ID,
+		Name:       r.Name,
+		Hash:       r.Hash,
+		Scopes:     strings.Fields(r.Scopes),
+		TenantID:   r.TenantID,
+		CreatedAt:  r.CreatedAt,
+		ExpiresAt:  r.ExpiresAt,
+		RevokedAt:  r.RevokedAt,
+		LastUsedAt: r.LastUsedAt,
+	}
+}
+
+// APIKeyStore is an auth.KeyStore backed by the api_keys table.
+type APIKeyStore struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyStore returns a store using db. Call Migrate to create the table.
+func NewAPIKeyStore(db *gorm.DB) *APIKeyStore {
+	return &APIKeyStore{db: db}
+}
+
+// Migrate creates or updates the api_keys table.
+func (s *APIKeyStore) Migrate(ctx context.Context) error {
+	if err := s.db.WithContext(ctx).AutoMigrate(&apiKeyRecord{}); err != nil {
+		return fmt.Errorf("db: migrate api_keys: %w", err)
+	}
+	return nil
+}
+
+// Get implements auth.KeyStore.
+func (s *APIKeyStore) Get(ctx context.Context, id string) (auth.APIKey, error) {
+	var r apiKeyRecord
+	err := s.db.WithContext(ctx).Where("id = ?", id).Take(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return auth.APIKey{}, auth.ErrKeyNotFound
+	}
+	if err != nil {
+		return auth.APIKey{}, fmt.Errorf("db: get api key: %w", err)
+	}
+	return r.key(), nil
+}
+
+// List implements auth.KeyStore.
+func (s *APIKeyStore) List(ctx context.Context) ([]auth.APIKey, error) {
+	var records []apiKeyRecord
+	if err := s.db.WithContext(ctx).Order("created_at, id").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("db: list api keys: %w", err)
+	}
+	keys := make([]auth.APIKey, len(records))
+	for i, r := range records {
+		keys[i] = r.key()
+	}
+	return keys, nil
+}
+
+// Create implements auth.KeyStore.
+func (s *APIKeyStore) Create(ctx context.Context, key auth.APIKey) error {
+	r := recordOf(key)
+	if err := s.db.WithContext(ctx).Create(&r).Error; err != nil {
+		return fmt.Errorf("db: create api key: %w", err)
+	}
+	return nil
+}
+
+// Revoke implements auth.KeyStore. Revoking a revoked key keeps the
+// original time.
+func (s *APIKeyStore) Revoke(ctx context.Context, id string, at time.Time) error {
+	return s.update(ctx, id, "revoked_at", at, "revoked_at IS NULL")
+}
+
+// Touch implements auth.KeyStore.
+func (s *APIKeyStore) Touch(ctx context.Context, id string, at time.Time) error {
+	return s.update(ctx, id, "last_used_at", at, "")
+}
+
+// update sets column of key id to value where cond holds, telling unknown
+// keys apart from keys cond excluded.
+func (s *APIKeyStore) update(ctx context.Context, id, column string, value any, cond string) error {
+	q := s.db.WithContext(ctx).Model(&apiKeyRecord{}).Where("id = ?", id)
+	if cond != "" {
+		q = q.Where(cond)
+	}
+	res := q.Update(column, value)
+	if res.Error != nil {
+		return fmt.Errorf("db: update api key: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		if _, err := s.Get(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"template-go/internal/auth"
+	"template-go/internal/config"
+)
+
+// newAPIKeyStore returns a migrated store on a fresh SQLite database.
+func newAPIKeyStore(t *testing.T) *APIKeyStore {
+	t.Helper()
+	gdb, err := Open(config.DBConfig{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = Close(gdb) })
+	require.NoError(t, Ping(context.Background(), gdb))
+	store := NewAPIKeyStore(gdb)
+	require.NoError(t, store.Migrate(context.Background()))
+	return store
+}
+
+var epoch = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAPIKeyStore_RoundTrip(t *testing.T) {
+	// GIVEN a stored key
+	store := newAPIKeyStore(t)
+	ctx := context.Background()
+	expiry := epoch.Add(24 * time.Hour)
+	key := auth.APIKey{ID: "3f9a1c2b7d4e", Name: "edge-eu", Hash: "abc", Scopes: []string{"items:read", "items:write"},
+		TenantID: "acme", CreatedAt: epoch, ExpiresAt: &expiry}
+	require.NoError(t, store.Create(ctx, key))
+
+	// WHEN it is used and revoked
+	require.NoError(t, store.Touch(ctx, key.ID, epoch.Add(time.Minute)))
+	require.NoError(t, store.Revoke(ctx, key.ID, epoch.Add(time.Hour)))
+	require.NoError(t, store.Revoke(ctx, key.ID, epoch.Add(2*time.Hour)))
+
+	// THEN it reads back with the first revocation time
+	got, err := store.Get(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, key.Scopes, got.Scopes)
+	assert.Equal(t, "acme", got.TenantID)
+	assert.True(t, expiry.Equal(*got.ExpiresAt))
+	assert.True(t, epoch.Add(time.Minute).Equal(*got.LastUsedAt))
+	assert.True(t, epoch.Add(time.Hour).Equal(*got.RevokedAt))
+
+	list, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, key.ID, list[0].ID)
+}
+
+func TestAPIKeyStore_NotFound(t *testing.T) {
+	store := newAPIKeyStore(t)
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "nope")
+	assert.ErrorIs(t, err, auth.ErrKeyNotFound)
+	assert.ErrorIs(t, store.Revoke(ctx, "nope", epoch), auth.ErrKeyNotFound)
+	assert.ErrorIs(t, store.Touch(ctx, "nope", epoch), auth.ErrKeyNotFound)
+}
+
+func TestAPIKeyStore_DatabaseErrors(t *testing.T) {
+	store := newAPIKeyStore(t)
+	ctx := context.Background()
+	require.NoError(t, Close(store.db))
+
+	_, err := store.Get(ctx, "a")
+	assert.ErrorContains(t, err, "db: get api key")
+	_, err = store.List(ctx)
+	assert.ErrorContains(t, err, "db: list api keys")
+	assert.ErrorContains(t, store.Create(ctx, auth.APIKey{ID: "a", Hash: "h"}), "db: create api key")
+	assert.ErrorContains(t, store.Touch(ctx, "a", epoch), "db: update api key")
+	assert.ErrorContains(t, store.Migrate(ctx), "db: migrate api_keys")
+}
+
+func TestAPIKeyStore_WithAuthenticator(t *testing.T) {
+	keys := auth.NewAPIKeys(newAPIKeyStore(t), time.Minute)
+	ctx := context.Background()
+
+	plain, rec, err := keys.Mint(ctx, auth.MintRequest{Name: "gateway", Scopes: []string{"items:read"}})
+	require.NoError(t, err)
+	claims, err := keys.Authenticate(ctx, plain)
+	require.NoError(t, err)
+	assert.Equal(t, "apikey:"+rec.ID, claims.Subject)
+
+	require.NoError(t, keys.Revoke(ctx, rec.ID))
+	_, err = keys.Authenticate(ctx, plain)
+	assert.Error(t, err)
+}
+
+func TestOpen_UnsupportedDriver(t *testing.T) {
+	_, err := Open(config.DBConfig{Driver: "oracle"})
+
+	assert.ErrorContains(t, err, `unsupported driver "oracle"`)
+}
+
+func TestOpen_UnreachablePostgres(t *testing.T) {
+	_, err := Open(config.DBConfig{Driver: "postgres", DSN: "host=127.0.0.1 port=1 user=app dbname=app sslmode=disable connect_timeout=1"})
+
+	assert.ErrorContains(t, err, "db: open postgres")
+}
+
+func TestPingAndClose_WithoutConnectionPool(t *testing.T) {
+	gdb := &gorm.DB{Config: &gorm.Config{}}
+
+	assert.ErrorIs(t, Ping(context.Background(), gdb), gorm.ErrInvalidDB)
+	assert.ErrorIs(t, Close(gdb), gorm.ErrInvalidDB)
+}
@@ -1 +1,54 @@
+// Package db connects to the database configured in config.DBConfig with
+// GORM and implements the repositories that need persistent storage.
 package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"template-go/internal/config"
+)
+
+// Open connects to the database selected by cfg.Driver, "postgres" or
+// "sqlite". The sqlite driver is pure Go, so builds do not need CGO.
+// GORM's own logging is silenced; errors are returned to callers, which log
+// them with request context.
+func Open(cfg config.DBConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case "postgres":
+		dialector = postgres.Open(cfg.DSN)
+	case "sqlite":
+		dialector = sqlite.Open(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("db: unsupported driver %q", cfg.Driver)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		return nil, fmt.Errorf("db: open %s: %w", cfg.Driver, err)
+	}
+	return db, nil
+}
+
+// Ping reports whether the database is reachable; use it as a health check.
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the connection pool of db.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
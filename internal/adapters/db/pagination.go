@@ -0,0 +1,70 @@
+package db
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"template-go/pkg/pagination"
+)
+
+// Paginate applies q to tx: its filters, the keyset condition selecting the
+// rows after the cursor, its order and a limit of one row more than
+// q.Limit, so that pagination.NewPage can tell whether a next page exists.
+//
+//	var rows []Order
+//	err := db.Paginate(tx.Model(&Order{}), q).Find(&rows).Error
+//	page := pagination.NewPage(q, rows, orderField)
+func Paginate(tx *gorm.DB, q pagination.Query) *gorm.DB {
+	for _, f := range q.Filters {
+		tx = tx.Where(filterExpr(f))
+	}
+	if q.After != nil {
+		tx = tx.Where(afterExpr(q.Sort, q.After))
+	}
+	order := clause.OrderBy{Columns: make([]clause.OrderByColumn, len(q.Sort))}
+	for i, s := range q.Sort {
+		order.Columns[i] = clause.OrderByColumn{Column: clause.Column{Name: s.Column}, Desc: s.Desc}
+	}
+	return tx.Clauses(order).Limit(q.Limit + 1)
+}
+
+func filterExpr(f pagination.Filter) clause.Expression {
+	col := clause.Column{Name: f.Column}
+	switch f.Op {
+	case pagination.Ne:
+		return clause.Neq{Column: col, Value: f.Values[0]}
+	case pagination.Lt:
+		return clause.Lt{Column: col, Value: f.Values[0]}
+	case pagination.Lte:
+		return clause.Lte{Column: col, Value: f.Values[0]}
+	case pagination.Gt:
+		return clause.Gt{Column: col, Value: f.Values[0]}
+	case pagination.Gte:
+		return clause.Gte{Column: col, Value: f.Values[0]}
+	case pagination.In:
+		return clause.IN{Column: col, Values: f.Values}
+	default:
+		return clause.Eq{Column: col, Value: f.Values[0]}
+	}
+}
+
+// afterExpr selects the rows ordered after the row with sort values after:
+// for sort a, b it is (a > x) OR (a = x AND b > y), with < for descending
+// fields.
+func afterExpr(sort []pagination.Sort, after []any) clause.Expression {
+	var or []clause.Expression
+	for i, s := range sort {
+		and := make([]clause.Expression, 0, i+1)
+		for j := range i {
+			and = append(and, clause.Eq{Column: clause.Column{Name: sort[j].Column}, Value: after[j]})
+		}
+		col := clause.Column{Name: s.Column}
+		if s.Desc {
+			and = append(and, clause.Lt{Column: col, Value: after[i]})
+		} else {
+			and = append(and, clause.Gt{Column: col, Value: after[i]})
+		}
+		or = append(or, clause.And(and...))
+	}
+	return clause.Or(or...)
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"template-go/internal/config"
+	"template-go/pkg/pagination"
+)
+
+type order struct {
+	ID        int64 `gorm:"primaryKey"`
+	Status    string
+	Total     float64
+	CreatedAt time.Time
+}
+
+func orderField(o order, field string) any {
+	switch field {
+	case "id":
+		return o.ID
+	case "status":
+		return o.Status
+	case "total":
+		return o.Total
+	default:
+		return o.CreatedAt
+	}
+}
+
+// newOrders returns a database with 10 orders: ids 1 to 10, created in
+// pairs sharing a timestamp, with status "new" for odd ids.
+func newOrders(t *testing.T) *gorm.DB {
+	t.Helper()
+	gdb, err := Open(config.DBConfig{Driver: "sqlite", DSN: filepath.Join(t.TempDir(), "test.db")})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = Close(gdb) })
+	require.NoError(t, gdb.AutoMigrate(&order{}))
+	for id := int64(1); id <= 10; id++ {
+		status := "paid"
+		if id%2 == 1 {
+			status = "new"
+		}
+		created := epoch.Add(time.Duration((id-1)/2) * time.Hour)
+		require.NoError(t, gdb.Create(&order{ID: id, Status: status, Total: float64(id * 10), CreatedAt: created}).Error)
+	}
+	return gdb
+}
+
+func newOrderPaginator(t *testing.T) *pagination.Paginator {
+	t.Helper()
+	p, err := pagination.New([]byte("secret"), pagination.Config{
+		Fields: []pagination.Field{
+			{Name: "id", Kind: pagination.Int, Sortable: true, Filterable: true},
+			{Name: "status", Filterable: true, Sortable: true},
+			{Name: "total", Kind: pagination.Float, Filterable: true},
+			{Name: "created", Column: "created_at", Kind: pagination.Time, Sortable: true, Filterable: true},
+		},
+		Key:         "id",
+		DefaultSort: "-created",
+	})
+	require.NoError(t, err)
+	return p
+}
+
+// walk fetches every page of the query string and returns the ids in order.
+func walk(t *testing.T, gdb *gorm.DB, p *pagination.Paginator, query string) []int64 {
+	t.Helper()
+	var ids []int64
+	for range 20 {
+		q, err := p.Parse(httptest.NewRequest("GET", "/orders?"+query, nil))
+		require.NoError(t, err)
+		var rows []order
+		require.NoError(t, Paginate(gdb.Model(&order{}), q).Find(&rows).Error)
+		page := pagination.NewPage(q, rows, orderField)
+		for _, o := range page.Items {
+			ids = append(ids, o.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		values, _ := url.ParseQuery(query)
+		values.Set("cursor", page.NextCursor)
+		query = values.Encode()
+	}
+	t.Fatal("pagination did not end")
+	return nil
+}
+
+func TestPaginate(t *testing.T) {
+	gdb := newOrders(t)
+	p := newOrderPaginator(t)
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"limit=3", []int64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}},
+		{"limit=3&sort=created", []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"limit=4&sort=status,-id", []int64{9, 7, 5, 3, 1, 10, 8, 6, 4, 2}},
+		{"limit=2&filter=status:eq:new", []int64{9, 7, 5, 3, 1}},
+		{"limit=2&filter=total:gte:30&filter=total:lt:70", []int64{6, 5, 4, 3}},
+		{"limit=2&sort=id&filter=status:in:new|void&filter=id:ne:3", []int64{1, 5, 7, 9}},
+		{"limit=3&sort=id&filter=id:lte:4", []int64{1, 2, 3, 4}},
+		{"limit=5&sort=id&filter=created:gt:" + epoch.Add(3*time.Hour).Format(time.RFC3339), []int64{9, 10}},
+		{"limit=100", []int64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, walk(t, gdb, p, tt.query))
+		})
+	}
+}
+
+func TestPaginate_LimitsRows(t *testing.T) {
+	gdb := newOrders(t)
+	q, err := newOrderPaginator(t).Parse(httptest.NewRequest("GET", "/orders?limit=4", nil))
+	require.NoError(t, err)
+
+	var rows []order
+	require.NoError(t, Paginate(gdb.Model(&order{}), q).Find(&rows).Error)
+
+	ids := make([]int64, len(rows))
+	for i, o := range rows {
+		ids[i] = o.ID
+	}
+	assert.Equal(t, []int64{10, 9, 8, 7, 6}, ids, "one extra row tells that a next page exists")
+}
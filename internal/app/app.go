@@ -0,0 +1,177 @@
+// Package app starts and stops the long-lived parts of the service in
+// dependency order.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Component is a part of the service with a lifecycle. Start must return once
+// the component is ready to be used by its dependents; long-running work
+// belongs in goroutines stopped by Stop.
+type Component interface {
+	// Name identifies the component in dependencies and errors.
+	Name() string
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Dependent is implemented by components that must start after others.
+type Dependent interface {
+	// DependsOn returns the names of the components to start first.
+	DependsOn() []string
+}
+
+// App manages a set of components. Components start in dependency order,
+// falling back to registration order, and stop in reverse start order.
+type App struct {
+	components   []Component
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+
+	started []Component
+}
+
+// Option customises an App.
+type Option func(*App)
+
+// WithStartTimeout bounds the Start call of each component. Zero means no limit.
+func WithStartTimeout(d time.Duration) Option {
+	return func(a *App) { a.startTimeout = d }
+}
+
+// WithStopTimeout bounds the Stop call of each component. Zero means no limit.
+func WithStopTimeout(d time.Duration) Option {
+	return func(a *App) { a.stopTimeout = d }
+}
+
+// New returns an App without components.
+func New(opts ...Option) *App {
+	a := &App{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Add registers components. It must be called before Start.
+func (a *App) Add(components ...Component) {
+	a.components = append(a.components, components...)
+}
+
+// Start starts every component in dependency order. If one fails, the
+// components already started are stopped again and the error is returned.
+func (a *App) Start(ctx context.Context) error {
+	order, err := sortComponents(a.components)
+	if err != nil {
+		return err
+	}
+	for _, c := range order {
+		if err := a.call(ctx, a.startTimeout, c.Start); err != nil {
+			err = fmt.Errorf("starting %s: %w", c.Name(), err)
+			return errors.Join(err, a.Stop(context.WithoutCancel(ctx)))
+		}
+		a.started = append(a.started, c)
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order. Every component is
+// stopped even if another one fails; all errors are returned joined.
+func (a *App) Stop(ctx context.Context) error {
+	var errs []error
+	for i := len(a.started) - 1; i >= 0; i-- {
+		c := a.started[i]
+		if err := a.call(ctx, a.stopTimeout, c.Stop); err != nil {
+			errs = append(errs, fmt.Errorf("stopping %s: %w", c.Name(), err))
+		}
+	}
+	a.started = nil
+	return errors.Join(errs...)
+}
+
+// Run starts the components, waits for ctx to be done and stops them.
+func (a *App) Run(ctx context.Context) error {
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return a.Stop(context.WithoutCancel(ctx))
+}
+
+// call runs fn with timeout applied to ctx. It returns when fn does or when
+// the timeout expires, whichever is first, so a stuck component cannot block
+// the whole lifecycle.
+func (a *App) call(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %s: %w", timeout, ctx.Err())
+	}
+}
+
+// sortComponents orders components so that each one comes after its
+// dependencies, keeping registration order where there is a choice.
+func sortComponents(components []Component) ([]Component, error) {
+	byName := make(map[string]Component, len(components))
+	for _, c := range components {
+		if _, dup := byName[c.Name()]; dup {
+			return nil, fmt.Errorf("duplicate component %q", c.Name())
+		}
+		byName[c.Name()] = c
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(components))
+	order := make([]Component, 0, len(components))
+
+	var visit func(c Component, path []string) error
+	visit = func(c Component, path []string) error {
+		switch state[c.Name()] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path, " -> "), c.Name())
+		}
+		state[c.Name()] = visiting
+		path = append(path, c.Name())
+		if d, ok := c.(Dependent); ok {
+			for _, dep := range d.DependsOn() {
+				next, ok := byName[dep]
+				if !ok {
+					return fmt.Errorf("component %q depends on unknown component %q", c.Name(), dep)
+				}
+				if err := visit(next, path); err != nil {
+					return err
+				}
+			}
+		}
+		state[c.Name()] = done
+		order = append(order, c)
+		return nil
+	}
+
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
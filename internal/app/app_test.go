@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder collects lifecycle events in the order they happen.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// component returns a Func component that records its start and stop.
+func (r *recorder) component(name string, startErr, stopErr error, deps ...string) Component {
+	return Func(name,
+		func(context.Context) error { r.add("start " + name); return startErr },
+		func(context.Context) error { r.add("stop " + name); return stopErr },
+		deps...)
+}
+
+func TestApp_StartsInDependencyOrderAndStopsInReverse(t *testing.T) {
+	// GIVEN components registered before their dependencies
+	rec := &recorder{}
+	a := New()
+	a.Add(
+		rec.component("server", nil, nil, "db", "otel"),
+		rec.component("db", nil, nil, "logger"),
+		rec.component("otel", nil, nil),
+		rec.component("logger", nil, nil),
+	)
+
+	// WHEN the app is started and stopped
+	require.NoError(t, a.Start(context.Background()))
+	require.NoError(t, a.Stop(context.Background()))
+
+	// THEN dependencies start first and everything stops in reverse
+	assert.Equal(t, []string{
+		"start logger", "start db", "start otel", "start server",
+		"stop server", "stop otel", "stop db", "stop logger",
+	}, rec.events)
+}
+
+func TestApp_StartFailureStopsStartedComponents(t *testing.T) {
+	// GIVEN a component that fails to start
+	rec := &recorder{}
+	a := New()
+	a.Add(
+		rec.component("logger", nil, nil),
+		rec.component("db", errors.New("connection refused"), nil),
+		rec.component("server", nil, nil),
+	)
+
+	// WHEN the app is started
+	err := a.Start(context.Background())
+
+	// THEN the error names the component and earlier ones are stopped
+	assert.ErrorContains(t, err, "starting db: connection refused")
+	assert.Equal(t, []string{"start logger", "start db", "stop logger"}, rec.events)
+}
+
+func TestApp_StopAggregatesErrors(t *testing.T) {
+	// GIVEN two components that fail to stop
+	rec := &recorder{}
+	errA, errB := errors.New("a failed"), errors.New("b failed")
+	a := New()
+	a.Add(rec.component("a", nil, errA), rec.component("b", nil, errB), rec.component("c", nil, nil))
+	require.NoError(t, a.Start(context.Background()))
+
+	// WHEN the app is stopped
+	err := a.Stop(context.Background())
+
+	// THEN every component is stopped and both errors are reported
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+	assert.Equal(t, []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}, rec.events)
+}
+
+func TestApp_StartTimeout(t *testing.T) {
+	// GIVEN a component whose start never finishes
+	block := make(chan struct{})
+	defer close(block)
+	a := New(WithStartTimeout(10 * time.Millisecond))
+	a.Add(Func("slow", func(context.Context) error { <-block; return nil }, nil))
+
+	// WHEN the app is started
+	err := a.Start(context.Background())
+
+	// THEN it gives up after the timeout
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "starting slow")
+}
+
+func TestApp_StopTimeout(t *testing.T) {
+	// GIVEN a component that honours its context on stop
+	a := New(WithStopTimeout(10 * time.Millisecond))
+	a.Add(Func("slow", nil, func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }))
+	require.NoError(t, a.Start(context.Background()))
+
+	// WHEN the app is stopped
+	err := a.Stop(context.Background())
+
+	// THEN the stop is bounded by the timeout
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestApp_StopWithinTimeout(t *testing.T) {
+	// GIVEN a component without a stop function and a stop timeout
+	a := New(WithStopTimeout(time.Second))
+	a.Add(Func("quick", nil, nil))
+	require.NoError(t, a.Start(context.Background()))
+
+	// WHEN the app is stopped THEN it returns without waiting for the timeout
+	assert.NoError(t, a.Stop(context.Background()))
+}
+
+func TestApp_InvalidDependencies(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []Component
+		wantErr    string
+	}{
+		{
+			name:       "unknown dependency",
+			components: []Component{Func("a", nil, nil, "missing")},
+			wantErr:    `component "a" depends on unknown component "missing"`,
+		},
+		{
+			name:       "cycle",
+			components: []Component{Func("a", nil, nil, "b"), Func("b", nil, nil, "a")},
+			wantErr:    "dependency cycle: a -> b -> a",
+		},
+		{
+			name:       "duplicate name",
+			components: []Component{Func("a", nil, nil), Func("a", nil, nil)},
+			wantErr:    `duplicate component "a"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New()
+			a.Add(tt.components...)
+
+			assert.EqualError(t, a.Start(context.Background()), tt.wantErr)
+		})
+	}
+}
+
+func TestApp_Run(t *testing.T) {
+	// GIVEN an app with one component
+	rec := &recorder{}
+	a := New()
+	a.Add(rec.component("worker", nil, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// WHEN it runs until the context is cancelled
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+	assert.Eventually(t, func() bool {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		return len(rec.events) == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+
+	// THEN the component is started and stopped
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"start worker", "stop worker"}, rec.events)
+}
+
+func TestApp_RunStartFailure(t *testing.T) {
+	a := New()
+	a.Add(Func("broken", func(context.Context) error { return errors.New("boom") }, nil))
+
+	err := a.Run(context.Background())
+
+	assert.ErrorContains(t, err, "starting broken: boom")
+}
+
+func TestAfter(t *testing.T) {
+	c := After(Func("server", nil, nil, "logger"), "otel")
+
+	assert.Equal(t, "server", c.Name())
+	assert.Equal(t, []string{"logger", "otel"}, c.(Dependent).DependsOn())
+}
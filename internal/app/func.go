@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"slices"
+)
+
+// funcComponent adapts plain functions to Component.
+type funcComponent struct {
+	name  string
+	start func(context.Context) error
+	stop  func(context.Context) error
+	deps  []string
+}
+
+// Func returns a Component named name that calls start and stop. Either
+// function may be nil. dependsOn lists components that must start first.
+func Func(name string, start, stop func(context.Context) error, dependsOn ...string) Component {
+	return &funcComponent{name: name, start: start, stop: stop, deps: dependsOn}
+}
+
+// Name implements Component.
+func (c *funcComponent) Name() string { return c.name }
+
+// Start implements Component.
+func (c *funcComponent) Start(ctx context.Context) error {
+	if c.start == nil {
+		return nil
+	}
+	return c.start(ctx)
+}
+
+// Stop implements Component.
+func (c *funcComponent) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	return c.stop(ctx)
+}
+
+// DependsOn implements Dependent.
+func (c *funcComponent) DependsOn() []string { return c.deps }
+
+// dependent adds dependencies to a component it wraps.
+type dependent struct {
+	Component
+	deps []string
+}
+
+// After returns c with deps added to the components it must start after.
+func After(c Component, deps ...string) Component {
+	if d, ok := c.(Dependent); ok {
+		deps = slices.Concat(d.DependsOn(), deps)
+	}
+	return &dependent{Component: c, deps: deps}
+}
+
+// DependsOn implements Dependent.
+func (d *dependent) DependsOn() []string { return d.deps }
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+// APIKeyPrefix starts every API key so leaked keys are easy to recognise,
+// e.g. by secret scanners.
+const APIKeyPrefix = "tg_"
+
+// apiKeySubjectPrefix starts the subject of claims issued for API keys.
+const apiKeySubjectPrefix = "apikey:"
+
+// ErrKeyNotFound is returned by KeyStore implementations for unknown IDs.
+var ErrKeyNotFound = errors.New("auth: api key not found")
+
+// APIKey describes an issued key. Only the SHA-256 hash of its secret is
+// stored; the plaintext key is shown once, when it is minted.
+type APIKey struct {
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	// Hash is the hex encoded SHA-256 of the secret part of the key.
+	Hash     string   `json:"-" yaml:"hash"`
+	Scopes   []string `json:"scopes" yaml:"scopes"`
+	TenantID string   `json:"tenant_id,omitempty" yaml:"tenant_id,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at" yaml:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" yaml:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" yaml:"last_used_at,omitempty"`
+} // @name APIKey
+
+// Active reports whether k may be used at now.
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// KeyStore persists API keys. Implementations must be safe for concurrent
+// use and return ErrKeyNotFound for unknown IDs.
+type KeyStore interface {
+	Get(ctx context.Context, id string) (APIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Create(ctx context.Context, key APIKey) error
+	Revoke(ctx context.Context, id string, at time.Time) error
+	// Touch records that the key was used at at.
+	Touch(ctx context.Context, id string, at time.Time) error
+}
+
+// MintRequest describes a key to issue.
+type MintRequest struct {
+	Name     string
+	Scopes   []string
+	TenantID string
+	// ExpiresAt is optional; keys without it never expire.
+	ExpiresAt *time.Time
+}
+
+// APIKeys authenticates API keys against a KeyStore and issues new ones.
+type APIKeys struct {
+	store         KeyStore
+	touchInterval time.Duration
+	now           func() time.Time
+	rand          io.Reader // source of key IDs and secrets
+}
+
+// NewAPIKeys returns an authenticator backed by store. The last-used time
+// of a key is written at most once per touchInterval.
+func NewAPIKeys(store KeyStore, touchInterval time.Duration) *APIKeys {
+	return &APIKeys{store: store, touchInterval: touchInterval, now: time.Now, rand: rand.Reader}
+}
+
+// Authenticate verifies key and returns claims for it: the subject is
+// "apikey:<id>" and the scope and tenant are those of the key. Unknown,
+// expired and revoked keys fail with CodeUnauthenticated.
+func (a *APIKeys) Authenticate(ctx context.Context, key string) (*Claims, error) {
+	invalid := func(cause error) error {
+		return apperr.Wrap(cause, apperr.CodeUnauthenticated, "The API key is invalid, expired or revoked.")
+	}
+	id, secret, ok := splitAPIKey(key)
+	if !ok {
+		return nil, invalid(errors.New("auth: malformed api key"))
+	}
+	k, err := a.store.Get(ctx, id)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, invalid(err)
+	}
+	if err != nil {
+		return nil, apperr.Wrap(err, apperr.CodeUnavailable, "API keys cannot be verified right now.")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(k.Hash)) != 1 {
+		return nil, invalid(fmt.Errorf("auth: api key %s: secret mismatch", id))
+	}
+	now := a.now()
+	if !k.Active(now) {
+		return nil, invalid(fmt.Errorf("auth: api key %s is expired or revoked", id))
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= a.touchInterval {
+		if err := a.store.Touch(ctx, id, now); err != nil {
+			logger.Warn(ctx, "failed to record API key use", zap.String("api_key_id", id), zap.Error(err))
+		}
+	}
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: apiKeySubjectPrefix + id},
+		Scope:            strings.Join(k.Scopes, " "),
+		TenantID:         k.TenantID,
+	}, nil
+}
+
+// Mint issues a key for req and returns the plaintext key with its record.
+func (a *APIKeys) Mint(ctx context.Context, req MintRequest) (string, APIKey, error) {
+	id, secret := make([]byte, 6), make([]byte, 32)
+	if _, err := io.ReadFull(a.rand, id); err != nil {
+		return "", APIKey{}, err
+	}
+	if _, err := io.ReadFull(a.rand, secret); err != nil {
+		return "", APIKey{}, err
+	}
+	k := APIKey{
+		ID:        hex.EncodeToString(id),
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		TenantID:  req.TenantID,
+		CreatedAt: a.now().UTC().Truncate(time.Second),
+		ExpiresAt: req.ExpiresAt,
+	}
+	plain := base64.RawURLEncoding.EncodeToString(secret)
+	k.Hash = hashSecret(plain)
+	if err := a.store.Create(ctx, k); err != nil {
+		return "", APIKey{}, err
+	}
+	return APIKeyPrefix + k.ID + "_" + plain, k, nil
+}
+
+// Revoke revokes the key with the given ID.
+func (a *APIKeys) Revoke(ctx context.Context, id string) error {
+	return a.store.Revoke(ctx, id, a.now().UTC())
+}
+
+// List returns every key, including expired and revoked ones.
+func (a *APIKeys) List(ctx context.Context) ([]APIKey, error) {
+	return a.store.List(ctx)
+}
+
+// splitAPIKey splits "tg_<id>_<secret>" into its ID and secret.
+func splitAPIKey(key string) (id, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(key, APIKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, secret, ok = strings.Cut(rest, "_")
+	return id, secret, ok && id != "" && secret != ""
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileKeyStore keeps API keys in a YAML file:
+//
+//	keys:
+//	  - id: 3f9a1c2b7d4e
+//	    name: edge-gateway-eu
+//	    hash: <hex SHA-256 of the secret>
+//	    scopes: [items:read]
+//	    created_at: 2024-01-01T00:00:00Z
+//
+// Minted and revoked keys are written back to the file. Last-used times
+// are only kept in memory, so the file is not rewritten on every request.
+type FileKeyStore struct {
+	path string
+
+	mu   sync.Mutex
+	keys []APIKey
+}
+
+// NewFileKeyStore loads the keys in the file at path. A missing file is an
+// empty store; it is created when the first key is minted.
+func NewFileKeyStore(path string) (*FileKeyStore, error) {
+	s := &FileKeyStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: read api keys: %w", err)
+	}
+	var doc struct {
+		Keys []APIKey `yaml:"keys"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("auth: parse api keys %s: %w", path, err)
+	}
+	for _, k := range doc.Keys {
+		if k.ID == "" || k.Hash == "" || strings.Contains(k.ID, "_") {
+			return nil, fmt.Errorf("auth: api keys %s: key %q needs an id without underscores and a hash", path, k.Name)
+		}
+	}
+	s.keys = doc.Keys
+	return s, nil
+}
+
+// Get implements KeyStore.
+func (s *FileKeyStore) Get(_ context.Context, id string) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.index(id); i >= 0 {
+		return s.keys[i], nil
+	}
+	return APIKey{}, ErrKeyNotFound
+}
+
+// List implements KeyStore.
+func (s *FileKeyStore) List(context.Context) ([]APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.keys), nil
+}
+
+// Create implements KeyStore.
+func (s *FileKeyStore) Create(_ context.Context, key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index(key.ID) >= 0 {
+		return fmt.Errorf("auth: api key %s already exists", key.ID)
+	}
+	return s.save(append(slices.Clone(s.keys), key))
+}
+
+// Revoke implements KeyStore.
+func (s *FileKeyStore) Revoke(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return ErrKeyNotFound
+	}
+	if s.keys[i].RevokedAt != nil {
+		return nil
+	}
+	keys := slices.Clone(s.keys)
+	keys[i].RevokedAt = &at
+	return s.save(keys)
+}
+
+// Touch implements KeyStore. The time is not persisted.
+func (s *FileKeyStore) Touch(_ context.Context, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return ErrKeyNotFound
+	}
+	s.keys[i].LastUsedAt = &at
+	return nil
+}
+
+func (s *FileKeyStore) index(id string) int {
+	return slices.IndexFunc(s.keys, func(k APIKey) bool { return k.ID == id })
+}
+
+// marshalKeys encodes the key file. It is a variable so tests can make it
+// fail.
+var marshalKeys = yaml.Marshal
+
+// save atomically replaces the file with keys and then adopts them; s.mu
+// must be held.
+func (s *FileKeyStore) save(keys []APIKey) error {
+	persisted := make([]APIKey, len(keys))
+	for i, k := range keys {
+		k.LastUsedAt = nil
+		persisted[i] = k
+	}
+	data, err := marshalKeys(map[string][]APIKey{"keys": persisted})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".api_keys-*")
+	if err != nil {
+		return fmt.Errorf("auth: write api keys: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("auth: write api keys: %w", err)
+	}
+	s.keys = keys
+	return nil
+}
@@ -0,0 +1,288 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"template-go/pkg/apperr"
+)
+
+func newFileStore(t *testing.T) (*FileKeyStore, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "api_keys.yaml")
+	store, err := NewFileKeyStore(path)
+	require.NoError(t, err)
+	return store, path
+}
+
+func newTestAPIKeys(store KeyStore, clock *time.Time) *APIKeys {
+	a := NewAPIKeys(store, time.Minute)
+	a.now = func() time.Time { return *clock }
+	return a
+}
+
+func requireUnauthenticated(t *testing.T, err error) {
+	t.Helper()
+	var appErr *apperr.Error
+	require.True(t, errors.As(err, &appErr), "got %v", err)
+	assert.Equal(t, apperr.CodeUnauthenticated, appErr.Code)
+}
+
+func TestAPIKeys_MintAndAuthenticate(t *testing.T) {
+	// GIVEN a freshly minted key
+	store, _ := newFileStore(t)
+	clock := now
+	keys := newTestAPIKeys(store, &clock)
+	plain, rec, err := keys.Mint(context.Background(), MintRequest{Name: "edge-eu", Scopes: []string{"items:read", "items:write"}, TenantID: "acme"})
+	require.NoError(t, err)
+
+	// WHEN it is presented
+	claims, err := keys.Authenticate(context.Background(), plain)
+
+	// THEN the caller gets the key's scopes and tenant
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plain, APIKeyPrefix+rec.ID+"_"))
+	assert.Equal(t, "apikey:"+rec.ID, claims.Subject)
+	assert.Equal(t, rec.ID, claims.APIKeyID())
+	assert.True(t, claims.HasScope("items:write"))
+	assert.Equal(t, "acme", claims.TenantID)
+
+	// AND only the hash of the secret is stored
+	stored, err := store.Get(context.Background(), rec.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, plain, stored.Hash)
+	assert.Len(t, stored.Hash, 64)
+}
+
+func TestAPIKeys_Rejects(t *testing.T) {
+	store, _ := newFileStore(t)
+	clock := now
+	keys := newTestAPIKeys(store, &clock)
+	ctx := context.Background()
+	expiry := now.Add(time.Hour)
+	expiring, _, err := keys.Mint(ctx, MintRequest{Name: "expiring", ExpiresAt: &expiry})
+	require.NoError(t, err)
+	revoked, rec, err := keys.Mint(ctx, MintRequest{Name: "revoked"})
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(ctx, rec.ID))
+	valid, _, err := keys.Mint(ctx, MintRequest{Name: "valid"})
+	require.NoError(t, err)
+	id, _, _ := splitAPIKey(valid)
+
+	clock = now.Add(2 * time.Hour)
+	for name, key := range map[string]string{
+		"expired":        expiring,
+		"revoked":        revoked,
+		"wrong secret":   APIKeyPrefix + id + "_not-the-secret",
+		"unknown id":     APIKeyPrefix + "000000000000_secret",
+		"missing prefix": strings.TrimPrefix(valid, APIKeyPrefix),
+		"missing secret": APIKeyPrefix + id + "_",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := keys.Authenticate(ctx, key)
+			requireUnauthenticated(t, err)
+		})
+	}
+}
+
+func TestAPIKeys_TouchesAtMostOncePerInterval(t *testing.T) {
+	store, _ := newFileStore(t)
+	clock := now
+	keys := newTestAPIKeys(store, &clock)
+	plain, rec, err := keys.Mint(context.Background(), MintRequest{Name: "k"})
+	require.NoError(t, err)
+	lastUsed := func() time.Time {
+		k, err := store.Get(context.Background(), rec.ID)
+		require.NoError(t, err)
+		require.NotNil(t, k.LastUsedAt)
+		return *k.LastUsedAt
+	}
+
+	_, err = keys.Authenticate(context.Background(), plain)
+	require.NoError(t, err)
+	assert.Equal(t, now, lastUsed())
+
+	clock = now.Add(30 * time.Second)
+	_, err = keys.Authenticate(context.Background(), plain)
+	require.NoError(t, err)
+	assert.Equal(t, now, lastUsed(), "within the touch interval")
+
+	clock = now.Add(time.Minute)
+	_, err = keys.Authenticate(context.Background(), plain)
+	require.NoError(t, err)
+	assert.Equal(t, clock, lastUsed())
+}
+
+type unavailableStore struct{ KeyStore }
+
+func (unavailableStore) Get(context.Context, string) (APIKey, error) {
+	return APIKey{}, errors.New("connection refused")
+}
+
+func TestAPIKeys_StoreFailure(t *testing.T) {
+	keys := NewAPIKeys(unavailableStore{}, time.Minute)
+
+	_, err := keys.Authenticate(context.Background(), APIKeyPrefix+"abc_secret")
+
+	var appErr *apperr.Error
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperr.CodeUnavailable, appErr.Code)
+}
+
+// failingWrites is a KeyStore whose writes fail.
+type failingWrites struct{ KeyStore }
+
+func (failingWrites) Create(context.Context, APIKey) error { return errors.New("read-only") }
+
+func (failingWrites) Touch(context.Context, string, time.Time) error { return errors.New("read-only") }
+
+func TestAPIKeys_StoreWriteFailures(t *testing.T) {
+	store, _ := newFileStore(t)
+	clock := now
+	plain, rec, err := newTestAPIKeys(store, &clock).Mint(context.Background(), MintRequest{Name: "k"})
+	require.NoError(t, err)
+	keys := newTestAPIKeys(failingWrites{store}, &clock)
+
+	_, err = keys.Authenticate(context.Background(), plain)
+	assert.NoError(t, err, "a failed touch does not reject the key")
+
+	_, _, err = keys.Mint(context.Background(), MintRequest{Name: "other"})
+	assert.EqualError(t, err, "read-only")
+
+	list, err := keys.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, rec.ID, list[0].ID)
+}
+
+func TestAPIKeys_MintRandomFailure(t *testing.T) {
+	store, _ := newFileStore(t)
+	clock := now
+	keys := newTestAPIKeys(store, &clock)
+	readers := map[string]io.Reader{
+		"id":     iotest.ErrReader(errors.New("no entropy")),
+		"secret": io.MultiReader(bytes.NewReader(make([]byte, 6)), iotest.ErrReader(errors.New("no entropy"))),
+	}
+	for name, r := range readers {
+		t.Run(name, func(t *testing.T) {
+			keys.rand = r
+
+			_, _, err := keys.Mint(context.Background(), MintRequest{Name: "k"})
+
+			assert.EqualError(t, err, "no entropy")
+		})
+	}
+	list, err := keys.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, list, "no key is stored")
+}
+
+func TestFileKeyStore_Persists(t *testing.T) {
+	// GIVEN a file store with a minted and a revoked key that were used
+	store, path := newFileStore(t)
+	clock := now
+	keys := newTestAPIKeys(store, &clock)
+	ctx := context.Background()
+	plain, kept, err := keys.Mint(ctx, MintRequest{Name: "kept", Scopes: []string{"items:read"}})
+	require.NoError(t, err)
+	_, gone, err := keys.Mint(ctx, MintRequest{Name: "gone"})
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(ctx, gone.ID))
+	_, err = keys.Authenticate(ctx, plain)
+	require.NoError(t, err)
+
+	// WHEN the file is loaded again
+	reloaded, err := NewFileKeyStore(path)
+	require.NoError(t, err)
+
+	// THEN keys and revocations survive, last-used times do not
+	list, err := reloaded.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, kept.ID, list[0].ID)
+	assert.Equal(t, []string{"items:read"}, list[0].Scopes)
+	assert.Nil(t, list[0].RevokedAt)
+	assert.Nil(t, list[0].LastUsedAt)
+	assert.NotNil(t, list[1].RevokedAt)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = newTestAPIKeys(reloaded, &clock).Authenticate(ctx, plain)
+	assert.NoError(t, err)
+}
+
+func TestFileKeyStore_Errors(t *testing.T) {
+	store, path := newFileStore(t)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, store.Revoke(ctx, "nope", now), ErrKeyNotFound)
+	assert.ErrorIs(t, store.Touch(ctx, "nope", now), ErrKeyNotFound)
+	require.NoError(t, store.Create(ctx, APIKey{ID: "a", Hash: "h"}))
+	assert.ErrorContains(t, store.Create(ctx, APIKey{ID: "a", Hash: "h"}), "already exists")
+
+	require.NoError(t, os.WriteFile(path, []byte("keys: [{id: a_b, hash: h}]"), 0o600))
+	_, err := NewFileKeyStore(path)
+	assert.ErrorContains(t, err, "without underscores")
+
+	require.NoError(t, os.WriteFile(path, []byte("keys: {"), 0o600))
+	_, err = NewFileKeyStore(path)
+	assert.ErrorContains(t, err, "auth: parse api keys")
+
+	_, err = NewFileKeyStore(t.TempDir())
+	assert.ErrorContains(t, err, "auth: read api keys")
+}
+
+func TestFileKeyStore_RevokeTwice(t *testing.T) {
+	store, _ := newFileStore(t)
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, APIKey{ID: "a", Hash: "h"}))
+	require.NoError(t, store.Revoke(ctx, "a", now))
+
+	require.NoError(t, store.Revoke(ctx, "a", now.Add(time.Hour)))
+
+	k, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, now, *k.RevokedAt, "the first revocation time is kept")
+}
+
+func TestFileKeyStore_WriteErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("marshal failure", func(t *testing.T) {
+		store, path := newFileStore(t)
+		marshalKeys = func(any) ([]byte, error) { return nil, errors.New("cannot marshal") }
+		t.Cleanup(func() { marshalKeys = yaml.Marshal })
+
+		assert.EqualError(t, store.Create(ctx, APIKey{ID: "a", Hash: "h"}), "cannot marshal")
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		store, err := NewFileKeyStore(filepath.Join(t.TempDir(), "missing", "api_keys.yaml"))
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, store.Create(ctx, APIKey{ID: "a", Hash: "h"}), "auth: write api keys")
+	})
+
+	t.Run("path replaced by a directory", func(t *testing.T) {
+		store, path := newFileStore(t)
+		require.NoError(t, os.MkdirAll(filepath.Join(path, "keep"), 0o700))
+
+		assert.ErrorContains(t, store.Create(ctx, APIKey{ID: "a", Hash: "h"}), "auth: write api keys")
+		_, err := store.Get(ctx, "a")
+		assert.ErrorIs(t, err, ErrKeyNotFound, "failed writes are not adopted")
+	})
+}
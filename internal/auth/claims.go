@@ -0,0 +1,64 @@
+// Package auth verifies JWT bearer tokens against keys from a JSON Web Key
+// Set or a shared secret and carries the resulting claims in the request
+// context.
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the token claims the service understands. Registered claims
+// (iss, sub, aud, exp, nbf, iat, jti) are validated by Verifier.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// Scope is the space separated OAuth 2.0 scope granted to the token.
+	Scope string `json:"scope,omitempty"`
+	// Roles are application roles assigned to the subject.
+	Roles []string `json:"roles,omitempty"`
+	// TenantID identifies the tenant the subject acts for.
+	TenantID string `json:"tenant_id,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// Scopes returns the individual scopes of c.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope reports whether c grants scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// HasRole reports whether c carries role.
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// APIKeyID returns the ID of the API key c was issued for, or "" when c
+// comes from a bearer token.
+func (c *Claims) APIKeyID() string {
+	id, ok := strings.CutPrefix(c.Subject, apiKeySubjectPrefix)
+	if !ok {
+		return ""
+	}
+	return id
+}
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx carrying c.
+func NewContext(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, c)
+}
+
+// FromContext returns the claims of the authenticated caller, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsKey{}).(*Claims)
+	return c, ok
+}
@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"template-go/pkg/logger"
+)
+
+// minRefreshInterval throttles refreshes triggered by unknown key IDs, so
+// tokens with made-up kids cannot hammer the identity provider.
+const minRefreshInterval = 30 * time.Second
+
+// maxJWKSSize bounds the key set document read from a URL.
+const maxJWKSSize = 1 << 20
+
+// ErrUnknownKey is returned when no key in the set matches a token.
+var ErrUnknownKey = errors.New("auth: no matching key")
+
+// KeySet caches the public keys of a JSON Web Key Set. Keys are reloaded
+// every refresh interval and, at most every 30 seconds, when a token names
+// a key ID the set does not contain, which picks up rotated keys. When a
+// reload fails the previous keys stay in use.
+//
+// Cached keys are served while a reload is in flight; only callers that
+// have no matching key wait for it, and concurrent callers share a single
+// download.
+type KeySet struct {
+	load     func(ctx context.Context) ([]byte, error)
+	interval time.Duration
+	now      func() time.Time
+	reloads  singleflight.Group
+
+	mu      sync.RWMutex
+	keys    map[string]PublicKey
+	fetched time.Time // last attempt, successful or not
+}
+
+// PublicKey is a verification key of a key set.
+type PublicKey struct {
+	Key crypto.PublicKey
+	// Alg restricts the key to one algorithm, e.g. "RS256"; empty allows
+	// any algorithm matching the key type.
+	Alg string
+}
+
+// NewRemoteKeySet returns a key set fetched from url with client.
+func NewRemoteKeySet(url string, client *http.Client, interval time.Duration) *KeySet {
+	return newKeySet(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	}, interval)
+}
+
+// NewFileKeySet returns a key set read from the file at path.
+func NewFileKeySet(path string, interval time.Duration) *KeySet {
+	return newKeySet(func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	}, interval)
+}
+
+func newKeySet(load func(context.Context) ([]byte, error), interval time.Duration) *KeySet {
+	return &KeySet{load: load, interval: interval, now: time.Now}
+}
+
+// Key returns the public key with the given ID for verifying a token
+// signed with alg. An empty kid matches the only key of a single-key set.
+// Keys published with an "alg" only match that algorithm.
+func (s *KeySet) Key(ctx context.Context, kid, alg string) (crypto.PublicKey, error) {
+	now := s.now()
+	keys, fetched := s.cached()
+	switch {
+	case keys == nil:
+		keys, fetched = s.reloadWait(ctx)
+	case now.Sub(fetched) >= s.interval:
+		s.reload(ctx)
+	}
+	key, ok := lookup(keys, kid)
+	if !ok && keys != nil && now.Sub(fetched) >= minRefreshInterval {
+		keys, _ = s.reloadWait(ctx)
+		key, ok = lookup(keys, kid)
+	}
+	switch {
+	case keys == nil:
+		return nil, fmt.Errorf("%w: key set could not be loaded", ErrUnknownKey)
+	case !ok:
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+	case key.Alg != "" && key.Alg != alg:
+		return nil, fmt.Errorf("%w: kid %q is for %s, not %s", ErrUnknownKey, kid, key.Alg, alg)
+	}
+	return key.Key, nil
+}
+
+// Refresh reloads the key set now.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	select {
+	case res := <-s.reload(ctx):
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check reports whether keys are available, loading them if they never
+// were. Use it as a health check; it does not hit the provider once keys
+// are cached.
+func (s *KeySet) Check(ctx context.Context) error {
+	if keys, _ := s.cached(); keys != nil {
+		return nil
+	}
+	return s.Refresh(ctx)
+}
+
+// cached returns the current keys and when they were last reloaded.
+func (s *KeySet) cached() (map[string]PublicKey, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys, s.fetched
+}
+
+func lookup(keys map[string]PublicKey, kid string) (PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, true
+		}
+	}
+	key, ok := keys[kid]
+	return key, ok
+}
+
+// reload starts reloading the keys unless a reload is already in flight,
+// and returns a channel receiving its outcome. The download is not bound
+// to ctx's cancellation, so a caller giving up does not abort it for the
+// others; the loader's own timeout bounds it.
+func (s *KeySet) reload(ctx context.Context) <-chan singleflight.Result {
+	ctx = context.WithoutCancel(ctx)
+	return s.reloads.DoChan("keys", func() (any, error) {
+		err := s.refresh(ctx)
+		if err != nil {
+			logger.Warn(ctx, "failed to refresh JSON Web Key Set; keeping previous keys", zap.Error(err))
+		}
+		return nil, err
+	})
+}
+
+// reloadWait reloads the keys and returns them once done or ctx is done.
+func (s *KeySet) reloadWait(ctx context.Context) (map[string]PublicKey, time.Time) {
+	select {
+	case <-s.reload(ctx):
+	case <-ctx.Done():
+	}
+	return s.cached()
+}
+
+// refresh downloads and parses the keys without holding s.mu, so cached
+// keys stay available meanwhile.
+func (s *KeySet) refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.fetched = s.now()
+	s.mu.Unlock()
+
+	data, err := s.load(ctx)
+	if err != nil {
+		return fmt.Errorf("auth: load key set: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+	return nil
+}
+
+// jwk is a single JSON Web Key (RFC 7517) of type RSA, EC or OKP.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes a JSON Web Key Set into public keys by key ID. Keys
+// meant for encryption ("use": "enc"), of unsupported types or malformed
+// are skipped; it fails only if the set has keys but none is usable.
+func ParseJWKS(data []byte) (map[string]PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("auth: parse key set: %w", err)
+	}
+	keys := make(map[string]PublicKey, len(set.Keys))
+	var errs []error
+	for _, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+		key, err := k.publicKey()
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("key %q: %w", k.Kid, err))
+		case key != nil:
+			keys[k.Kid] = PublicKey{Key: key, Alg: k.Alg}
+		}
+	}
+	if len(keys) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("auth: parse key set: %w", errors.Join(errs...))
+	}
+	return keys, nil
+}
+
+// publicKey converts k, returning nil for unsupported key types.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("invalid coordinates")
+		}
+		key, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
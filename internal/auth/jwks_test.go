@@ -0,0 +1,331 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// signingKey is a private key with the key ID it is published under.
+type signingKey struct {
+	kid string
+	key crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return signingKey{kid: kid, key: k}
+}
+
+func newECKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return signingKey{kid: kid, key: k}
+}
+
+func newEdKey(t *testing.T, kid string) signingKey {
+	t.Helper()
+	_, k, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return signingKey{kid: kid, key: k}
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// jwks renders the public halves of keys as a JSON Web Key Set.
+func jwks(t *testing.T, keys ...signingKey) []byte {
+	t.Helper()
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for _, k := range keys {
+		switch pub := k.key.Public().(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{"kty": "RSA", "kid": k.kid,
+				"n": b64(pub.N.Bytes()), "e": b64(big.NewInt(int64(pub.E)).Bytes())})
+		case *ecdsa.PublicKey:
+			raw, err := pub.Bytes()
+			require.NoError(t, err)
+			size := (len(raw) - 1) / 2
+			set.Keys = append(set.Keys, map[string]string{"kty": "EC", "kid": k.kid, "crv": pub.Curve.Params().Name,
+				"x": b64(raw[1 : 1+size]), "y": b64(raw[1+size:])})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{"kty": "OKP", "kid": k.kid, "crv": "Ed25519", "x": b64(pub)})
+		}
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+// jwksServer serves a key set that tests can replace, counting requests.
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	body     []byte
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, body []byte) *jwksServer {
+	t.Helper()
+	s := &jwksServer{body: body}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_, _ = w.Write(s.body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, ecKey, edKey := newRSAKey(t, "rsa"), newECKey(t, "ec"), newEdKey(t, "ed")
+
+	keys, err := ParseJWKS(jwks(t, rsaKey, ecKey, edKey))
+
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.True(t, rsaKey.key.Public().(*rsa.PublicKey).Equal(keys["rsa"].Key))
+	assert.True(t, ecKey.key.Public().(*ecdsa.PublicKey).Equal(keys["ec"].Key))
+	assert.True(t, edKey.key.Public().(ed25519.PublicKey).Equal(keys["ed"].Key))
+}
+
+func TestParseJWKS_Curves(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P384(), elliptic.P521()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			k, err := ecdsa.GenerateKey(curve, rand.Reader)
+			require.NoError(t, err)
+
+			keys, err := ParseJWKS(jwks(t, signingKey{kid: "ec", key: k}))
+
+			require.NoError(t, err)
+			assert.True(t, k.PublicKey.Equal(keys["ec"].Key))
+		})
+	}
+}
+
+func TestParseJWKS_SkipsUnusableKeys(t *testing.T) {
+	data := `{"keys":[
+		{"kty":"RSA","kid":"enc","use":"enc","n":"AQAB","e":"AQAB"},
+		{"kty":"oct","kid":"sym","k":"c2VjcmV0"},
+		{"kty":"EC","kid":"k1","crv":"secp256k1","x":"AA","y":"AA"},
+		{"kty":"OKP","kid":"ok","crv":"Ed25519","x":"` + b64(make([]byte, 32)) + `"}
+	]}`
+
+	keys, err := ParseJWKS([]byte(data))
+
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Contains(t, keys, "ok")
+}
+
+func TestParseJWKS_Errors(t *testing.T) {
+	tests := map[string]string{
+		"not json":          `{`,
+		"bad curve point":   `{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"` + b64(make([]byte, 32)) + `","y":"` + b64(make([]byte, 32)) + `"}]}`,
+		"bad rsa exponent":  `{"keys":[{"kty":"RSA","kid":"a","n":"AQAB","e":""}]}`,
+		"bad rsa modulus":   `{"keys":[{"kty":"RSA","kid":"a","n":"!","e":"AQAB"}]}`,
+		"bad coordinates":   `{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"!","y":"AA"}]}`,
+		"unsupported okp":   `{"keys":[{"kty":"OKP","kid":"a","crv":"X25519","x":"AA"}]}`,
+		"short ed25519 key": `{"keys":[{"kty":"OKP","kid":"a","crv":"Ed25519","x":"AA"}]}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseJWKS([]byte(data))
+			assert.ErrorContains(t, err, "auth: parse key set")
+		})
+	}
+}
+
+func TestKeySet_RefreshesOnUnknownKey(t *testing.T) {
+	// GIVEN a cached key set published by the identity provider
+	old, rotated := newRSAKey(t, "old"), newECKey(t, "new")
+	srv := newJWKSServer(t, jwks(t, old))
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	ctx := context.Background()
+	_, err := keys.Key(ctx, "old", "RS256")
+	require.NoError(t, err)
+
+	// WHEN the provider rotates its key a while later
+	srv.set(jwks(t, rotated))
+	now = now.Add(minRefreshInterval)
+
+	// THEN an unknown kid refreshes the set, at most every 30 seconds
+	_, err = keys.Key(ctx, "new", "ES256")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), srv.requests.Load())
+
+	_, err = keys.Key(ctx, "bogus", "RS256")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, int32(2), srv.requests.Load(), "refresh is throttled")
+
+	now = now.Add(minRefreshInterval)
+	_, err = keys.Key(ctx, "bogus", "RS256")
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Equal(t, int32(3), srv.requests.Load())
+}
+
+func TestKeySet_KeepsKeysWhenRefreshFails(t *testing.T) {
+	key := newEdKey(t, "")
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(t, key), 0o600))
+	keys := NewFileKeySet(path, time.Minute)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	require.NoError(t, keys.Check(context.Background()))
+
+	require.NoError(t, os.Remove(path))
+	now = now.Add(time.Hour)
+	got, err := keys.Key(context.Background(), "", "EdDSA")
+
+	require.NoError(t, err, "an empty kid matches the only key")
+	assert.True(t, key.key.Public().(ed25519.PublicKey).Equal(got))
+	assert.Error(t, keys.Refresh(context.Background()))
+	assert.NoError(t, keys.Check(context.Background()))
+}
+
+func TestKeySet_UnavailableProvider(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+
+	_, err := keys.Key(context.Background(), "any", "RS256")
+
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.ErrorContains(t, keys.Check(context.Background()), "404")
+}
+
+func TestKeySet_RemoteRequestErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	for name, url := range map[string]string{"invalid url": "http://idp\x7f/jwks.json", "unreachable": srv.URL} {
+		t.Run(name, func(t *testing.T) {
+			keys := NewRemoteKeySet(url, srv.Client(), time.Hour)
+
+			assert.Error(t, keys.Check(context.Background()))
+		})
+	}
+}
+
+func TestKeySet_ServesCachedKeysWhileRefreshing(t *testing.T) {
+	// GIVEN cached keys that are due for a refresh, and a provider that hangs
+	key := newRSAKey(t, "rsa")
+	release := make(chan struct{})
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > 1 {
+			<-release
+		}
+		_, _ = w.Write(jwks(t, key))
+	}))
+	defer srv.Close()
+	defer close(release)
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Minute)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	_, err := keys.Key(context.Background(), "rsa", "RS256")
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+
+	// WHEN tokens with the cached kid are verified during the refresh
+	done := make(chan error)
+	go func() {
+		for range 10 {
+			if _, err := keys.Key(context.Background(), "rsa", "RS256"); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	// THEN they do not wait for the provider
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Key blocked on the refresh")
+	}
+	// AND the stale set is reloaded once
+	assert.Eventually(t, func() bool { return requests.Load() == 2 }, 5*time.Second, time.Millisecond)
+	assert.NoError(t, keys.Check(context.Background()))
+}
+
+func TestKeySet_UnknownKidWaitIsBoundByContext(t *testing.T) {
+	// GIVEN a provider that hangs
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-release }))
+	defer srv.Close()
+	defer close(release)
+	keys := NewRemoteKeySet(srv.URL, srv.Client(), time.Hour)
+
+	// WHEN a caller without cached keys gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := keys.Key(ctx, "rsa", "RS256")
+
+	// THEN it returns without waiting for the download
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.ErrorIs(t, keys.Refresh(ctx), context.DeadlineExceeded)
+}
+
+func TestKeySet_MatchesAlgorithm(t *testing.T) {
+	// GIVEN a key published for RS384 only and one without an algorithm
+	pub := newRSAKey(t, "").key.Public().(*rsa.PublicKey)
+	n, e := b64(pub.N.Bytes()), b64(big.NewInt(int64(pub.E)).Bytes())
+	data := `{"keys":[{"kty":"RSA","kid":"pinned","alg":"RS384","n":"` + n + `","e":"` + e + `"},
+		{"kty":"RSA","kid":"any","n":"` + n + `","e":"` + e + `"}]}`
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	keys := NewFileKeySet(path, time.Hour)
+	ctx := context.Background()
+
+	// WHEN they are looked up for tokens of several algorithms
+	_, pinnedOK := keys.Key(ctx, "pinned", "RS384")
+	_, pinnedOther := keys.Key(ctx, "pinned", "RS256")
+	_, anyOK := keys.Key(ctx, "any", "RS512")
+
+	// THEN a key's "alg" must match the token's
+	assert.NoError(t, pinnedOK)
+	assert.ErrorIs(t, pinnedOther, ErrUnknownKey)
+	assert.ErrorContains(t, pinnedOther, "is for RS384, not RS256")
+	assert.NoError(t, anyOK)
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"template-go/internal/config"
+	"template-go/pkg/apperr"
+)
+
+// jwksTimeout bounds a single key set download.
+const jwksTimeout = 10 * time.Second
+
+// Verifier validates bearer tokens: the signature against the key set or
+// HMAC secret, the algorithm against the configured list, and the exp, nbf,
+// iat, iss and aud claims.
+type Verifier struct {
+	keys   *KeySet
+	secret []byte
+	parser *jwt.Parser
+	now    func() time.Time
+}
+
+// NewVerifier returns a verifier for cfg. Keys are loaded lazily on the
+// first token; call KeySet().Refresh to load them up front.
+func NewVerifier(cfg config.AuthConfig) (*Verifier, error) {
+	if len(cfg.Algorithms) == 0 {
+		return nil, errors.New("auth: no algorithms configured")
+	}
+	v := &Verifier{now: time.Now}
+	if cfg.HMACSecret != "" {
+		v.secret = []byte(cfg.HMACSecret)
+	}
+	switch {
+	case cfg.JWKSFile != "":
+		v.keys = NewFileKeySet(cfg.JWKSFile, cfg.JWKSRefreshInterval)
+	case cfg.JWKSURL != "":
+		v.keys = NewRemoteKeySet(cfg.JWKSURL, &http.Client{Timeout: jwksTimeout}, cfg.JWKSRefreshInterval)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithLeeway(cfg.ClockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(func() time.Time { return v.now() }),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if len(cfg.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(cfg.Audience...))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// KeySet returns the key set used for asymmetric algorithms, or nil when
+// only an HMAC secret is configured.
+func (v *Verifier) KeySet() *KeySet {
+	return v.keys
+}
+
+// Verify parses and validates token. Failures are apperr errors with code
+// CodeUnauthenticated; the reason is kept as the cause.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if strings.HasPrefix(t.Method.Alg(), "HS") {
+			if v.secret == nil {
+				return nil, errors.New("no HMAC secret configured")
+			}
+			return v.secret, nil
+		}
+		if v.keys == nil {
+			return nil, errors.New("no key set configured")
+		}
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid, t.Method.Alg())
+	})
+	if err != nil {
+		return nil, apperr.Wrap(fmt.Errorf("auth: %w", err), apperr.CodeUnauthenticated, "The access token is invalid or expired.")
+	}
+	return claims, nil
+}
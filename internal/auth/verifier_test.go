@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/config"
+	"template-go/pkg/apperr"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// sign returns a token for claims signed by k with the algorithm matching
+// its key type.
+func sign(t *testing.T, k signingKey, claims jwt.Claims) string {
+	t.Helper()
+	var method jwt.SigningMethod
+	switch k.key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		method = jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	}
+	tok := jwt.NewWithClaims(method, claims)
+	tok.Header["kid"] = k.kid
+	s, err := tok.SignedString(k.key)
+	require.NoError(t, err)
+	return s
+}
+
+func signHMAC(t *testing.T, secret string, claims jwt.Claims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return s
+}
+
+// now is the verifier's clock in these tests.
+var now = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func claimsAt(iat time.Time, ttl time.Duration) *Claims {
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://id.example.com/",
+			Subject:   "user-1",
+			Audience:  jwt.ClaimStrings{"template-go"},
+			IssuedAt:  jwt.NewNumericDate(iat),
+			ExpiresAt: jwt.NewNumericDate(iat.Add(ttl)),
+		},
+		Scope:    "items:read items:write",
+		Roles:    []string{"admin"},
+		TenantID: "acme",
+	}
+}
+
+func authConfig(jwksURL string) config.AuthConfig {
+	return config.AuthConfig{
+		Enabled:             true,
+		JWKSURL:             jwksURL,
+		JWKSRefreshInterval: time.Hour,
+		HMACSecret:          testSecret,
+		Algorithms:          []string{"RS256", "ES256", "EdDSA", "HS256"},
+		Issuer:              "https://id.example.com/",
+		Audience:            []string{"template-go", "other"},
+		ClockSkew:           30 * time.Second,
+	}
+}
+
+func newTestVerifier(t *testing.T, cfg config.AuthConfig) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(cfg)
+	require.NoError(t, err)
+	v.now = func() time.Time { return now }
+	if v.keys != nil {
+		v.keys.now = v.now
+	}
+	return v
+}
+
+func TestVerifier_Algorithms(t *testing.T) {
+	keys := []signingKey{newRSAKey(t, "rsa"), newECKey(t, "ec"), newEdKey(t, "ed")}
+	srv := newJWKSServer(t, jwks(t, keys...))
+	v := newTestVerifier(t, authConfig(srv.URL))
+
+	tokens := map[string]string{"HS256": signHMAC(t, testSecret, claimsAt(now, time.Hour))}
+	for _, k := range keys {
+		tokens[k.kid] = sign(t, k, claimsAt(now, time.Hour))
+	}
+	for name, token := range tokens {
+		t.Run(name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), token)
+
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.Subject)
+			assert.Equal(t, "acme", claims.TenantID)
+			assert.True(t, claims.HasScope("items:write"))
+			assert.False(t, claims.HasScope("items"))
+			assert.True(t, claims.HasRole("admin"))
+			assert.Empty(t, claims.APIKeyID())
+		})
+	}
+}
+
+func TestVerifier_Rejects(t *testing.T) {
+	key := newRSAKey(t, "rsa")
+	srv := newJWKSServer(t, jwks(t, key))
+	cfg := authConfig(srv.URL)
+	cfg.Algorithms = []string{"RS256", "ES256"}
+	v := newTestVerifier(t, cfg)
+
+	withClaims := func(edit func(*Claims)) *Claims {
+		c := claimsAt(now, time.Hour)
+		edit(c)
+		return c
+	}
+	tests := map[string]string{
+		"malformed":         "not-a-token",
+		"expired":           sign(t, key, claimsAt(now.Add(-2*time.Hour), time.Hour)),
+		"issued in future":  sign(t, key, claimsAt(now.Add(time.Minute), time.Hour)),
+		"no expiry":         sign(t, key, withClaims(func(c *Claims) { c.ExpiresAt = nil })),
+		"wrong issuer":      sign(t, key, withClaims(func(c *Claims) { c.Issuer = "https://evil.example.com/" })),
+		"wrong audience":    sign(t, key, withClaims(func(c *Claims) { c.Audience = jwt.ClaimStrings{"billing"} })),
+		"unknown key":       sign(t, newRSAKey(t, "rsa"), claimsAt(now, time.Hour)),
+		"unknown kid":       sign(t, newRSAKey(t, "other"), claimsAt(now, time.Hour)),
+		"algorithm not set": signHMAC(t, testSecret, claimsAt(now, time.Hour)),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), token)
+
+			var appErr *apperr.Error
+			require.True(t, errors.As(err, &appErr), "got %v", err)
+			assert.Equal(t, apperr.CodeUnauthenticated, appErr.Code)
+		})
+	}
+}
+
+func TestVerifier_ClockSkew(t *testing.T) {
+	v := newTestVerifier(t, config.AuthConfig{Algorithms: []string{"HS256"}, HMACSecret: testSecret, ClockSkew: 30 * time.Second})
+
+	_, err := v.Verify(context.Background(), signHMAC(t, testSecret, claimsAt(now.Add(-time.Hour-20*time.Second), time.Hour)))
+	assert.NoError(t, err, "expired within the skew")
+
+	_, err = v.Verify(context.Background(), signHMAC(t, testSecret, claimsAt(now.Add(20*time.Second), time.Hour)))
+	assert.NoError(t, err, "issued in the future within the skew")
+
+	_, err = v.Verify(context.Background(), signHMAC(t, testSecret, claimsAt(now.Add(-time.Hour-time.Minute), time.Hour)))
+	assert.Error(t, err)
+}
+
+func TestVerifier_SecretMismatch(t *testing.T) {
+	v := newTestVerifier(t, config.AuthConfig{Algorithms: []string{"HS256"}, HMACSecret: testSecret})
+
+	_, err := v.Verify(context.Background(), signHMAC(t, "another-secret-another-secret-xx", claimsAt(now, time.Hour)))
+
+	assert.ErrorContains(t, err, "signature is invalid")
+}
+
+func TestVerifier_MissingKeyMaterial(t *testing.T) {
+	v := newTestVerifier(t, config.AuthConfig{Algorithms: []string{"HS256", "RS256"}})
+
+	_, err := v.Verify(context.Background(), signHMAC(t, testSecret, claimsAt(now, time.Hour)))
+	assert.ErrorContains(t, err, "no HMAC secret configured")
+
+	_, err = v.Verify(context.Background(), sign(t, newRSAKey(t, "rsa"), claimsAt(now, time.Hour)))
+	assert.ErrorContains(t, err, "no key set configured")
+}
+
+func TestNewVerifier_FileKeySet(t *testing.T) {
+	v, err := NewVerifier(config.AuthConfig{Algorithms: []string{"RS256"}, JWKSFile: "/etc/jwks.json", JWKSURL: "https://ignored"})
+	require.NoError(t, err)
+	assert.NotNil(t, v.KeySet())
+
+	v, err = NewVerifier(config.AuthConfig{Algorithms: []string{"HS256"}, HMACSecret: testSecret})
+	require.NoError(t, err)
+	assert.Nil(t, v.KeySet())
+
+	_, err = NewVerifier(config.AuthConfig{})
+	assert.Error(t, err)
+}
+
+func TestContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	c := claimsAt(now, time.Hour)
+	got, ok := FromContext(NewContext(context.Background(), c))
+	assert.True(t, ok)
+	assert.Same(t, c, got)
+	assert.Equal(t, []string{"items:read", "items:write"}, got.Scopes())
+}
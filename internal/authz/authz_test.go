@@ -0,0 +1,168 @@
+package authz
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"template-go/internal/auth"
+	"template-go/pkg/logger"
+)
+
+// observeLogs routes pkg/logger into an observer for the duration of t.
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(logger.Replace(zap.New(core)))
+	return logs
+}
+
+func caller(subject, tenant, scope string, roles ...string) *auth.Claims {
+	return &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+		Scope:            scope,
+		Roles:            roles,
+		TenantID:         tenant,
+	}
+}
+
+// tenantRouter guards /tenants/{tenant}/items with p.
+func tenantRouter(p *Policy) chi.Router {
+	r := chi.NewRouter()
+	r.With(p.Middleware).Get("/tenants/{tenant}/items", func(http.ResponseWriter, *http.Request) {})
+	return r
+}
+
+func serve(h http.Handler, path string, c *auth.Claims) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if c != nil {
+		req = req.WithContext(auth.NewContext(req.Context(), c))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPolicy_Rules(t *testing.T) {
+	policy := New("items.read",
+		Scopes("items:read"),
+		AnyOf(AnyRole("admin"), SameTenant("tenant")),
+		Attr("not suspended", func(_ *http.Request, c *auth.Claims) bool { return c.Subject != "suspended" }),
+	)
+	tests := []struct {
+		name       string
+		path       string
+		claims     *auth.Claims
+		wantStatus int
+	}{
+		{"own tenant", "/tenants/acme/items", caller("u1", "acme", "items:read"), http.StatusOK},
+		{"admin of another tenant", "/tenants/acme/items", caller("u2", "globex", "items:read", "admin"), http.StatusOK},
+		{"other tenant", "/tenants/acme/items", caller("u3", "globex", "items:read"), http.StatusForbidden},
+		{"no tenant", "/tenants/acme/items", caller("u4", "", "items:read"), http.StatusForbidden},
+		{"missing scope", "/tenants/acme/items", caller("u5", "acme", "items:write"), http.StatusForbidden},
+		{"attribute predicate", "/tenants/acme/items", caller("suspended", "acme", "items:read"), http.StatusForbidden},
+		{"anonymous", "/tenants/acme/items", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observeLogs(t)
+
+			rec := serve(tenantRouter(policy), tt.path, tt.claims)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestPolicy_DenyReasons(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := caller("u1", "globex", "items:read")
+
+	assert.Equal(t, "not authenticated", New("p").Evaluate(req, nil))
+	assert.Empty(t, New("p").Evaluate(req, c), "a policy without rules admits any caller")
+	assert.Equal(t, `missing scope "items:write"`, New("p", Scopes("items:read", "items:write")).Evaluate(req, c))
+	assert.Equal(t, "none of roles [admin editor]", New("p", AnyRole("admin", "editor")).Evaluate(req, c))
+	assert.Equal(t, `none of roles [admin]; missing scope "x"`, New("p", AnyOf(AnyRole("admin"), Scopes("x"))).Evaluate(req, c))
+}
+
+func TestPolicy_RecordsDenial(t *testing.T) {
+	// GIVEN a traced request from a caller of another tenant
+	logs := observeLogs(t)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "request")
+	req := httptest.NewRequest(http.MethodGet, "/tenants/acme/items", nil)
+	req = req.WithContext(auth.NewContext(ctx, caller("u1", "globex", "")))
+
+	// WHEN the policy denies it
+	tenantRouter(New("items.read", SameTenant("tenant"))).ServeHTTP(httptest.NewRecorder(), req)
+	span.End()
+
+	// THEN the reason is logged
+	entries := logs.FilterMessage("authorization denied").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "items.read", fields["policy"])
+	assert.Equal(t, `tenant "globex" may not access tenant "acme"`, fields["reason"])
+	assert.Equal(t, "u1", fields["subject"])
+	assert.Equal(t, "/tenants/{tenant}/items", fields["route"])
+
+	// AND recorded on the span
+	attrs := map[string]string{}
+	for _, kv := range exporter.GetSpans()[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	assert.Equal(t, "items.read", attrs["authz.policy"])
+	assert.Equal(t, "deny", attrs["authz.decision"])
+	assert.Equal(t, `tenant "globex" may not access tenant "acme"`, attrs["authz.reason"])
+}
+
+func TestDump(t *testing.T) {
+	// GIVEN routes with a group policy, a route policy and an open route
+	r := NewRouter()
+	r.Get("/", func(http.ResponseWriter, *http.Request) {})
+	r.Route("/admin", func(r chi.Router) {
+		Use(r, New("admin", AnyRole("admin")))
+		r.Get("/users", func(http.ResponseWriter, *http.Request) {})
+		With(r, New("users.delete", Scopes("users:delete"))).Delete("/users/{id}", func(http.ResponseWriter, *http.Request) {})
+	})
+	With(r, New("me")).Get("/me", func(http.ResponseWriter, *http.Request) {})
+
+	// WHEN they are dumped
+	dump := Dump(r)
+
+	// THEN every route lists the policies on its path
+	assert.Equal(t, []RouteRules{
+		{Method: "GET", Route: "/", Policies: []PolicyRules{}},
+		{Method: "GET", Route: "/admin/users", Policies: []PolicyRules{{Name: "admin", Rules: []string{"role in [admin]"}}}},
+		{Method: "DELETE", Route: "/admin/users/{id}", Policies: []PolicyRules{
+			{Name: "admin", Rules: []string{"role in [admin]"}},
+			{Name: "users.delete", Rules: []string{"scopes include [users:delete]"}},
+		}},
+		{Method: "GET", Route: "/me", Policies: []PolicyRules{{Name: "me", Rules: []string{"authenticated"}}}},
+	}, dump)
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRouter()
+	With(r, New("p", SameTenant("t"), AnyOf(AnyRole("a"), Attr("owner", nil)))).Get("/x/{t}", func(http.ResponseWriter, *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	Handler(r).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/policies", nil))
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `[{"method":"GET","route":"/x/{t}","policies":[
+		{"name":"p","rules":["tenant_id == {t}","any of (role in [a] | owner)"]}
+	]}]`, rec.Body.String())
+}
@@ -0,0 +1,54 @@
+package authz
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// RouteRules describes the policies guarding one route.
+type RouteRules struct {
+	Method string `json:"method"`
+	Route  string `json:"route"`
+	// Policies is empty for routes open to anonymous callers.
+	Policies []PolicyRules `json:"policies"`
+}
+
+// PolicyRules describes one policy.
+type PolicyRules struct {
+	Name  string   `json:"name"`
+	Rules []string `json:"rules"`
+}
+
+// Dump lists every route of r with the policies recorded for it, sorted by
+// route and method.
+func Dump(r *Router) []RouteRules {
+	var out []RouteRules
+	r.node.collect("", nil, &out)
+	slices.SortFunc(out, func(a, b RouteRules) int {
+		return cmp.Or(cmp.Compare(a.Route, b.Route), cmp.Compare(a.Method, b.Method))
+	})
+	return out
+}
+
+// Handler serves Dump of r as JSON, for audits.
+func Handler(r *Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(Dump(r))
+	})
+}
+
+func (p *Policy) describe() PolicyRules {
+	rules := make([]string, len(p.Rules))
+	for i, rule := range p.Rules {
+		rules[i] = rule.String()
+	}
+	if len(rules) == 0 {
+		rules = []string{"authenticated"}
+	}
+	return PolicyRules{Name: p.Name, Rules: rules}
+}
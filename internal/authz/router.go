@@ -0,0 +1,226 @@
+package authz
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Router is a chi.Router that records the policies guarding each route
+// registered through it, for Dump. Attach policies with Use and With;
+// policies added as plain middlewares are enforced but not recorded.
+// Routers mounted on it are included: their own records if they are
+// Routers, otherwise their routes with the policies in force at the mount.
+type Router struct {
+	chi.Router
+	node     *node
+	policies []*Policy
+}
+
+// node records the routes and mounts of one chi.Mux and of the inline
+// routers derived from it with With and Group.
+type node struct {
+	mu     sync.Mutex
+	routes []route
+	mounts []mount
+}
+
+type route struct {
+	method, pattern string
+	policies        []*Policy
+}
+
+type mount struct {
+	pattern  string
+	policies []*Policy
+	node     *node      // set when a Router is mounted
+	routes   chi.Routes // set when another chi router is mounted
+}
+
+// NewRouter returns a Router without routes.
+func NewRouter() *Router {
+	return &Router{Router: chi.NewRouter(), node: &node{}}
+}
+
+// Use enforces policies on the routes registered on r afterwards, like
+// r.Use(p.Middleware), and records them when r is a Router.
+func Use(r chi.Router, policies ...*Policy) {
+	r.Use(middlewares(policies)...)
+	if ar, ok := r.(*Router); ok {
+		ar.policies = append(slices.Clip(ar.policies), policies...)
+	}
+}
+
+// With returns an inline router enforcing policies on the routes
+// registered on it, like r.With(p.Middleware), which records them when r
+// is a Router.
+func With(r chi.Router, policies ...*Policy) chi.Router {
+	ir := r.With(middlewares(policies)...)
+	if ar, ok := ir.(*Router); ok {
+		ar.policies = append(ar.policies, policies...)
+	}
+	return ir
+}
+
+func middlewares(policies []*Policy) []func(http.Handler) http.Handler {
+	mws := make([]func(http.Handler) http.Handler, len(policies))
+	for i, p := range policies {
+		mws[i] = p.Middleware
+	}
+	return mws
+}
+
+// With implements chi.Router.
+func (r *Router) With(middlewares ...func(http.Handler) http.Handler) chi.Router {
+	return &Router{Router: r.Router.With(middlewares...), node: r.node, policies: slices.Clip(r.policies)}
+}
+
+// Group implements chi.Router.
+func (r *Router) Group(fn func(r chi.Router)) chi.Router {
+	ir := r.With()
+	if fn != nil {
+		fn(ir)
+	}
+	return ir
+}
+
+// Route implements chi.Router.
+func (r *Router) Route(pattern string, fn func(r chi.Router)) chi.Router {
+	sub := NewRouter()
+	fn(sub)
+	r.Mount(pattern, sub)
+	return sub
+}
+
+// Mount implements chi.Router.
+func (r *Router) Mount(pattern string, h http.Handler) {
+	m := mount{pattern: pattern, policies: slices.Clone(r.policies)}
+	switch sub := h.(type) {
+	case *Router:
+		// Mount the chi.Mux itself, which inherits the not found handlers.
+		m.node, h = sub.node, sub.Router
+	case chi.Routes:
+		m.routes = sub
+	}
+	r.Router.Mount(pattern, h)
+	r.node.mu.Lock()
+	defer r.node.mu.Unlock()
+	r.node.mounts = append(r.node.mounts, m)
+}
+
+// Handle implements chi.Router. Routes for every method are recorded with
+// method "*".
+func (r *Router) Handle(pattern string, h http.Handler) {
+	if method, rest, ok := strings.Cut(pattern, " "); ok {
+		r.Method(method, strings.TrimLeft(rest, " \t"), h)
+		return
+	}
+	r.Router.Handle(pattern, h)
+	r.record("*", pattern)
+}
+
+// HandleFunc implements chi.Router.
+func (r *Router) HandleFunc(pattern string, h http.HandlerFunc) { r.Handle(pattern, h) }
+
+// Method implements chi.Router.
+func (r *Router) Method(method, pattern string, h http.Handler) {
+	r.Router.Method(method, pattern, h)
+	r.record(strings.ToUpper(method), pattern)
+}
+
+// MethodFunc implements chi.Router.
+func (r *Router) MethodFunc(method, pattern string, h http.HandlerFunc) { r.Method(method, pattern, h) }
+
+// Connect implements chi.Router.
+func (r *Router) Connect(pattern string, h http.HandlerFunc) {
+	r.Method(http.MethodConnect, pattern, h)
+}
+
+// Delete implements chi.Router.
+func (r *Router) Delete(pattern string, h http.HandlerFunc) { r.Method(http.MethodDelete, pattern, h) }
+
+// Get implements chi.Router.
+func (r *Router) Get(pattern string, h http.HandlerFunc) { r.Method(http.MethodGet, pattern, h) }
+
+// Head implements chi.Router.
+func (r *Router) Head(pattern string, h http.HandlerFunc) { r.Method(http.MethodHead, pattern, h) }
+
+// Options implements chi.Router.
+func (r *Router) Options(pattern string, h http.HandlerFunc) {
+	r.Method(http.MethodOptions, pattern, h)
+}
+
+// Patch implements chi.Router.
+func (r *Router) Patch(pattern string, h http.HandlerFunc) { r.Method(http.MethodPatch, pattern, h) }
+
+// Post implements chi.Router.
+func (r *Router) Post(pattern string, h http.HandlerFunc) { r.Method(http.MethodPost, pattern, h) }
+
+// Put implements chi.Router.
+func (r *Router) Put(pattern string, h http.HandlerFunc) { r.Method(http.MethodPut, pattern, h) }
+
+// Trace implements chi.Router.
+func (r *Router) Trace(pattern string, h http.HandlerFunc) { r.Method(http.MethodTrace, pattern, h) }
+
+func (r *Router) record(method, pattern string) {
+	r.node.mu.Lock()
+	defer r.node.mu.Unlock()
+	r.node.routes = append(r.node.routes, route{method: method, pattern: pattern, policies: slices.Clone(r.policies)})
+}
+
+// collect appends the routes of n, mounted at prefix below inherited
+// policies, to out.
+func (n *node) collect(prefix string, inherited []*Policy, out *[]RouteRules) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, rt := range n.routes {
+		*out = append(*out, routeRules(rt.method, joinPattern(prefix, rt.pattern), inherited, rt.policies))
+	}
+	for _, m := range n.mounts {
+		policies := append(slices.Clip(inherited), m.policies...)
+		switch {
+		case m.node != nil:
+			m.node.collect(joinPattern(prefix, m.pattern), policies, out)
+		case m.routes != nil:
+			walk(m.routes, joinPattern(prefix, m.pattern), policies, out)
+		}
+	}
+}
+
+// walk appends the routes of a chi router mounted at prefix, which are
+// only guarded by the inherited policies, to out.
+func walk(routes chi.Routes, prefix string, inherited []*Policy, out *[]RouteRules) {
+	for _, rt := range routes.Routes() {
+		if rt.SubRoutes != nil {
+			// Mounts are stored as "<pattern>/*".
+			walk(rt.SubRoutes, joinPattern(prefix, strings.TrimSuffix(rt.Pattern, "/*")), inherited, out)
+			continue
+		}
+		for method := range rt.Handlers {
+			if method != "*" {
+				*out = append(*out, routeRules(method, joinPattern(prefix, rt.Pattern), inherited))
+			}
+		}
+	}
+}
+
+// joinPattern appends pattern to the prefix of a mount.
+func joinPattern(prefix, pattern string) string {
+	if pattern == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + pattern
+}
+
+func routeRules(method, route string, policies ...[]*Policy) RouteRules {
+	rr := RouteRules{Method: method, Route: route, Policies: []PolicyRules{}}
+	for _, ps := range policies {
+		for _, p := range ps {
+			rr.Policies = append(rr.Policies, p.describe())
+		}
+	}
+	return rr
+}
@@ -0,0 +1,103 @@
+package authz
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func noop(http.ResponseWriter, *http.Request) {}
+
+func TestRouter_RecordsEveryRegistration(t *testing.T) {
+	// GIVEN routes registered with each method of the router
+	r := NewRouter()
+	r.Connect("/c", noop)
+	r.Delete("/d", noop)
+	r.Head("/h", noop)
+	r.Options("/o", noop)
+	r.Patch("/pa", noop)
+	r.Post("/po", noop)
+	r.Put("/pu", noop)
+	r.Trace("/t", noop)
+	r.MethodFunc("get", "/m", noop)
+	r.HandleFunc("/any", noop)
+	r.Handle("POST  /typed", http.HandlerFunc(noop))
+	r.Group(func(r chi.Router) {
+		Use(r, New("group"))
+		r.Get("/grouped", noop)
+	})
+	r.Group(nil)
+
+	// WHEN they are dumped
+	var routes []string
+	for _, rr := range Dump(r) {
+		routes = append(routes, rr.Method+" "+rr.Route)
+	}
+
+	// THEN each is listed once with its method
+	assert.Equal(t, []string{
+		"* /any", "CONNECT /c", "DELETE /d", "GET /grouped", "HEAD /h", "GET /m",
+		"OPTIONS /o", "PATCH /pa", "POST /po", "PUT /pu", "TRACE /t", "POST /typed",
+	}, routes)
+}
+
+func TestRouter_Mounts(t *testing.T) {
+	// GIVEN a plain chi router with a nested mount and a Router, both
+	// mounted below a policy
+	nested := chi.NewRouter()
+	nested.Get("/{id}", noop)
+	plain := chi.NewRouter()
+	plain.Get("/", noop)
+	plain.Mount("/orders", nested)
+	guarded := NewRouter()
+	With(guarded, New("items.write")).Post("/", noop)
+
+	r := NewRouter()
+	r.Route("/", func(r chi.Router) {
+		Use(r, New("tenant"))
+		r.Mount("/", plain)
+		r.Mount("/items", guarded)
+	})
+	r.Mount("/static", http.NotFoundHandler())
+
+	// WHEN they are dumped
+	dump := Dump(r)
+
+	// THEN the routes of both are listed below the mount with its policies
+	policies := map[string][]string{}
+	for _, rr := range dump {
+		for _, p := range rr.Policies {
+			policies[rr.Method+" "+rr.Route] = append(policies[rr.Method+" "+rr.Route], p.Name)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"GET /":            {"tenant"},
+		"GET /orders/{id}": {"tenant"},
+		"POST /items/":     {"tenant", "items.write"},
+	}, policies)
+	assert.Len(t, dump, 3)
+
+	// AND the mounted routes are served behind the policy
+	observeLogs(t)
+	assert.Equal(t, http.StatusUnauthorized, serve(r, "/orders/7", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(r, "/orders/7", caller("user-1", "", "")).Code)
+}
+
+func TestRouter_MountsChiRootMount(t *testing.T) {
+	// GIVEN a chi router that mounts another one at its root
+	inner := chi.NewRouter()
+	inner.Get("/status", noop)
+	outer := chi.NewRouter()
+	outer.Mount("/", inner)
+	r := NewRouter()
+	r.Mount("/api", outer)
+
+	// WHEN they are dumped
+	dump := Dump(r)
+
+	// THEN the inner routes keep the mount prefix
+	assert.Len(t, dump, 1)
+	assert.Equal(t, "GET /api/status", dump[0].Method+" "+dump[0].Route)
+}
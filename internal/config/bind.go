@@ -0,0 +1,192 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Struct tags understood by the binder:
+//
+//	config:"listen_addr"   key segment; nested struct keys are joined with "."
+//	env:"LISTEN_ADDR"      environment variable name
+//	default:":8080"        default value in its textual form
+//	required:"true"        the value must not be empty (checked by Validate)
+//	enum:"json,console"    allowed values (checked by Validate)
+//	desc:"..."             human readable description
+//	secret:"true"          the value is redacted when printed
+//
+// Fields without a config tag are ignored.
+
+// field describes one bindable leaf of a configuration struct.
+type field struct {
+	key      string
+	env      string
+	def      string
+	desc     string
+	enum     []string
+	required bool
+	secret   bool
+	typ      reflect.Type
+	index    []int
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// fieldsOf walks t, descending into nested structs, and returns every
+// tagged leaf field in declaration order.
+func fieldsOf(t reflect.Type) []field {
+	return appendFields(nil, t, "", nil)
+}
+
+func appendFields(out []field, t reflect.Type, prefix string, index []int) []field {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, ok := sf.Tag.Lookup("config")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		idx := append(append([]int(nil), index...), i)
+
+		if sf.Type.Kind() == reflect.Struct && !reflect.PointerTo(sf.Type).Implements(textUnmarshalerType) {
+			out = appendFields(out, sf.Type, key, idx)
+			continue
+		}
+		out = append(out, field{
+			key:      key,
+			env:      sf.Tag.Get("env"),
+			def:      sf.Tag.Get("default"),
+			desc:     sf.Tag.Get("desc"),
+			enum:     splitList(sf.Tag.Get("enum")),
+			required: sf.Tag.Get("required") == "true",
+			secret:   sf.Tag.Get("secret") == "true",
+			typ:      sf.Type,
+			index:    idx,
+		})
+	}
+	return out
+}
+
+// value returns the addressable field f within the struct pointed to by dst.
+func (f field) value(dst reflect.Value) reflect.Value {
+	return dst.Elem().FieldByIndex(f.index)
+}
+
+// setValue parses raw according to the type of v and assigns it.
+func setValue(v reflect.Value, raw string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+	if v.Type() == durationType {
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		if raw == "" {
+			v.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(orZero(raw), 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(orZero(raw), 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(orZero(raw), v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		items := splitList(raw)
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(s.Index(i), item); err != nil {
+				return err
+			}
+		}
+		if len(items) == 0 {
+			s = reflect.Zero(v.Type())
+		}
+		v.Set(s)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		items := splitList(raw)
+		if len(items) == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(items))
+		for _, pair := range items {
+			k, val, found := strings.Cut(pair, "=")
+			if !found || strings.TrimSpace(k) == "" {
+				return fmt.Errorf("invalid key=value pair %q", pair)
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, strings.TrimSpace(val)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma separated value, trimming blanks and dropping
+// empty items.
+func splitList(raw string) []string {
+	var out []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// orZero maps an empty numeric value to "0".
+func orZero(raw string) string {
+	if strings.TrimSpace(raw) == "" {
+		return "0"
+	}
+	return strings.TrimSpace(raw)
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindNested struct {
+	Tags []string `config:"tags" env:"TAGS"`
+}
+
+type bindTarget struct {
+	Name     string            `config:"name" env:"NAME" default:"svc" required:"true" desc:"Service name."`
+	Enabled  bool              `config:"enabled"`
+	Count    int               `config:"count"`
+	Port     uint16            `config:"port"`
+	Ratio    float64           `config:"ratio"`
+	Timeout  time.Duration     `config:"timeout"`
+	Limit    ByteSize          `config:"limit"`
+	Hosts    []string          `config:"hosts"`
+	Ports    []int             `config:"ports"`
+	Labels   map[string]string `config:"labels"`
+	Weights  map[string]int    `config:"weights"`
+	Token    string            `config:"token" secret:"true"`
+	Nested   bindNested        `config:"nested"`
+	Untagged string
+}
+
+func TestFieldsOf(t *testing.T) {
+	fields := fieldsOf(reflect.TypeOf(bindTarget{}))
+
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.key
+	}
+	assert.Equal(t, []string{
+		"name", "enabled", "count", "port", "ratio", "timeout", "limit",
+		"hosts", "ports", "labels", "weights", "token", "nested.tags",
+	}, keys)
+
+	name := fields[0]
+	assert.Equal(t, "NAME", name.env)
+	assert.Equal(t, "svc", name.def)
+	assert.Equal(t, "Service name.", name.desc)
+	assert.True(t, name.required)
+	assert.True(t, fields[11].secret)
+	assert.Equal(t, "TAGS", fields[12].env)
+}
+
+func TestSetValue(t *testing.T) {
+	tests := []struct {
+		key  string
+		raw  string
+		want any
+	}{
+		{"name", "api", "api"},
+		{"enabled", "true", true},
+		{"enabled", "", false},
+		{"count", "-3", -3},
+		{"count", "", 0},
+		{"port", "8080", uint16(8080)},
+		{"ratio", "0.25", 0.25},
+		{"timeout", "1m30s", 90 * time.Second},
+		{"timeout", "", time.Duration(0)},
+		{"limit", "10MiB", 10 * MiB},
+		{"limit", " ", ByteSize(0)},
+		{"limit", "2 kb", 2 * KB},
+		{"hosts", "a, b,,c ", []string{"a", "b", "c"}},
+		{"hosts", "", []string(nil)},
+		{"ports", "80,443", []int{80, 443}},
+		{"labels", "team=core, tier = gold", map[string]string{"team": "core", "tier": "gold"}},
+		{"labels", " ", map[string]string(nil)},
+		{"weights", "a=1,b=2", map[string]int{"a": 1, "b": 2}},
+		{"nested.tags", "x,y", []string{"x", "y"}},
+	}
+	fields := fieldsOf(reflect.TypeOf(bindTarget{}))
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.raw, func(t *testing.T) {
+			var target bindTarget
+			f := findField(t, fields, tt.key)
+
+			require.NoError(t, setValue(f.value(reflect.ValueOf(&target)), tt.raw))
+
+			assert.Equal(t, tt.want, f.value(reflect.ValueOf(&target)).Interface())
+		})
+	}
+}
+
+func TestSetValue_Errors(t *testing.T) {
+	tests := []struct {
+		key     string
+		raw     string
+		wantErr string
+	}{
+		{"enabled", "yes please", `invalid boolean "yes please"`},
+		{"count", "many", `invalid integer "many"`},
+		{"port", "70000", `invalid unsigned integer "70000"`},
+		{"ratio", "half", `invalid number "half"`},
+		{"timeout", "10", `invalid duration "10"`},
+		{"limit", "10XB", `invalid byte size "10XB"`},
+		{"ports", "80,http", `invalid integer "http"`},
+		{"labels", "team", `invalid key=value pair "team"`},
+		{"weights", "a=heavy", `invalid integer "heavy"`},
+	}
+	fields := fieldsOf(reflect.TypeOf(bindTarget{}))
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.raw, func(t *testing.T) {
+			var target bindTarget
+			f := findField(t, fields, tt.key)
+
+			err := setValue(f.value(reflect.ValueOf(&target)), tt.raw)
+
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSetValue_UnsupportedType(t *testing.T) {
+	var target struct {
+		Ch chan int
+	}
+	v := reflect.ValueOf(&target).Elem().Field(0)
+
+	assert.EqualError(t, setValue(v, "x"), "unsupported type chan int")
+}
+
+func TestSetValue_UnsupportedMapKey(t *testing.T) {
+	var target struct {
+		Codes map[int]string
+	}
+	v := reflect.ValueOf(&target).Elem().Field(0)
+
+	assert.EqualError(t, setValue(v, "1=a"), "unsupported map key type int")
+}
+
+// findField returns the field with the given key or fails the test.
+func findField(t *testing.T, fields []field, key string) field {
+	t.Helper()
+	for _, f := range fields {
+		if f.key == key {
+			return f
+		}
+	}
+	t.Fatalf("no field %q", key)
+	return field{}
+}
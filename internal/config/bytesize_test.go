@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"4096", 4096, false},
+		{"512B", 512, false},
+		{"10MiB", 10 * MiB, false},
+		{"10mb", 10 * MB, false},
+		{"1.5GiB", GiB + 512*MiB, false},
+		{"64 KiB", 64 * KiB, false},
+		{"2k", 2 * KiB, false},
+		{"", 0, true},
+		{"MiB", 0, true},
+		{"1.2.3MB", 0, true},
+		{"10 parsecs", 0, true},
+		{"99999999999GiB", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseByteSize(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	tests := []struct {
+		in   ByteSize
+		want string
+	}{
+		{0, "0B"},
+		{1000, "1000B"},
+		{KiB, "1KiB"},
+		{10 * MiB, "10MiB"},
+		{3 * GiB, "3GiB"},
+		{MiB + 1, "1048577B"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.in.String())
+		text, err := tt.in.MarshalText()
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, string(text))
+	}
+}
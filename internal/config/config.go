@@ -1,27 +1,307 @@
 package config
 
-import "os"
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
 
-// Config holds basic runtime configuration.
+// exit and stderr are variables so tests can observe MustLoad failures.
+var (
+	exit             = os.Exit
+	stderr io.Writer = os.Stderr
+)
+
+// Config holds basic runtime configuration. Each leaf field is bound from
+// the config file, environment and flags according to its struct tags; see
+// bind.go for the supported tags and types.
 type Config struct {
-	ListenAddr      string
-	OTELExporter    string
-	OTELServiceName string
+	// Env selects the profile whose defaults and overlay file apply.
+	Env string `config:"env" env:"APP_ENV" default:"prod" enum:"dev,staging,prod" desc:"Deployment profile."`
+
+	Server  ServerConfig  `config:"server"`
+	OTEL    OTELConfig    `config:"otel"`
+	Logging LoggingConfig `config:"logging"`
+	DB      DBConfig      `config:"db"`
+
+	Lifecycle LifecycleConfig `config:"lifecycle"`
+	RateLimit RateLimitConfig `config:"rate_limit"`
+	Auth      AuthConfig      `config:"auth"`
+
+	// Features toggles optional behaviour at runtime, keyed by feature name.
+	Features map[string]bool `config:"features" env:"FEATURES" desc:"Feature toggles as name=true|false pairs."`
+	// ReloadInterval is how often the config file is checked for changes.
+	ReloadInterval time.Duration `config:"reload_interval" env:"CONFIG_RELOAD_INTERVAL" default:"10s" desc:"How often the config file is polled for changes; 0 disables polling."`
+
+	// files are the config file and profile overlay the values were read
+	// from, in the order they were applied.
+	files []string
+	// origins records which source supplied each setting, keyed by its
+	// dotted name (e.g. "server.listen_addr").
+	origins map[string]Source
 }
 
-// MustLoad loads configuration from environment variables or defaults.
-func MustLoad() Config {
-	return Config{
-		ListenAddr:      getenv("LISTEN_ADDR", ":8080"),
-		OTELExporter:    getenv("OTEL_EXPORTER", "otlp"),
-		OTELServiceName: getenv("OTEL_SERVICE_NAME", "template-go"),
+// ServerConfig configures the HTTP listener.
+type ServerConfig struct {
+	ListenAddr  string   `config:"listen_addr" env:"LISTEN_ADDR" default:":8080" desc:"Address the HTTP server listens on."`
+	MaxBodySize ByteSize `config:"max_body_size" env:"SERVER_MAX_BODY_SIZE" default:"1MiB" desc:"Maximum accepted request body size."`
+	// AdminAddr serves metrics, docs, health probes and pprof. It should
+	// only be reachable from inside the cluster.
+	AdminAddr string `config:"admin_addr" env:"ADMIN_LISTEN_ADDR" default:":9090" desc:"Address of the admin listener (metrics, docs, probes, pprof)."`
+
+	// Timeouts applied to every connection; zero disables the timeout.
+	ReadTimeout       time.Duration `config:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"15s" desc:"Maximum duration for reading an entire request."`
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s" desc:"Maximum duration for reading request headers."`
+	WriteTimeout      time.Duration `config:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s" desc:"Maximum duration before timing out writes of a response."`
+	IdleTimeout       time.Duration `config:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s" desc:"How long keep-alive connections wait for the next request."`
+
+	// DrainDelay is how long the server keeps serving after it has been
+	// marked not ready, giving load balancers time to stop routing to it.
+	DrainDelay time.Duration `config:"drain_delay" env:"SERVER_DRAIN_DELAY" default:"5s" desc:"Time between reporting not ready and closing listeners."`
+	// ShutdownTimeout bounds how long in-flight requests may take to finish.
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s" desc:"Deadline for in-flight requests to complete on shutdown."`
+	// TrustedProxies are the reverse proxies, as IPs or CIDRs, allowed to
+	// name the client in X-Forwarded-For and X-Real-IP. The headers of other
+	// peers are ignored.
+	TrustedProxies []string `config:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" desc:"IPs or CIDRs of reverse proxies whose X-Forwarded-For and X-Real-IP headers are trusted."`
+
+	TLS TLSConfig `config:"tls"`
+}
+
+// TrustedProxyPrefixes returns TrustedProxies as prefixes, skipping the
+// invalid entries Validate reports.
+func (s ServerConfig) TrustedProxyPrefixes() []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, v := range s.TrustedProxies {
+		if p, err := parsePrefix(v); err == nil {
+			prefixes = append(prefixes, p)
+		}
 	}
+	return prefixes
 }
 
-// getenv retrieves an environment variable or returns a fallback value.
-func getenv(key, fallback string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
+// parsePrefix parses a CIDR or a single IP address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
 	}
-	return fallback
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// Client certificate modes accepted by TLSConfig.ClientAuth.
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequire          = "require"
+	ClientAuthVerifyIfGiven    = "verify-if-given"
+	ClientAuthRequireAndVerify = "require-and-verify"
+)
+
+// TLSConfig configures TLS on the public listener. TLS is enabled when
+// CertFile is set; certificates are re-read when the files change.
+type TLSConfig struct {
+	CertFile string `config:"cert_file" env:"TLS_CERT_FILE" desc:"PEM certificate chain; enables TLS on the public listener."`
+	KeyFile  string `config:"key_file" env:"TLS_KEY_FILE" desc:"PEM private key matching cert_file."`
+	// MinVersion is the lowest protocol version accepted.
+	MinVersion string `config:"min_version" env:"TLS_MIN_VERSION" default:"1.2" enum:"1.2,1.3" desc:"Minimum TLS version."`
+	// CipherSuites restricts the TLS 1.2 cipher suites by their Go names
+	// (e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256). TLS 1.3 suites are fixed.
+	CipherSuites []string `config:"cipher_suites" env:"TLS_CIPHER_SUITES" desc:"Allowed TLS 1.2 cipher suites; empty uses Go's secure defaults."`
+	// ClientCAFile is the PEM bundle used to verify client certificates.
+	ClientCAFile string `config:"client_ca_file" env:"TLS_CLIENT_CA_FILE" desc:"PEM CA bundle used to verify client certificates."`
+	ClientAuth   string `config:"client_auth" env:"TLS_CLIENT_AUTH" default:"none" enum:"none,request,require,verify-if-given,require-and-verify" desc:"Client certificate policy (mutual TLS)."`
+	// ReloadInterval is how often the certificate files are checked for
+	// changes, e.g. after cert-manager rotated them.
+	ReloadInterval time.Duration `config:"reload_interval" env:"TLS_RELOAD_INTERVAL" default:"30s" desc:"How often certificate files are checked for changes; 0 disables reloading."`
+}
+
+// Enabled reports whether the public listener serves TLS.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// OTELConfig configures OpenTelemetry tracing and its exporter.
+type OTELConfig struct {
+	Exporter    string `config:"exporter" env:"OTEL_EXPORTER" default:"otlp" enum:"otlp,otlp-grpc,otlp-http,stdout,file,none" desc:"Trace exporter."`
+	ServiceName string `config:"service_name" env:"OTEL_SERVICE_NAME" default:"template-go" required:"true" desc:"Service name reported on every span."`
+
+	// Endpoint is the collector address used by the OTLP exporters.
+	// When empty the exporter falls back to its own default (localhost).
+	Endpoint string `config:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" desc:"OTLP collector URL or host:port."`
+	// Headers are extra headers sent with every OTLP export request.
+	Headers map[string]string `config:"headers" env:"OTEL_EXPORTER_OTLP_HEADERS" secret:"true" desc:"Extra OTLP request headers as k=v pairs."`
+	// Insecure disables TLS for the OTLP exporters.
+	Insecure bool `config:"insecure" env:"OTEL_EXPORTER_OTLP_INSECURE" default:"false" desc:"Disable TLS when talking to the collector."`
+	// CertFile is a PEM CA bundle used to verify the collector.
+	CertFile string `config:"cert_file" env:"OTEL_EXPORTER_OTLP_CERTIFICATE" desc:"PEM CA bundle used to verify the collector."`
+	// Compression is either "gzip" or "none".
+	Compression string `config:"compression" env:"OTEL_EXPORTER_OTLP_COMPRESSION" default:"gzip" enum:"gzip,none" desc:"OTLP compression."`
+	// Timeout bounds a single export request.
+	Timeout time.Duration `config:"timeout" env:"OTEL_EXPORTER_OTLP_TIMEOUT" default:"10s" desc:"Timeout for a single export request."`
+	// SampleRatio is the fraction of new traces that are sampled.
+	SampleRatio float64 `config:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" default:"1" desc:"Fraction of root traces sampled, between 0 and 1."`
+	// FilePath is where the "file" exporter writes JSON lines.
+	FilePath string `config:"file_path" env:"OTEL_EXPORTER_FILE_PATH" default:"traces.jsonl" desc:"Output path of the file exporter."`
+}
+
+// LoggingConfig configures the application logger.
+type LoggingConfig struct {
+	Level  string `config:"level" env:"LOG_LEVEL" default:"info" enum:"debug,info,warn,error" desc:"Minimum log level."`
+	Format string `config:"format" env:"LOG_FORMAT" default:"json" enum:"json,console" desc:"Log encoding."`
+
+	Access AccessLogConfig `config:"access"`
+}
+
+// AccessLogConfig configures the HTTP access log.
+type AccessLogConfig struct {
+	Enabled bool `config:"enabled" env:"ACCESS_LOG_ENABLED" default:"true" desc:"Log one line per HTTP request."`
+	// SampleRatio applies to requests answered with a status below 400;
+	// client and server errors are always logged.
+	SampleRatio float64 `config:"sample_ratio" env:"ACCESS_LOG_SAMPLE_RATIO" default:"1" desc:"Fraction of successful requests logged, between 0 and 1."`
+	// ExcludePaths are never logged. An entry ending in "*" matches by prefix.
+	ExcludePaths []string `config:"exclude_paths" env:"ACCESS_LOG_EXCLUDE_PATHS" default:"/metrics,/livez,/readyz,/healthz" desc:"Request paths not logged; a trailing * matches a prefix."`
+}
+
+// LifecycleConfig bounds how long each component may take to start or stop.
+type LifecycleConfig struct {
+	StartTimeout time.Duration `config:"start_timeout" env:"START_TIMEOUT" default:"30s" desc:"Maximum time a single component may take to start."`
+	// StopTimeout should exceed server.drain_delay plus server.shutdown_timeout.
+	StopTimeout time.Duration `config:"stop_timeout" env:"STOP_TIMEOUT" default:"45s" desc:"Maximum time a single component may take to stop."`
+}
+
+// AuthConfig configures JWT bearer authentication on the public router.
+// Keys come from a JWKS URL or file for asymmetric algorithms and from
+// HMACSecret for HS256.
+type AuthConfig struct {
+	Enabled bool `config:"enabled" env:"AUTH_ENABLED" default:"false" desc:"Validate bearer tokens on the public listener."`
+
+	JWKSURL  string `config:"jwks_url" env:"AUTH_JWKS_URL" desc:"URL of the identity provider's JSON Web Key Set."`
+	JWKSFile string `config:"jwks_file" env:"AUTH_JWKS_FILE" desc:"Local JSON Web Key Set file, used instead of jwks_url."`
+	// JWKSRefreshInterval is how often keys are re-read. Unknown key IDs
+	// trigger an earlier refresh so rotated keys are picked up.
+	JWKSRefreshInterval time.Duration `config:"jwks_refresh_interval" env:"AUTH_JWKS_REFRESH_INTERVAL" default:"15m" desc:"How often the key set is refreshed."`
+	HMACSecret          string        `config:"hmac_secret" env:"AUTH_HMAC_SECRET" secret:"true" desc:"Shared secret for HS256 tokens."`
+
+	Algorithms []string `config:"algorithms" env:"AUTH_ALGORITHMS" default:"RS256,ES256,EdDSA" desc:"Accepted signing algorithms."`
+	Issuer     string   `config:"issuer" env:"AUTH_ISSUER" desc:"Required iss claim; empty accepts any issuer."`
+	// Audience accepts tokens whose aud claim contains any of the values.
+	Audience []string `config:"audience" env:"AUTH_AUDIENCE" desc:"Accepted aud claim values; empty accepts any audience."`
+	// ClockSkew is the leeway applied to exp, nbf and iat.
+	ClockSkew time.Duration `config:"clock_skew" env:"AUTH_CLOCK_SKEW" default:"30s" desc:"Tolerated clock difference when checking token times."`
+
+	APIKeys APIKeyConfig `config:"api_keys"`
+}
+
+// APIKeyConfig configures API key authentication for machine clients. It
+// works independently of bearer tokens.
+type APIKeyConfig struct {
+	Enabled bool `config:"enabled" env:"AUTH_API_KEYS_ENABLED" default:"false" desc:"Accept API keys on the public listener."`
+
+	Header     string `config:"header" env:"AUTH_API_KEY_HEADER" default:"X-API-Key" desc:"Request header carrying the API key."`
+	QueryParam string `config:"query_param" env:"AUTH_API_KEY_QUERY_PARAM" desc:"Query parameter also accepted for the API key; empty disables it."`
+
+	// Store selects where hashed keys live: a YAML file or the database
+	// configured in DB.
+	Store string `config:"store" env:"AUTH_API_KEY_STORE" default:"file" enum:"file,db" desc:"Where hashed API keys are stored."`
+	File  string `config:"file" env:"AUTH_API_KEY_FILE" default:"api_keys.yaml" desc:"YAML file holding hashed API keys for the file store."`
+	// TouchInterval limits how often the last-used time of a key is written.
+	TouchInterval time.Duration `config:"touch_interval" env:"AUTH_API_KEY_TOUCH_INTERVAL" default:"1m" desc:"Minimum time between last-used updates of a key."`
+
+	// Admin mounts the key management endpoints on the admin listener.
+	// They mint credentials, so they are off by default and require
+	// AdminToken as a bearer token.
+	Admin      bool   `config:"admin" env:"AUTH_API_KEY_ADMIN" default:"false" desc:"Serve the API key management endpoints under /admin/api-keys."`
+	AdminToken string `config:"admin_token" env:"AUTH_API_KEY_ADMIN_TOKEN" secret:"true" desc:"Bearer token required by the API key management endpoints."`
+}
+
+// MinAdminTokenLength is the shortest accepted APIKeyConfig.AdminToken.
+const MinAdminTokenLength = 32
+
+// Supported values for APIKeyConfig.Store.
+const (
+	APIKeyStoreFile = "file"
+	APIKeyStoreDB   = "db"
+)
+
+// JWT signing algorithms accepted in AuthConfig.Algorithms.
+var authAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA", "HS256", "HS384", "HS512"}
+
+// DBConfig configures the database adapter.
+type DBConfig struct {
+	Driver string `config:"driver" env:"DB_DRIVER" desc:"Database driver name: postgres or sqlite."`
+	DSN    string `config:"dsn" env:"DB_DSN" secret:"true" desc:"Database connection string."`
+}
+
+// Supported values for OTELConfig.Exporter.
+const (
+	ExporterOTLP     = "otlp"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterStdout   = "stdout"
+	ExporterFile     = "file"
+	ExporterNone     = "none"
+)
+
+// Load reads configuration from defaults, an optional config file,
+// environment variables and the given command-line arguments, in increasing
+// order of precedence, and validates the result. Invalid settings are
+// reported together as a *ValidationError.
+func Load(args ...string) (Config, error) {
+	cfg, err := Read(Options{Args: args})
+	if err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// MustLoad is like Load but prints the problems to stderr and exits the
+// process if the configuration is invalid.
+func MustLoad(args ...string) Config {
+	cfg, err := Load(args...)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		exit(1)
+	}
+	return cfg
+}
+
+// Source reports where the setting identified by its dotted key came from.
+// Unknown keys report SourceDefault.
+func (c Config) Source(key string) Source {
+	if src, ok := c.origins[key]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+// File returns the path of the base config file the values were read from,
+// or "" if no file was used.
+func (c Config) File() string {
+	if len(c.files) == 0 {
+		return ""
+	}
+	return c.files[0]
+}
+
+// Files returns the base config file followed by the profile overlay, if any.
+func (c Config) Files() []string {
+	return slices.Clone(c.files)
+}
+
+// Sources returns a copy of the origin of every setting, keyed by its dotted name.
+func (c Config) Sources() map[string]Source {
+	return maps.Clone(c.origins)
 }
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMustLoadDefaults(t *testing.T) {
@@ -21,19 +25,75 @@ func TestMustLoadDefaults(t *testing.T) {
 
 	cfg := MustLoad()
 
-	assert.Equal(t, ":8080", cfg.ListenAddr)
-	assert.Equal(t, "otlp", cfg.OTELExporter)
-	assert.Equal(t, "template-go", cfg.OTELServiceName)
+	assert.Equal(t, ":8080", cfg.Server.ListenAddr)
+	assert.Equal(t, "otlp", cfg.OTEL.Exporter)
+	assert.Equal(t, "template-go", cfg.OTEL.ServiceName)
 }
 
 func TestMustLoadWithEnvOverrides(t *testing.T) {
 	t.Setenv("LISTEN_ADDR", "127.0.0.1:9090")
-	t.Setenv("OTEL_EXPORTER", "prometheus")
+	t.Setenv("OTEL_EXPORTER", "stdout")
 	t.Setenv("OTEL_SERVICE_NAME", "custom-service")
 
 	cfg := MustLoad()
 
-	assert.Equal(t, "127.0.0.1:9090", cfg.ListenAddr)
-	assert.Equal(t, "prometheus", cfg.OTELExporter)
-	assert.Equal(t, "custom-service", cfg.OTELServiceName)
+	assert.Equal(t, "127.0.0.1:9090", cfg.Server.ListenAddr)
+	assert.Equal(t, "stdout", cfg.OTEL.Exporter)
+	assert.Equal(t, "custom-service", cfg.OTEL.ServiceName)
+}
+
+func TestMustLoadExporterSettings(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "collector:4317")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer abc, x-tenant = acme")
+	t.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	t.Setenv("OTEL_EXPORTER_OTLP_COMPRESSION", "none")
+	t.Setenv("OTEL_EXPORTER_OTLP_TIMEOUT", "3s")
+	t.Setenv("OTEL_EXPORTER_FILE_PATH", "/tmp/spans.jsonl")
+
+	cfg := MustLoad()
+
+	assert.Equal(t, "collector:4317", cfg.OTEL.Endpoint)
+	assert.Equal(t, map[string]string{"authorization": "Bearer abc", "x-tenant": "acme"}, cfg.OTEL.Headers)
+	assert.True(t, cfg.OTEL.Insecure)
+	assert.Equal(t, "none", cfg.OTEL.Compression)
+	assert.Equal(t, 3*time.Second, cfg.OTEL.Timeout)
+	assert.Equal(t, "/tmp/spans.jsonl", cfg.OTEL.FilePath)
+}
+
+func TestMustLoadExitsWithReport(t *testing.T) {
+	t.Setenv("LISTEN_ADDR", "8080")
+	t.Setenv("OTEL_EXPORTER", "zipkin")
+
+	var out bytes.Buffer
+	var code int
+	oldExit, oldStderr := exit, stderr
+	defer func() { exit, stderr = oldExit, oldStderr }()
+	exit = func(c int) { code = c }
+	stderr = &out
+
+	MustLoad()
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, out.String(), "invalid configuration (2 problems)")
+	assert.Contains(t, out.String(), `server.listen_addr="8080"`)
+	assert.Contains(t, out.String(), `otel.exporter="zipkin"`)
+}
+
+func TestLoadReturnsValidationError(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "verbose")
+
+	_, err := Load()
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Len(t, verr.Errors, 1)
+	assert.Equal(t, "logging.level", verr.Errors[0].Key)
+}
+
+func TestLoadReturnsReadError(t *testing.T) {
+	_, err := Load("--config", "/does/not/exist.yaml")
+
+	var verr *ValidationError
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &verr))
 }
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Source identifies where a configuration value came from.
+type Source string
+
+// Sources in increasing order of precedence.
+const (
+	SourceDefault Source = "default"
+	SourceProfile Source = "profile"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Options controls where Read looks for configuration.
+type Options struct {
+	// Args are command-line arguments, typically os.Args[1:].
+	Args []string
+	// File is the config file path. When empty it is taken from the
+	// --config flag or the CONFIG_FILE environment variable.
+	File string
+	// LookupEnv resolves environment variables. Defaults to os.LookupEnv.
+	LookupEnv func(string) (string, bool)
+	// SecretProviders resolve "<scheme>://" references in values. They are
+	// consulted after the built-in file:// and env:// providers (and a Vault
+	// provider when VAULT_ADDR is set) and win for the same scheme.
+	SecretProviders []SecretProvider
+}
+
+// configFields lists every bindable setting of Config. Flags are derived
+// from the dotted key by replacing underscores with dashes, e.g.
+// --server.listen-addr.
+var configFields = fieldsOf(reflect.TypeOf(Config{}))
+
+// Read merges defaults, profile defaults, the config file and its profile
+// overlay, environment variables and flags, in increasing order of
+// precedence, and records the origin of every value.
+// An environment variable FOO may instead be given as FOO_FILE naming a file
+// that holds the value, and any value may be a secret reference such as
+// "file:///run/secrets/dsn" or "env://OTHER_VAR".
+// Values that cannot be parsed are reported together as a *ValidationError;
+// Read does not otherwise validate the result, see Load.
+func Read(opts Options) (Config, error) {
+	lookup := opts.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	flags, file, err := parseFlags(opts.Args)
+	if err != nil {
+		return Config{}, err
+	}
+	if opts.File != "" {
+		file = opts.File
+	}
+	if file == "" {
+		file, _ = lookup("CONFIG_FILE")
+	}
+
+	var fromFile map[string]string
+	var files []string
+	if file != "" {
+		if fromFile, err = readFile(file); err != nil {
+			return Config{}, err
+		}
+		files = append(files, file)
+	}
+
+	profile := selectProfile(flags, lookup, fromFile)
+	if overlay := overlayPath(file, profile); overlay != "" {
+		fromOverlay, err := readFile(overlay)
+		if err != nil {
+			return Config{}, err
+		}
+		maps.Copy(fromFile, fromOverlay)
+		files = append(files, overlay)
+	}
+
+	providers := append(defaultProviders(lookup), opts.SecretProviders...)
+
+	cfg := Config{files: files, origins: make(map[string]Source, len(configFields))}
+	dst := reflect.ValueOf(&cfg)
+	verr := &ValidationError{}
+	for _, f := range configFields {
+		value, src := f.def, SourceDefault
+		if v, ok := profileDefaults[profile][f.key]; ok {
+			value, src = v, SourceProfile
+		}
+		if v, ok := fromFile[f.key]; ok {
+			value, src = v, SourceFile
+		}
+		if f.env != "" {
+			if v, ok := lookup(f.env); ok {
+				value, src = v, SourceEnv
+			} else if path, ok := lookup(f.env + "_FILE"); ok {
+				secret, err := readSecretFile(path)
+				if err != nil {
+					verr.add(f.key, path, "%v (from %s_FILE)", err, f.env)
+					continue
+				}
+				value, src = secret, SourceEnv
+			}
+		}
+		if v, ok := flags[f.key]; ok {
+			value, src = v, SourceFlag
+		}
+		resolved, err := resolveSecret(value, providers)
+		if err != nil {
+			verr.add(f.key, value, "%v (from %s)", err, src)
+			continue
+		}
+		if err := setValue(f.value(dst), resolved); err != nil {
+			verr.add(f.key, f.display(resolved), "%v (from %s)", err, src)
+			continue
+		}
+		cfg.origins[f.key] = src
+	}
+	if err := verr.errOrNil(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// flagName returns the command-line flag for a dotted setting key.
+func flagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// parseFlags parses args into setting values keyed by their dotted name,
+// plus the value of --config if given. Only explicitly set flags are returned.
+func parseFlags(args []string) (map[string]string, string, error) {
+	fs := flag.NewFlagSet("template-go", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	file := fs.String("config", "", "path to a YAML or TOML config file")
+	values := make(map[string]*string, len(configFields))
+	for _, f := range configFields {
+		values[flagName(f.key)] = fs.String(flagName(f.key), f.def, f.desc)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, "", fmt.Errorf("invalid flags: %w", err)
+	}
+
+	set := make(map[string]string)
+	fs.Visit(func(fl *flag.Flag) {
+		for _, f := range configFields {
+			if flagName(f.key) == fl.Name {
+				set[f.key] = *values[fl.Name]
+			}
+		}
+	})
+	return set, *file, nil
+}
+
+// readFile decodes a YAML or TOML file, chosen by extension, and flattens it
+// into setting values keyed by their dotted name.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	known := make(map[string]bool, len(configFields))
+	for _, f := range configFields {
+		known[f.key] = true
+	}
+	out := make(map[string]string)
+	if err := flatten("", raw, known, out); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// flatten walks nested sections, storing the textual form of every known
+// setting under its dotted key. Unknown keys are reported as errors so that
+// typos do not go unnoticed.
+func flatten(prefix string, node map[string]any, known map[string]bool, out map[string]string) error {
+	var errs []error
+	for k, v := range node {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if known[key] {
+			out[key] = formatValue(v)
+			continue
+		}
+		child, ok := v.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown key %q", key))
+			continue
+		}
+		if err := flatten(key, child, known, out); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// formatValue renders a decoded file value in the same textual form accepted
+// from environment variables: lists are comma separated and maps are
+// comma separated key=value pairs.
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = formatValue(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		parts := make([]string, 0, len(val))
+		for k, item := range val {
+			parts = append(parts, k+"="+formatValue(item))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(val)
+	}
+}
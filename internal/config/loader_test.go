@@ -0,0 +1,214 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envMap returns a LookupEnv function backed by a map so tests are isolated
+// from the process environment.
+func envMap(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+// writeFile creates a file with the given name and contents in a temp dir.
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+const yamlConfig = `
+server:
+  listen_addr: ":7000"
+otel:
+  exporter: stdout
+  service_name: from-file
+  timeout: 2s
+  headers:
+    authorization: Bearer abc
+    x-tenant: acme
+logging:
+  level: debug
+`
+
+func TestRead_Defaults(t *testing.T) {
+	cfg, err := Read(Options{LookupEnv: envMap(nil)})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":8080", cfg.Server.ListenAddr)
+	assert.Equal(t, "otlp", cfg.OTEL.Exporter)
+	assert.Equal(t, 10*time.Second, cfg.OTEL.Timeout)
+	assert.Equal(t, "info", cfg.Logging.Level)
+	assert.Equal(t, "json", cfg.Logging.Format)
+	assert.Equal(t, SourceDefault, cfg.Source("server.listen_addr"))
+	assert.Equal(t, SourceDefault, cfg.Source("no.such.key"))
+	assert.Empty(t, cfg.File())
+}
+
+func TestRead_YAMLFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", yamlConfig)
+
+	cfg, err := Read(Options{File: path, LookupEnv: envMap(nil)})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":7000", cfg.Server.ListenAddr)
+	assert.Equal(t, "stdout", cfg.OTEL.Exporter)
+	assert.Equal(t, "from-file", cfg.OTEL.ServiceName)
+	assert.Equal(t, 2*time.Second, cfg.OTEL.Timeout)
+	assert.Equal(t, map[string]string{"authorization": "Bearer abc", "x-tenant": "acme"}, cfg.OTEL.Headers)
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, SourceFile, cfg.Source("otel.headers"))
+	assert.Equal(t, SourceProfile, cfg.Source("logging.format"))
+}
+
+func TestRead_TOMLFile(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+[server]
+listen_addr = ":7001"
+
+[otel]
+insecure = true
+`)
+
+	cfg, err := Read(Options{File: path, LookupEnv: envMap(nil)})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":7001", cfg.Server.ListenAddr)
+	assert.True(t, cfg.OTEL.Insecure)
+}
+
+func TestRead_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", yamlConfig)
+	env := envMap(map[string]string{
+		"CONFIG_FILE":       path,
+		"LISTEN_ADDR":       ":7100",
+		"OTEL_SERVICE_NAME": "from-env",
+	})
+
+	cfg, err := Read(Options{
+		Args:      []string{"--server.listen-addr", ":7200"},
+		LookupEnv: env,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		key    string
+		got    string
+		want   string
+		source Source
+	}{
+		{"server.listen_addr", cfg.Server.ListenAddr, ":7200", SourceFlag},
+		{"otel.service_name", cfg.OTEL.ServiceName, "from-env", SourceEnv},
+		{"otel.exporter", cfg.OTEL.Exporter, "stdout", SourceFile},
+		{"logging.format", cfg.Logging.Format, "json", SourceProfile},
+		{"otel.compression", cfg.OTEL.Compression, "gzip", SourceDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.got)
+			assert.Equal(t, tt.source, cfg.Source(tt.key))
+		})
+	}
+	assert.Len(t, cfg.Sources(), len(configFields))
+}
+
+func TestRead_ConfigFlag(t *testing.T) {
+	path := writeFile(t, "config.yml", yamlConfig)
+
+	cfg, err := Read(Options{Args: []string{"--config=" + path}, LookupEnv: envMap(nil)})
+	require.NoError(t, err)
+
+	assert.Equal(t, ":7000", cfg.Server.ListenAddr)
+}
+
+func TestRead_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    func(t *testing.T) Options
+		wantErr string
+	}{
+		{
+			name: "unknown flag",
+			opts: func(t *testing.T) Options {
+				return Options{Args: []string{"--nope"}}
+			},
+			wantErr: "invalid flags",
+		},
+		{
+			name: "missing file",
+			opts: func(t *testing.T) Options {
+				return Options{File: filepath.Join(t.TempDir(), "missing.yaml")}
+			},
+			wantErr: "failed to read config file",
+		},
+		{
+			name: "unsupported extension",
+			opts: func(t *testing.T) Options {
+				return Options{File: writeFile(t, "config.ini", "a=b")}
+			},
+			wantErr: `unsupported config file extension ".ini"`,
+		},
+		{
+			name: "malformed yaml",
+			opts: func(t *testing.T) Options {
+				return Options{File: writeFile(t, "config.yaml", "server: [")}
+			},
+			wantErr: "failed to parse config file",
+		},
+		{
+			name: "unknown key",
+			opts: func(t *testing.T) Options {
+				return Options{File: writeFile(t, "config.yaml", "server:\n  listen_adr: \":1\"\n")}
+			},
+			wantErr: `unknown key "server.listen_adr"`,
+		},
+		{
+			name: "invalid duration",
+			opts: func(t *testing.T) Options {
+				return Options{Args: []string{"--otel.timeout=soon"}}
+			},
+			wantErr: `invalid duration "soon" (from flag)`,
+		},
+		{
+			name: "invalid map",
+			opts: func(t *testing.T) Options {
+				return Options{LookupEnv: envMap(map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "broken"})}
+			},
+			wantErr: `invalid key=value pair "broken" (from env)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts(t)
+			if opts.LookupEnv == nil {
+				opts.LookupEnv = envMap(nil)
+			}
+			_, err := Read(opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestRead_AggregatesParseErrors(t *testing.T) {
+	_, err := Read(Options{
+		Args:      []string{"--otel.timeout=soon"},
+		LookupEnv: envMap(map[string]string{"OTEL_EXPORTER_OTLP_INSECURE": "maybe"}),
+	})
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Len(t, verr.Errors, 2)
+	assert.Equal(t, "otel.insecure", verr.Errors[0].Key)
+	assert.Equal(t, "otel.timeout", verr.Errors[1].Key)
+}
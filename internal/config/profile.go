@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Deployment profiles selected by APP_ENV, --env or the "env" file key.
+const (
+	ProfileDev     = "dev"
+	ProfileStaging = "staging"
+	ProfileProd    = "prod"
+)
+
+// profileDefaults override the built-in defaults for each profile. They are
+// still overridden by the config file, environment and flags.
+var profileDefaults = map[string]map[string]string{
+	ProfileDev: {
+		"logging.level":  "debug",
+		"logging.format": "console",
+		"otel.exporter":  ExporterStdout,
+	},
+	ProfileStaging: {
+		"logging.level":  "info",
+		"logging.format": "json",
+		"otel.exporter":  ExporterOTLP,
+	},
+	ProfileProd: {
+		"logging.level":  "info",
+		"logging.format": "json",
+		"otel.exporter":  ExporterOTLP,
+	},
+}
+
+// selectProfile resolves the active profile with the usual precedence:
+// flag, environment, base config file, then ProfileProd, which is also the
+// default of the "env" field.
+func selectProfile(flags map[string]string, lookup func(string) (string, bool), fromFile map[string]string) string {
+	if v, ok := flags["env"]; ok {
+		return v
+	}
+	if v, ok := lookup("APP_ENV"); ok {
+		return v
+	}
+	if v, ok := fromFile["env"]; ok {
+		return v
+	}
+	return ProfileProd
+}
+
+// overlayPath returns the profile overlay next to base, e.g.
+// "config.staging.yaml" for "config.yaml", or "" if there is none.
+func overlayPath(base, profile string) string {
+	if base == "" || profile == "" {
+		return ""
+	}
+	ext := filepath.Ext(base)
+	path := strings.TrimSuffix(base, ext) + "." + profile + ext
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// IsDev reports whether the development profile is active.
+func (c Config) IsDev() bool {
+	return c.Env == ProfileDev
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead_ProfileDefaults(t *testing.T) {
+	tests := []struct {
+		env      string
+		exporter string
+		format   string
+		level    string
+	}{
+		{"dev", ExporterStdout, "console", "debug"},
+		{"staging", ExporterOTLP, "json", "info"},
+		{"prod", ExporterOTLP, "json", "info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			cfg, err := Read(Options{LookupEnv: envMap(map[string]string{"APP_ENV": tt.env})})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.env, cfg.Env)
+			assert.Equal(t, tt.exporter, cfg.OTEL.Exporter)
+			assert.Equal(t, tt.format, cfg.Logging.Format)
+			assert.Equal(t, tt.level, cfg.Logging.Level)
+			assert.Equal(t, SourceProfile, cfg.Source("otel.exporter"))
+			assert.Equal(t, tt.env == "dev", cfg.IsDev())
+		})
+	}
+}
+
+func TestRead_DefaultProfileIsProd(t *testing.T) {
+	cfg, err := Read(Options{LookupEnv: envMap(nil)})
+	require.NoError(t, err)
+
+	assert.Equal(t, ProfileProd, cfg.Env)
+	assert.Equal(t, SourceDefault, cfg.Source("env"))
+}
+
+func TestRead_ProfileOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("env: staging\nserver:\n  listen_addr: \":7000\"\nlogging:\n  level: warn\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.staging.yaml"), []byte("logging:\n  level: error\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.dev.yaml"), []byte("logging:\n  level: debug\n"), 0o600))
+
+	t.Run("profile from base file", func(t *testing.T) {
+		cfg, err := Read(Options{File: base, LookupEnv: envMap(nil)})
+		require.NoError(t, err)
+
+		assert.Equal(t, "staging", cfg.Env)
+		assert.Equal(t, ":7000", cfg.Server.ListenAddr)
+		assert.Equal(t, "error", cfg.Logging.Level, "overlay wins over base file")
+		assert.Equal(t, []string{base, filepath.Join(dir, "config.staging.yaml")}, cfg.Files())
+		assert.Equal(t, base, cfg.File())
+	})
+
+	t.Run("flag selects another overlay", func(t *testing.T) {
+		cfg, err := Read(Options{File: base, Args: []string{"--env=dev"}, LookupEnv: envMap(nil)})
+		require.NoError(t, err)
+
+		assert.Equal(t, "dev", cfg.Env)
+		assert.Equal(t, "debug", cfg.Logging.Level)
+		assert.Equal(t, "console", cfg.Logging.Format, "dev profile default still applies")
+	})
+
+	t.Run("env overrides overlay", func(t *testing.T) {
+		cfg, err := Read(Options{File: base, LookupEnv: envMap(map[string]string{"LOG_LEVEL": "info"})})
+		require.NoError(t, err)
+
+		assert.Equal(t, "info", cfg.Logging.Level)
+	})
+
+	t.Run("missing overlay is ignored", func(t *testing.T) {
+		cfg, err := Read(Options{File: base, LookupEnv: envMap(map[string]string{"APP_ENV": "prod"})})
+		require.NoError(t, err)
+
+		assert.Equal(t, "warn", cfg.Logging.Level)
+		assert.Equal(t, []string{base}, cfg.Files())
+	})
+}
+
+func TestRead_InvalidOverlay(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("env: dev\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.dev.yaml"), []byte("nope: 1\n"), 0o600))
+
+	_, err := Read(Options{File: base, LookupEnv: envMap(nil)})
+
+	assert.ErrorContains(t, err, `unknown key "nope"`)
+}
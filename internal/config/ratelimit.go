@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limiting algorithms accepted by RateLimitPolicy.Algorithm.
+const (
+	AlgorithmTokenBucket   = "token_bucket"
+	AlgorithmSlidingWindow = "sliding_window"
+)
+
+// Built-in rate limit keys accepted by RateLimitPolicy.Key. api_key and
+// tenant identify the authenticated caller; requests without one are
+// counted by IP. A key may also be "header:<Name>" or the name of an
+// extractor registered in code.
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyAPIKey = "api_key"
+	RateLimitKeyTenant = "tenant"
+)
+
+// RateLimitConfig configures request rate limiting on the public router.
+type RateLimitConfig struct {
+	Enabled bool   `config:"enabled" env:"RATE_LIMIT_ENABLED" default:"false" desc:"Rate limit requests on the public listener."`
+	Store   string `config:"store" env:"RATE_LIMIT_STORE" default:"memory" enum:"memory,redis" desc:"Where counters are kept; use redis when running several replicas."`
+
+	RedisAddr     string `config:"redis_addr" env:"RATE_LIMIT_REDIS_ADDR" default:"localhost:6379" desc:"Redis host:port used by the redis store."`
+	RedisPassword string `config:"redis_password" env:"RATE_LIMIT_REDIS_PASSWORD" secret:"true" desc:"Redis password."`
+	RedisDB       int    `config:"redis_db" env:"RATE_LIMIT_REDIS_DB" default:"0" desc:"Redis database number."`
+
+	// Default applies to every route without a policy of its own.
+	Default RateLimitPolicy `config:"default" env:"RATE_LIMIT_DEFAULT" default:"100/1m" desc:"Policy for routes without their own, e.g. \"100/1m burst=20 key=ip\"; \"none\" disables it."`
+	// Routes are keyed by chi route pattern, optionally preceded by a
+	// method: "/v1/search" or "POST /v1/login".
+	Routes map[string]RateLimitPolicy `config:"routes" env:"RATE_LIMIT_ROUTES" desc:"Per-route policies as \"[METHOD ]pattern=policy\" pairs."`
+	// AuthFailures limits the requests answered with 401 per client IP, so
+	// credentials cannot be guessed faster than it allows.
+	AuthFailures RateLimitPolicy `config:"auth_failures" env:"RATE_LIMIT_AUTH_FAILURES" default:"10/1m" desc:"Failed authentications (401 responses) allowed per client IP; \"none\" disables it."`
+}
+
+// RateLimitPolicy is a rate limit written as "<requests>/<window>" followed
+// by optional space separated settings, e.g.
+//
+//	100/1m
+//	10/1s burst=30 key=api_key
+//	1000/1h algorithm=sliding_window key=header:X-Client-ID
+//
+// The token bucket (the default algorithm) refills requests per window and
+// holds up to burst tokens, which defaults to requests. The sliding window
+// allows requests within any window-long period. The key defaults to ip.
+// "none" or an empty string is the zero policy, which does not limit.
+type RateLimitPolicy struct {
+	Requests  int
+	Window    time.Duration
+	Burst     int
+	Algorithm string
+	Key       string
+}
+
+// ParseRateLimitPolicy parses the textual form of a policy.
+func ParseRateLimitPolicy(s string) (RateLimitPolicy, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || (len(fields) == 1 && fields[0] == "none") {
+		return RateLimitPolicy{}, nil
+	}
+
+	rate, window, ok := strings.Cut(fields[0], "/")
+	if !ok {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %q must start with <requests>/<window>", s)
+	}
+	p := RateLimitPolicy{Algorithm: AlgorithmTokenBucket, Key: RateLimitKeyIP}
+	var err error
+	if p.Requests, err = strconv.Atoi(rate); err != nil || p.Requests <= 0 {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %q: requests must be a positive integer", s)
+	}
+	if !strings.ContainsAny(window, "0123456789") {
+		window = "1" + window // "10/s" reads as "10/1s"
+	}
+	if p.Window, err = time.ParseDuration(window); err != nil || p.Window < time.Millisecond {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %q: window must be a duration of at least 1ms", s)
+	}
+
+	for _, opt := range fields[1:] {
+		name, value, ok := strings.Cut(opt, "=")
+		if !ok || value == "" {
+			return RateLimitPolicy{}, fmt.Errorf("rate limit %q: setting %q must be name=value", s, opt)
+		}
+		switch name {
+		case "burst":
+			if p.Burst, err = strconv.Atoi(value); err != nil || p.Burst <= 0 {
+				return RateLimitPolicy{}, fmt.Errorf("rate limit %q: burst must be a positive integer", s)
+			}
+		case "algorithm":
+			if value != AlgorithmTokenBucket && value != AlgorithmSlidingWindow {
+				return RateLimitPolicy{}, fmt.Errorf("rate limit %q: algorithm must be %s or %s", s, AlgorithmTokenBucket, AlgorithmSlidingWindow)
+			}
+			p.Algorithm = value
+		case "key":
+			if h, ok := strings.CutPrefix(value, "header:"); ok && h == "" {
+				return RateLimitPolicy{}, fmt.Errorf("rate limit %q: key header: needs a header name", s)
+			}
+			p.Key = value
+		default:
+			return RateLimitPolicy{}, fmt.Errorf("rate limit %q: unknown setting %q", s, name)
+		}
+	}
+	if p.Burst == 0 {
+		p.Burst = p.Requests
+	}
+	return p, nil
+}
+
+// Enabled reports whether p limits anything.
+func (p RateLimitPolicy) Enabled() bool {
+	return p.Requests > 0
+}
+
+// String formats p in the form accepted by ParseRateLimitPolicy.
+func (p RateLimitPolicy) String() string {
+	if !p.Enabled() {
+		return "none"
+	}
+	s := strconv.Itoa(p.Requests) + "/" + p.Window.String()
+	if p.Burst != 0 && p.Burst != p.Requests {
+		s += " burst=" + strconv.Itoa(p.Burst)
+	}
+	if p.Algorithm != "" && p.Algorithm != AlgorithmTokenBucket {
+		s += " algorithm=" + p.Algorithm
+	}
+	if p.Key != "" && p.Key != RateLimitKeyIP {
+		s += " key=" + p.Key
+	}
+	return s
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *RateLimitPolicy) UnmarshalText(text []byte) error {
+	parsed, err := ParseRateLimitPolicy(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p RateLimitPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimitPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RateLimitPolicy
+		wantErr bool
+	}{
+		{"100/1m", RateLimitPolicy{Requests: 100, Window: time.Minute, Burst: 100, Algorithm: AlgorithmTokenBucket, Key: RateLimitKeyIP}, false},
+		{"10/s burst=30 key=api_key", RateLimitPolicy{Requests: 10, Window: time.Second, Burst: 30, Algorithm: AlgorithmTokenBucket, Key: RateLimitKeyAPIKey}, false},
+		{" 5/10s  algorithm=sliding_window key=header:X-Client-ID ", RateLimitPolicy{Requests: 5, Window: 10 * time.Second, Burst: 5, Algorithm: AlgorithmSlidingWindow, Key: "header:X-Client-ID"}, false},
+		{"none", RateLimitPolicy{}, false},
+		{"", RateLimitPolicy{}, false},
+		{"100", RateLimitPolicy{}, true},
+		{"0/1m", RateLimitPolicy{}, true},
+		{"10/forever", RateLimitPolicy{}, true},
+		{"10/1us", RateLimitPolicy{}, true},
+		{"10/1m burst=0", RateLimitPolicy{}, true},
+		{"10/1m algorithm=leaky_bucket", RateLimitPolicy{}, true},
+		{"10/1m key=header:", RateLimitPolicy{}, true},
+		{"10/1m burst", RateLimitPolicy{}, true},
+		{"10/1m color=red", RateLimitPolicy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRateLimitPolicy(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRateLimitPolicy_StringRoundTrip(t *testing.T) {
+	for _, in := range []string{"none", "100/1m0s", "10/1s burst=30 key=api_key", "5/10s algorithm=sliding_window key=tenant"} {
+		p, err := ParseRateLimitPolicy(in)
+		require.NoError(t, err)
+		assert.Equal(t, in, p.String())
+	}
+}
+
+func TestRateLimitPolicy_Text(t *testing.T) {
+	var p RateLimitPolicy
+	require.NoError(t, p.UnmarshalText([]byte("10/1s burst=30")))
+	text, err := p.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "10/1s burst=30", string(text))
+
+	assert.Error(t, p.UnmarshalText([]byte("ten per second")))
+	assert.Equal(t, 10, p.Requests, "a failed unmarshal keeps the policy")
+}
+
+func TestRead_RateLimitRoutes(t *testing.T) {
+	// GIVEN route policies in the config file
+	path := writeFile(t, "config.yaml", `
+rate_limit:
+  enabled: true
+  default: 50/1m
+  routes:
+    "POST /v1/login": 5/1m key=ip
+    /v1/search: 10/1s algorithm=sliding_window key=api_key
+`)
+
+	// WHEN it is read
+	cfg, err := Read(Options{File: path, LookupEnv: envMap(nil)})
+
+	// THEN every route is keyed by its pattern and parsed
+	require.NoError(t, err)
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, 50, cfg.RateLimit.Default.Requests)
+	assert.Equal(t, map[string]RateLimitPolicy{
+		"POST /v1/login": {Requests: 5, Window: time.Minute, Burst: 5, Algorithm: AlgorithmTokenBucket, Key: RateLimitKeyIP},
+		"/v1/search":     {Requests: 10, Window: time.Second, Burst: 10, Algorithm: AlgorithmSlidingWindow, Key: RateLimitKeyAPIKey},
+	}, cfg.RateLimit.Routes)
+	require.NoError(t, cfg.Validate())
+}
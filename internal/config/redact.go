@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// RedactedValue replaces the value of secret settings whenever a Config is
+// printed or logged.
+const RedactedValue = "[REDACTED]"
+
+// display returns the textual form of value to show for f, hiding secrets.
+func (f field) display(value string) string {
+	if f.secret && value != "" {
+		return RedactedValue
+	}
+	return value
+}
+
+// formatField renders a setting value in the same textual form accepted by
+// the loader: lists are comma separated and maps are sorted k=v pairs.
+func formatField(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatField(v.Index(i))
+		}
+		return strings.Join(parts, ",")
+	case reflect.Map:
+		parts := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			parts = append(parts, fmt.Sprint(iter.Key().Interface())+"="+formatField(iter.Value()))
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Values returns the textual value of every setting keyed by its dotted
+// name, with secret settings redacted.
+func (c Config) Values() map[string]string {
+	cv := reflect.ValueOf(&c)
+	out := make(map[string]string, len(configFields))
+	for _, f := range configFields {
+		out[f.key] = f.display(formatField(f.value(cv)))
+	}
+	return out
+}
+
+// Redacted returns a copy of c in which every non-empty secret setting is
+// replaced: strings and map values by RedactedValue, other kinds by their
+// zero value.
+func (c Config) Redacted() Config {
+	cv := reflect.ValueOf(&c)
+	for _, f := range configFields {
+		if v := f.value(cv); f.secret && !v.IsZero() {
+			redact(v)
+		}
+	}
+	return c
+}
+
+// redact replaces the settable value v as described by Config.Redacted.
+func redact(v reflect.Value) {
+	switch {
+	case v.Kind() == reflect.String:
+		v.SetString(RedactedValue)
+	case v.Kind() == reflect.Map && v.Type().Elem().Kind() == reflect.String:
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), reflect.ValueOf(RedactedValue).Convert(v.Type().Elem()))
+		}
+		v.Set(m)
+	default:
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+// String implements fmt.Stringer, listing every setting in declaration
+// order with secrets redacted.
+func (c Config) String() string {
+	values := c.Values()
+	parts := make([]string, len(configFields))
+	for i, f := range configFields {
+		parts[i] = f.key + "=" + values[f.key]
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// GoString implements fmt.GoStringer so %#v does not leak secrets either.
+func (c Config) GoString() string {
+	return "config.Config" + c.String()
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler so zap.Any and
+// zap.Object log the redacted settings.
+func (c Config) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	cv := reflect.ValueOf(&c)
+	for _, f := range configFields {
+		enc.AddString(f.key, f.display(formatField(f.value(cv))))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// secretConfig returns a valid Config carrying secret values.
+func secretConfig() Config {
+	cfg := validConfig()
+	cfg.DB = DBConfig{Driver: "postgres", DSN: "postgres://user:hunter2@db/app"}
+	cfg.OTEL.Headers = map[string]string{"authorization": "Bearer hunter2"}
+	return cfg
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := secretConfig()
+
+	r := cfg.Redacted()
+
+	assert.Equal(t, RedactedValue, r.DB.DSN)
+	assert.Equal(t, map[string]string{"authorization": RedactedValue}, r.OTEL.Headers)
+	assert.Equal(t, "postgres", r.DB.Driver)
+	// The original is untouched.
+	assert.Equal(t, "postgres://user:hunter2@db/app", cfg.DB.DSN)
+	assert.Equal(t, "Bearer hunter2", cfg.OTEL.Headers["authorization"])
+}
+
+func TestConfig_RedactedKeepsEmptySecrets(t *testing.T) {
+	r := validConfig().Redacted()
+
+	assert.Empty(t, r.DB.DSN)
+	assert.Nil(t, r.OTEL.Headers)
+}
+
+func TestRedact_OtherKinds(t *testing.T) {
+	target := struct {
+		Key  []byte
+		Pins []string
+	}{Key: []byte("hunter2"), Pins: []string{"1234"}}
+	v := reflect.ValueOf(&target).Elem()
+
+	redact(v.Field(0))
+	redact(v.Field(1))
+
+	assert.Nil(t, target.Key)
+	assert.Nil(t, target.Pins)
+}
+
+func TestConfig_PrintingNeverLeaksSecrets(t *testing.T) {
+	cfg := secretConfig()
+
+	for _, verb := range []string{"%v", "%+v", "%s", "%#v"} {
+		out := fmt.Sprintf(verb, cfg)
+		assert.NotContains(t, out, "hunter2", verb)
+		assert.Contains(t, out, "db.dsn="+RedactedValue, verb)
+		assert.Contains(t, out, "db.driver=postgres", verb)
+	}
+}
+
+func TestConfig_LoggingNeverLeaksSecrets(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zap.DebugLevel)
+
+	zap.New(core).Info("loaded", zap.Object("config", secretConfig()), zap.Any("any", secretConfig()))
+
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.Contains(t, buf.String(), `"db.dsn":"[REDACTED]"`)
+	assert.Contains(t, buf.String(), `"server.max_body_size":"1MiB"`)
+}
+
+func TestConfig_Values(t *testing.T) {
+	cfg := secretConfig()
+	cfg.Features = map[string]bool{"b": true, "a": false}
+
+	values := cfg.Values()
+
+	assert.Equal(t, ":8080", values["server.listen_addr"])
+	assert.Equal(t, "10s", values["otel.timeout"])
+	assert.Equal(t, "a=false,b=true", values["features"])
+	assert.Equal(t, RedactedValue, values["otel.headers"])
+}
+
+func TestField_DisplayHidesSecrets(t *testing.T) {
+	type withSecret struct {
+		Port int `config:"port" secret:"true"`
+	}
+	f := fieldsOf(reflect.TypeOf(withSecret{}))[0]
+
+	assert.Equal(t, RedactedValue, f.display("hunter2"))
+	assert.Equal(t, "", f.display(""))
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"template-go/internal/filestamp"
+	"template-go/pkg/logger"
+)
+
+// Subscriber is called after a new configuration has been swapped in.
+type Subscriber func(old, new Config)
+
+// Holder owns the live configuration. It re-reads configuration on demand,
+// validates it and only then swaps it atomically and notifies subscribers,
+// so an invalid reload never affects the running service.
+type Holder struct {
+	current atomic.Pointer[Config]
+	load    func() (Config, error)
+
+	mu   sync.Mutex // serialises reloads and guards subs
+	subs []Subscriber
+}
+
+// NewHolder returns a Holder serving cfg. load is called on every reload and
+// is expected to validate its result, e.g. a closure around Load.
+func NewHolder(cfg Config, load func() (Config, error)) *Holder {
+	h := &Holder{load: load}
+	h.current.Store(&cfg)
+	return h
+}
+
+// Get returns the current configuration.
+func (h *Holder) Get() Config {
+	return *h.current.Load()
+}
+
+// Subscribe registers fn to be notified of every successful reload.
+// Subscribers run synchronously in registration order.
+func (h *Holder) Subscribe(fn Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs = append(h.subs, fn)
+}
+
+// Reload loads a new configuration and, if it is valid, makes it current and
+// notifies subscribers. It returns the keys whose values changed.
+func (h *Holder) Reload() ([]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	next, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+	prev := h.current.Swap(&next)
+
+	changed := Diff(*prev, next)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	for _, fn := range h.subs {
+		fn(*prev, next)
+	}
+	return changed, nil
+}
+
+// Watch reloads the configuration on SIGHUP and, when the current config was
+// read from files and interval is positive, whenever the size or modification
+// time of one of them changes. Failed reloads are logged and the running
+// configuration is kept. Watch blocks until ctx is done.
+func (h *Holder) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	files := h.Get().Files()
+	if len(files) > 0 && interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	last := filestamp.Of(files)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			h.reloadAndLog(ctx, "signal")
+		case <-tick:
+			if cur := filestamp.Of(files); !slices.Equal(cur, last) {
+				last = cur
+				h.reloadAndLog(ctx, "file change")
+			}
+		}
+	}
+}
+
+// reloadAndLog reloads and reports the outcome through the logger.
+func (h *Holder) reloadAndLog(ctx context.Context, trigger string) {
+	changed, err := h.Reload()
+	if err != nil {
+		logger.Error(ctx, "config reload rejected, keeping current configuration",
+			zap.String("trigger", trigger), zap.Error(err))
+		return
+	}
+	logger.Info(ctx, "config reloaded", zap.String("trigger", trigger), zap.Strings("changed", changed))
+}
+
+// Diff returns the dotted keys of settings whose values differ between a and b.
+func Diff(a, b Config) []string {
+	av, bv := reflect.ValueOf(&a), reflect.ValueOf(&b)
+	var changed []string
+	for _, f := range configFields {
+		if !reflect.DeepEqual(f.value(av).Interface(), f.value(bv).Interface()) {
+			changed = append(changed, f.key)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"template-go/pkg/logger"
+)
+
+func TestHolder_ReloadNotifiesSubscribers(t *testing.T) {
+	initial := validConfig()
+	next := validConfig()
+	next.Logging.Level = "debug"
+	next.Features = map[string]bool{"checkout": true}
+
+	h := NewHolder(initial, func() (Config, error) { return next, nil })
+	var gotOld, gotNew Config
+	calls := 0
+	h.Subscribe(func(old, new Config) {
+		calls++
+		gotOld, gotNew = old, new
+	})
+
+	changed, err := h.Reload()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"logging.level", "features"}, changed)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "info", gotOld.Logging.Level)
+	assert.Equal(t, "debug", gotNew.Logging.Level)
+	assert.Equal(t, "debug", h.Get().Logging.Level)
+}
+
+func TestHolder_ReloadRejectsInvalidConfig(t *testing.T) {
+	h := NewHolder(validConfig(), func() (Config, error) {
+		return Config{}, errors.New("invalid configuration")
+	})
+	h.Subscribe(func(old, new Config) { t.Fatal("subscriber must not run for rejected reloads") })
+
+	_, err := h.Reload()
+
+	assert.EqualError(t, err, "invalid configuration")
+	assert.Equal(t, ":8080", h.Get().Server.ListenAddr)
+}
+
+func TestHolder_ReloadWithoutChanges(t *testing.T) {
+	h := NewHolder(validConfig(), func() (Config, error) { return validConfig(), nil })
+	h.Subscribe(func(old, new Config) { t.Fatal("subscriber must not run when nothing changed") })
+
+	changed, err := h.Reload()
+
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+}
+
+// watch runs h.Watch until the test ends, so that it no longer logs or
+// listens for signals when the next test starts.
+func watch(t *testing.T, h *Holder, interval time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.Watch(ctx, interval)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestHolder_WatchReloadsOnFileChange(t *testing.T) {
+	logger.Init()
+	path := writeFile(t, "config.yaml", "logging:\n  level: info\n")
+	load := func() (Config, error) {
+		return Load("--config", path)
+	}
+	cfg, err := load()
+	require.NoError(t, err)
+
+	h := NewHolder(cfg, load)
+	reloaded := make(chan Config, 1)
+	h.Subscribe(func(_, new Config) { reloaded <- new })
+
+	watch(t, h, 10*time.Millisecond)
+
+	// An invalid edit is rejected and the running configuration is kept.
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: loud\n"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "info", h.Get().Logging.Level)
+
+	require.NoError(t, os.WriteFile(path, []byte("logging:\n  level: debug\n"), 0o600))
+	select {
+	case got := <-reloaded:
+		assert.Equal(t, "debug", got.Logging.Level)
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the file change to trigger a reload")
+	}
+}
+
+func TestHolder_WatchLogsRejectedReloadOnSignal(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(logger.Replace(zap.New(core)))
+	// Keep SIGHUP from terminating the test process before Watch listens.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	t.Cleanup(func() { signal.Stop(hup) })
+
+	h := NewHolder(validConfig(), func() (Config, error) {
+		return Config{}, errors.New("invalid configuration")
+	})
+	watch(t, h, 0)
+
+	assert.Eventually(t, func() bool {
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+		return logs.FilterMessage("config reload rejected, keeping current configuration").
+			FilterField(zap.String("trigger", "signal")).Len() > 0
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, ":8080", h.Get().Server.ListenAddr)
+}
+
+func TestDiff(t *testing.T) {
+	a, b := validConfig(), validConfig()
+	assert.Empty(t, Diff(a, b))
+
+	b.Server.ListenAddr = ":9000"
+	b.OTEL.Headers = map[string]string{"x": "y"}
+
+	assert.Equal(t, []string{"server.listen_addr", "otel.headers"}, Diff(a, b))
+}
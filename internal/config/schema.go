@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Setting describes the effective value of one configuration setting.
+type Setting struct {
+	Key         string `json:"key"`
+	Env         string `json:"env,omitempty"`
+	Flag        string `json:"flag"`
+	Value       string `json:"value"`
+	Source      Source `json:"source"`
+	Secret      bool   `json:"secret,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Settings lists every setting of c in declaration order with its effective
+// value and origin. Secret values are redacted.
+func (c Config) Settings() []Setting {
+	values := c.Values()
+	out := make([]Setting, len(configFields))
+	for i, f := range configFields {
+		out[i] = Setting{
+			Key:         f.key,
+			Env:         f.env,
+			Flag:        "--" + flagName(f.key),
+			Value:       values[f.key],
+			Source:      c.Source(f.key),
+			Secret:      f.secret,
+			Description: f.desc,
+		}
+	}
+	return out
+}
+
+// schemaNode is the subset of JSON Schema emitted by JSONSchema.
+type schemaNode struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 any                    `json:"type,omitempty"`
+	Properties           map[string]*schemaNode `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *schemaNode            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	WriteOnly            bool                   `json:"writeOnly,omitempty"`
+}
+
+// Patterns for values that are written as strings but have structure.
+const (
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+	byteSizePattern = `^[0-9]+(\.[0-9]+)?\s*([kKmMgG]([iI]?[bB])?|[bB])?$`
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the config
+// file layout, suitable for validating Helm values or CI fixtures.
+func JSONSchema() ([]byte, error) {
+	root := &schemaNode{
+		Schema:               "https://json-schema.org/draft/2020-12/schema",
+		Title:                "template-go configuration",
+		Type:                 "object",
+		Properties:           map[string]*schemaNode{},
+		AdditionalProperties: false,
+	}
+	for _, f := range configFields {
+		parent := root
+		parts := strings.Split(f.key, ".")
+		for _, section := range parts[:len(parts)-1] {
+			child, ok := parent.Properties[section]
+			if !ok {
+				child = &schemaNode{Type: "object", Properties: map[string]*schemaNode{}, AdditionalProperties: false}
+				parent.Properties[section] = child
+			}
+			parent = child
+		}
+		name := parts[len(parts)-1]
+		parent.Properties[name] = fieldSchema(f)
+		if f.required {
+			parent.Required = append(parent.Required, name)
+		}
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// fieldSchema describes a single leaf setting.
+func fieldSchema(f field) *schemaNode {
+	node := typeSchema(f.typ)
+	node.Description = f.desc
+	node.Enum = f.enum
+	node.WriteOnly = f.secret
+	if f.def != "" {
+		node.Default = typedDefault(f.typ, f.def)
+	}
+	return node
+}
+
+// typeSchema maps a Go type to its JSON Schema representation.
+func typeSchema(t reflect.Type) *schemaNode {
+	switch {
+	case t == durationType:
+		return &schemaNode{Type: "string", Pattern: durationPattern}
+	case t == reflect.TypeOf(ByteSize(0)):
+		return &schemaNode{Type: []string{"string", "integer"}, Pattern: byteSizePattern}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schemaNode{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schemaNode{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schemaNode{Type: "number"}
+	case reflect.Slice:
+		return &schemaNode{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &schemaNode{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	default:
+		return &schemaNode{Type: "string"}
+	}
+}
+
+// typedDefault converts a textual default into the JSON type used by the
+// schema so that tools render it naturally.
+func typedDefault(t reflect.Type, def string) any {
+	if t == durationType || t == reflect.TypeOf(ByteSize(0)) {
+		return def
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(def); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(def, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(def, 64); err == nil {
+			return n
+		}
+	case reflect.Slice:
+		return splitList(def)
+	}
+	return def
+}
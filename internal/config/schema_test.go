@@ -0,0 +1,82 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	data, err := JSONSchema()
+	require.NoError(t, err)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	prop := func(path ...string) map[string]any {
+		t.Helper()
+		node := schema
+		for _, p := range path {
+			props, ok := node["properties"].(map[string]any)
+			require.True(t, ok, "no properties at %v", path)
+			node, ok = props[p].(map[string]any)
+			require.True(t, ok, "no property %q", p)
+		}
+		return node
+	}
+
+	assert.Equal(t, "object", schema["type"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	listen := prop("server", "listen_addr")
+	assert.Equal(t, "string", listen["type"])
+	assert.Equal(t, ":8080", listen["default"])
+	assert.Equal(t, "Address the HTTP server listens on.", listen["description"])
+
+	assert.Equal(t, []any{"string", "integer"}, prop("server", "max_body_size")["type"])
+	assert.Equal(t, durationPattern, prop("otel", "timeout")["pattern"])
+	assert.Equal(t, false, prop("otel", "insecure")["default"])
+	assert.Equal(t, float64(1), prop("otel", "sample_ratio")["default"])
+	assert.Contains(t, prop("otel", "exporter")["enum"], "otlp-http")
+	assert.Equal(t, []any{"service_name"}, prop("otel")["required"])
+	assert.Equal(t, true, prop("db", "dsn")["writeOnly"])
+	assert.Equal(t, map[string]any{"type": "boolean"}, prop("features")["additionalProperties"])
+}
+
+func TestSettings(t *testing.T) {
+	cfg, err := Read(Options{
+		Args:      []string{"--logging.level=debug"},
+		LookupEnv: envMap(map[string]string{"DB_DSN": "postgres://secret"}),
+	})
+	require.NoError(t, err)
+
+	settings := cfg.Settings()
+	require.Len(t, settings, len(configFields))
+
+	byKey := map[string]Setting{}
+	for _, s := range settings {
+		byKey[s.Key] = s
+	}
+	assert.Equal(t, Setting{
+		Key:         "logging.level",
+		Env:         "LOG_LEVEL",
+		Flag:        "--logging.level",
+		Value:       "debug",
+		Source:      SourceFlag,
+		Description: "Minimum log level.",
+	}, byKey["logging.level"])
+	assert.Equal(t, RedactedValue, byKey["db.dsn"].Value)
+	assert.True(t, byKey["db.dsn"].Secret)
+	assert.Equal(t, SourceEnv, byKey["db.dsn"].Source)
+	assert.Equal(t, "--server.max-body-size", byKey["server.max_body_size"].Flag)
+}
+
+func TestExampleConfigIsValid(t *testing.T) {
+	cfg, err := Read(Options{File: "../../config.example.yaml", LookupEnv: envMap(nil)})
+	require.NoError(t, err)
+
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, SourceFile, cfg.Source("server.listen_addr"))
+}
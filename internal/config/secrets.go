@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SecretProvider resolves references of the form "<scheme>://<ref>" found in
+// configuration values, e.g. "vault://secret/data/app#db_password".
+type SecretProvider interface {
+	// Scheme is the reference scheme handled by the provider.
+	Scheme() string
+	// Resolve returns the secret identified by ref (the part after "://").
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// fileProvider resolves "file:///path" to the trimmed contents of the file.
+type fileProvider struct{}
+
+// Scheme implements SecretProvider.
+func (fileProvider) Scheme() string { return "file" }
+
+// Resolve implements SecretProvider.
+func (fileProvider) Resolve(_ context.Context, ref string) (string, error) {
+	return readSecretFile(ref)
+}
+
+// envProvider resolves "env://NAME" to the value of another variable.
+type envProvider struct {
+	lookup func(string) (string, bool)
+}
+
+// Scheme implements SecretProvider.
+func (envProvider) Scheme() string { return "env" }
+
+// Resolve implements SecretProvider.
+func (p envProvider) Resolve(_ context.Context, ref string) (string, error) {
+	v, ok := p.lookup(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return v, nil
+}
+
+// readSecretFile reads a mounted secret, dropping the trailing newline most
+// tools append.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// defaultProviders returns the built-in providers plus a Vault provider when
+// VAULT_ADDR is set.
+func defaultProviders(lookup func(string) (string, bool)) []SecretProvider {
+	providers := []SecretProvider{fileProvider{}, envProvider{lookup: lookup}}
+	if addr, ok := lookup("VAULT_ADDR"); ok && addr != "" {
+		token, _ := lookup("VAULT_TOKEN")
+		if path, ok := lookup("VAULT_TOKEN_FILE"); ok && token == "" {
+			token, _ = readSecretFile(path)
+		}
+		providers = append(providers, NewVaultProvider(addr, token, nil))
+	}
+	return providers
+}
+
+// resolveSecret replaces value with the secret it references if it starts
+// with the scheme of one of providers. Other values, including ordinary
+// URLs such as "http://...", are returned unchanged.
+func resolveSecret(value string, providers []SecretProvider) (string, error) {
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return value, nil
+	}
+	// Later providers override earlier ones for the same scheme.
+	for i := len(providers) - 1; i >= 0; i-- {
+		if providers[i].Scheme() == scheme {
+			secret, err := providers[i].Resolve(context.Background(), ref)
+			if err != nil {
+				return "", fmt.Errorf("resolving %s reference: %w", scheme, err)
+			}
+			return secret, nil
+		}
+	}
+	return value, nil
+}
+
+// VaultProvider reads secrets from a HashiCorp Vault compatible HTTP API.
+// References look like "vault://<path>#<key>", e.g.
+// "vault://secret/data/app#db_password". Both KV v1 and KV v2 response
+// shapes are understood.
+type VaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+// NewVaultProvider returns a provider talking to the Vault server at addr.
+// A nil client uses a client with a 10 second timeout.
+func NewVaultProvider(addr, token string, client *http.Client) *VaultProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &VaultProvider{addr: strings.TrimRight(addr, "/"), token: token, client: client}
+}
+
+// Scheme implements SecretProvider.
+func (p *VaultProvider) Scheme() string { return "vault" }
+
+// Resolve implements SecretProvider.
+func (p *VaultProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault reference %q must look like path#key", ref)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.addr+"/v1/"+(&url.URL{Path: strings.TrimLeft(path, "/")}).EscapedPath(), nil)
+	if err != nil {
+		return "", err
+	}
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s for %s", resp.Status, path)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested // KV v2 wraps the secret in a second "data" object
+	}
+	v, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %q not found at %s", key, path)
+	}
+	return fmt.Sprint(v), nil
+}
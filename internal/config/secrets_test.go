@@ -0,0 +1,154 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVaultStub serves KV v2 and v1 shaped secrets and checks the token.
+func newVaultStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.test" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			_, _ = w.Write([]byte(`{"data":{"data":{"db_dsn":"postgres://user:pw@db/app"},"metadata":{"version":3}}}`))
+		case "/v1/kv/legacy":
+			_, _ = w.Write([]byte(`{"data":{"token":"abc"}}`))
+		case "/v1/secret/data/broken":
+			_, _ = w.Write([]byte(`{`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVaultProvider_Resolve(t *testing.T) {
+	srv := newVaultStub(t)
+	p := NewVaultProvider(srv.URL+"/", "s.test", srv.Client())
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "secret/data/app#db_dsn", want: "postgres://user:pw@db/app"},
+		{ref: "/kv/legacy#token", want: "abc"},
+		{ref: "secret/data/app#missing", wantErr: `key "missing" not found`},
+		{ref: "secret/data/other#x", wantErr: "vault returned 404 Not Found"},
+		{ref: "secret/data/broken#x", wantErr: "invalid vault response"},
+		{ref: "secret/data/app", wantErr: "must look like path#key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := p.Resolve(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVaultProvider_BadToken(t *testing.T) {
+	srv := newVaultStub(t)
+	p := NewVaultProvider(srv.URL, "wrong", nil)
+
+	_, err := p.Resolve(context.Background(), "secret/data/app#db_dsn")
+
+	assert.ErrorContains(t, err, "403 Forbidden")
+}
+
+func TestRead_ResolvesSecretReferences(t *testing.T) {
+	srv := newVaultStub(t)
+	dsnFile := writeFile(t, "dsn", "postgres://from-file\n")
+	tokenFile := writeFile(t, "token", "s.test\n")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"_FILE convention", map[string]string{"DB_DSN_FILE": dsnFile}, "postgres://from-file"},
+		{"plain value wins over _FILE", map[string]string{"DB_DSN": "inline", "DB_DSN_FILE": dsnFile}, "inline"},
+		{"file reference", map[string]string{"DB_DSN": "file://" + dsnFile}, "postgres://from-file"},
+		{"env reference", map[string]string{"DB_DSN": "env://PRIMARY_DSN", "PRIMARY_DSN": "postgres://from-env"}, "postgres://from-env"},
+		{"vault reference", map[string]string{
+			"DB_DSN":           "vault://secret/data/app#db_dsn",
+			"VAULT_ADDR":       srv.URL,
+			"VAULT_TOKEN_FILE": tokenFile,
+		}, "postgres://user:pw@db/app"},
+		{"unknown scheme is kept", map[string]string{"DB_DSN": "postgres://host/db"}, "postgres://host/db"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Read(Options{LookupEnv: envMap(tt.env)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.DB.DSN)
+			assert.Equal(t, SourceEnv, cfg.Source("db.dsn"))
+		})
+	}
+}
+
+// staticProvider resolves every reference to a fixed value.
+type staticProvider struct{ value string }
+
+func (staticProvider) Scheme() string { return "static" }
+func (p staticProvider) Resolve(context.Context, string) (string, error) {
+	return p.value, nil
+}
+
+func TestRead_CustomSecretProvider(t *testing.T) {
+	cfg, err := Read(Options{
+		Args:            []string{"--otel.service-name=static://name"},
+		LookupEnv:       envMap(nil),
+		SecretProviders: []SecretProvider{staticProvider{value: "resolved"}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "resolved", cfg.OTEL.ServiceName)
+}
+
+func TestRead_SecretErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"missing _FILE", map[string]string{"DB_DSN_FILE": "/nonexistent/dsn"}, "failed to read secret file"},
+		{"missing file reference", map[string]string{"DB_DSN": "file:///nonexistent/dsn"}, "resolving file reference"},
+		{"missing env reference", map[string]string{"DB_DSN": "env://NOPE"}, "environment variable NOPE is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(Options{LookupEnv: envMap(tt.env)})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestVaultProvider_RequestErrors(t *testing.T) {
+	srv := newVaultStub(t)
+	srv.Close()
+
+	_, err := NewVaultProvider(srv.URL, "s.test", nil).Resolve(context.Background(), "secret/data/app#db_dsn")
+	assert.ErrorContains(t, err, "vault request failed")
+
+	_, err = NewVaultProvider("http://vault\x7f", "s.test", nil).Resolve(context.Background(), "secret/data/app#db_dsn")
+	assert.Error(t, err)
+}
@@ -0,0 +1,313 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError describes a single invalid setting.
+type FieldError struct {
+	// Key is the dotted setting name, e.g. "server.listen_addr".
+	Key string
+	// Value is the offending value in its textual form.
+	Value string
+	// Message explains what is wrong with the value.
+	Message string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s=%q: %s", e.Key, e.Value, e.Message)
+}
+
+// ValidationError aggregates every problem found while loading or validating
+// a Config so they can be reported at once.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error renders one problem per line, suitable for printing at startup.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid configuration (%d problem", len(e.Errors))
+	if len(e.Errors) != 1 {
+		b.WriteString("s")
+	}
+	b.WriteString("):")
+	for _, fe := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(fe.Error())
+	}
+	return b.String()
+}
+
+// add records a problem for key.
+func (e *ValidationError) add(key string, value any, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Key: key, Value: fmt.Sprint(value), Message: fmt.Sprintf(format, args...)})
+}
+
+// errOrNil returns e if it holds any problems, nil otherwise.
+func (e *ValidationError) errOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate checks every setting and returns a *ValidationError listing all
+// problems, or nil if the configuration is usable.
+func (c Config) Validate() error {
+	verr := &ValidationError{}
+
+	cv := reflect.ValueOf(&c)
+	for _, f := range configFields {
+		v := f.value(cv)
+		if f.required && isEmpty(v) {
+			verr.add(f.key, v.Interface(), "is required")
+		}
+		if len(f.enum) > 0 && v.Kind() == reflect.String {
+			checkEnum(verr, f.key, v.String(), f.enum)
+		}
+	}
+
+	checkAddr(verr, "server.listen_addr", c.Server.ListenAddr)
+	checkAddr(verr, "server.admin_addr", c.Server.AdminAddr)
+	if c.Server.AdminAddr == c.Server.ListenAddr {
+		verr.add("server.admin_addr", c.Server.AdminAddr, "must differ from server.listen_addr")
+	}
+	if c.Server.MaxBodySize <= 0 {
+		verr.add("server.max_body_size", c.Server.MaxBodySize, "must be a positive size")
+	}
+	checkNonNegative(verr, "server.read_timeout", c.Server.ReadTimeout)
+	checkNonNegative(verr, "server.read_header_timeout", c.Server.ReadHeaderTimeout)
+	checkNonNegative(verr, "server.write_timeout", c.Server.WriteTimeout)
+	checkNonNegative(verr, "server.idle_timeout", c.Server.IdleTimeout)
+	checkNonNegative(verr, "server.drain_delay", c.Server.DrainDelay)
+	checkPositive(verr, "server.shutdown_timeout", c.Server.ShutdownTimeout)
+	for _, v := range c.Server.TrustedProxies {
+		if _, err := parsePrefix(v); err != nil {
+			verr.add("server.trusted_proxies", v, "must be an IP address or CIDR")
+		}
+	}
+	checkTLS(verr, c.Server.TLS)
+
+	if c.OTEL.Endpoint != "" {
+		checkEndpoint(verr, "otel.endpoint", c.OTEL.Endpoint)
+	}
+	checkPositive(verr, "otel.timeout", c.OTEL.Timeout)
+	if c.OTEL.SampleRatio < 0 || c.OTEL.SampleRatio > 1 {
+		verr.add("otel.sample_ratio", c.OTEL.SampleRatio, "must be between 0 and 1")
+	}
+	if c.OTEL.Exporter == ExporterFile {
+		checkRequired(verr, "otel.file_path", c.OTEL.FilePath)
+	}
+
+	if c.Logging.Access.SampleRatio < 0 || c.Logging.Access.SampleRatio > 1 {
+		verr.add("logging.access.sample_ratio", c.Logging.Access.SampleRatio, "must be between 0 and 1")
+	}
+
+	if c.DB.DSN != "" {
+		checkRequired(verr, "db.driver", c.DB.Driver)
+	}
+
+	checkPositive(verr, "lifecycle.start_timeout", c.Lifecycle.StartTimeout)
+	checkPositive(verr, "lifecycle.stop_timeout", c.Lifecycle.StopTimeout)
+
+	checkRateLimit(verr, c.RateLimit)
+	checkAuth(verr, c.Auth, c.DB)
+
+	checkNonNegative(verr, "reload_interval", c.ReloadInterval)
+
+	return verr.errOrNil()
+}
+
+// checkRateLimit reports unusable rate limiting settings.
+func checkRateLimit(verr *ValidationError, r RateLimitConfig) {
+	if !r.Enabled {
+		return
+	}
+	if r.Store == "redis" {
+		checkAddr(verr, "rate_limit.redis_addr", r.RedisAddr)
+	}
+	if r.RedisDB < 0 {
+		verr.add("rate_limit.redis_db", r.RedisDB, "must not be negative")
+	}
+	if r.AuthFailures.Enabled() && r.AuthFailures.Key != RateLimitKeyIP {
+		verr.add("rate_limit.auth_failures", r.AuthFailures, "must be counted by key=ip")
+	}
+	for route := range r.Routes {
+		pattern := route
+		if method, rest, ok := strings.Cut(route, " "); ok && method == strings.ToUpper(method) {
+			pattern = strings.TrimSpace(rest)
+		}
+		if !strings.HasPrefix(pattern, "/") {
+			verr.add("rate_limit.routes", route, "must be a route pattern starting with /, optionally preceded by a method")
+		}
+	}
+}
+
+// checkAuth reports missing key sources and unsupported algorithms.
+func checkAuth(verr *ValidationError, a AuthConfig, db DBConfig) {
+	checkAPIKeys(verr, a.APIKeys, db)
+	if !a.Enabled {
+		return
+	}
+	var symmetric, asymmetric bool
+	for _, alg := range a.Algorithms {
+		switch {
+		case !slices.Contains(authAlgorithms, alg):
+			verr.add("auth.algorithms", alg, "must be one of %s", strings.Join(authAlgorithms, ", "))
+		case strings.HasPrefix(alg, "HS"):
+			symmetric = true
+		default:
+			asymmetric = true
+		}
+	}
+	if len(a.Algorithms) == 0 {
+		verr.add("auth.algorithms", "", "is required")
+	}
+	if symmetric && a.HMACSecret == "" {
+		verr.add("auth.hmac_secret", "", "is required for HS algorithms")
+	}
+	if asymmetric && a.JWKSURL == "" && a.JWKSFile == "" {
+		verr.add("auth.jwks_url", "", "jwks_url or jwks_file is required for asymmetric algorithms")
+	}
+	if a.JWKSURL != "" {
+		if u, err := url.Parse(a.JWKSURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.add("auth.jwks_url", a.JWKSURL, "must be an http(s) URL")
+		}
+	}
+	checkPositive(verr, "auth.jwks_refresh_interval", a.JWKSRefreshInterval)
+	checkNonNegative(verr, "auth.clock_skew", a.ClockSkew)
+}
+
+// checkAPIKeys reports API key settings without a key source.
+func checkAPIKeys(verr *ValidationError, k APIKeyConfig, db DBConfig) {
+	if !k.Enabled {
+		return
+	}
+	if k.Header == "" && k.QueryParam == "" {
+		verr.add("auth.api_keys.header", "", "header or query_param is required")
+	}
+	switch k.Store {
+	case APIKeyStoreFile:
+		if k.File == "" {
+			verr.add("auth.api_keys.file", "", "is required for the file store")
+		}
+	case APIKeyStoreDB:
+		if db.Driver == "" || db.DSN == "" {
+			verr.add("db.dsn", "", "db.driver and db.dsn are required for the db API key store")
+		}
+	}
+	checkNonNegative(verr, "auth.api_keys.touch_interval", k.TouchInterval)
+	if k.Admin && len(k.AdminToken) < MinAdminTokenLength {
+		verr.add("auth.api_keys.admin_token", "", "must be at least %d characters when admin is enabled", MinAdminTokenLength)
+	}
+}
+
+// checkTLS reports inconsistent TLS and mutual TLS settings.
+func checkTLS(verr *ValidationError, t TLSConfig) {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		verr.add("server.tls.key_file", t.KeyFile, "cert_file and key_file must be set together")
+	}
+	if !t.Enabled() && t.ClientAuth != "" && t.ClientAuth != ClientAuthNone {
+		verr.add("server.tls.client_auth", t.ClientAuth, "requires server.tls.cert_file")
+	}
+	if (t.ClientAuth == ClientAuthVerifyIfGiven || t.ClientAuth == ClientAuthRequireAndVerify) && t.ClientCAFile == "" {
+		verr.add("server.tls.client_ca_file", t.ClientCAFile, "is required when client_auth is %s", t.ClientAuth)
+	}
+	for _, name := range t.CipherSuites {
+		if _, ok := CipherSuite(name); !ok {
+			verr.add("server.tls.cipher_suites", name, "is not a supported cipher suite")
+		}
+	}
+	checkNonNegative(verr, "server.tls.reload_interval", t.ReloadInterval)
+}
+
+// CipherSuite returns the ID of the secure cipher suite with the given Go
+// name, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+func CipherSuite(name string) (uint16, bool) {
+	for _, cs := range tls.CipherSuites() {
+		if cs.Name == name {
+			return cs.ID, true
+		}
+	}
+	return 0, false
+}
+
+// isEmpty reports whether v is the zero value or a blank string.
+func isEmpty(v reflect.Value) bool {
+	if v.Kind() == reflect.String {
+		return strings.TrimSpace(v.String()) == ""
+	}
+	return v.IsZero()
+}
+
+// checkRequired reports an empty value.
+func checkRequired(verr *ValidationError, key, value string) {
+	if strings.TrimSpace(value) == "" {
+		verr.add(key, value, "is required")
+	}
+}
+
+// checkEnum reports a value that is not one of allowed.
+func checkEnum(verr *ValidationError, key, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		verr.add(key, value, "must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// checkPositive reports a zero or negative duration.
+func checkPositive(verr *ValidationError, key string, value time.Duration) {
+	if value <= 0 {
+		verr.add(key, value, "must be a positive duration")
+	}
+}
+
+// checkNonNegative reports a negative duration; zero usually means "disabled".
+func checkNonNegative(verr *ValidationError, key string, value time.Duration) {
+	if value < 0 {
+		verr.add(key, value, "must not be negative")
+	}
+}
+
+// checkAddr reports a value that is not a valid host:port listen address.
+func checkAddr(verr *ValidationError, key, value string) {
+	if msg := addrProblem(value); msg != "" {
+		verr.add(key, value, "%s", msg)
+	}
+}
+
+// checkEndpoint accepts either an absolute http(s) URL or a host:port pair.
+func checkEndpoint(verr *ValidationError, key, value string) {
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			verr.add(key, value, "must be an http(s) URL or host:port")
+		}
+		return
+	}
+	if msg := addrProblem(value); msg != "" {
+		verr.add(key, value, "%s", msg)
+	}
+}
+
+// addrProblem describes why value is not a host:port address, or returns "".
+func addrProblem(value string) string {
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return "must be in host:port form (e.g. \":8080\")"
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > 65535 {
+		return "port must be a number between 0 and 65535"
+	}
+	return ""
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"crypto/tls"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig returns a Config that passes validation.
+func validConfig() Config {
+	return Config{
+		Env: ProfileProd,
+		Server: ServerConfig{
+			ListenAddr:      ":8080",
+			AdminAddr:       ":9090",
+			MaxBodySize:     MiB,
+			ShutdownTimeout: 30 * time.Second,
+			TLS:             TLSConfig{MinVersion: "1.2", ClientAuth: ClientAuthNone},
+		},
+		OTEL: OTELConfig{
+			Exporter:    ExporterOTLP,
+			ServiceName: "template-go",
+			Compression: "gzip",
+			Timeout:     10 * time.Second,
+			FilePath:    "traces.jsonl",
+		},
+		Logging:   LoggingConfig{Level: "info", Format: "json"},
+		Lifecycle: LifecycleConfig{StartTimeout: 30 * time.Second, StopTimeout: 45 * time.Second},
+		RateLimit: RateLimitConfig{Store: "memory"},
+		Auth:      AuthConfig{APIKeys: APIKeyConfig{Store: APIKeyStoreFile}},
+	}
+}
+
+// validAuth returns enabled authentication settings that pass validation.
+func validAuth() AuthConfig {
+	return AuthConfig{
+		Enabled:             true,
+		JWKSFile:            "jwks.json",
+		JWKSRefreshInterval: 15 * time.Minute,
+		Algorithms:          []string{"RS256", "ES256"},
+		APIKeys:             APIKeyConfig{Store: APIKeyStoreFile},
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestValidate_AuthEnabled(t *testing.T) {
+	c := validConfig()
+	c.Auth = validAuth()
+	c.Auth.JWKSFile = ""
+	c.Auth.JWKSURL = "https://id.example.com/.well-known/jwks.json"
+	c.Auth.Algorithms = []string{"EdDSA", "HS256"}
+	c.Auth.HMACSecret = "secret"
+
+	assert.NoError(t, c.Validate())
+}
+
+func TestValidate_Fields(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(c *Config)
+		wantKey string
+	}{
+		{"listen addr without port", func(c *Config) { c.Server.ListenAddr = "8080" }, "server.listen_addr"},
+		{"listen addr bad port", func(c *Config) { c.Server.ListenAddr = ":http" }, "server.listen_addr"},
+		{"listen addr port out of range", func(c *Config) { c.Server.ListenAddr = ":70000" }, "server.listen_addr"},
+		{"admin addr without port", func(c *Config) { c.Server.AdminAddr = "9090" }, "server.admin_addr"},
+		{"admin addr same as listen addr", func(c *Config) { c.Server.AdminAddr = ":8080" }, "server.admin_addr"},
+		{"cert without key", func(c *Config) { c.Server.TLS.CertFile = "tls.crt" }, "server.tls.key_file"},
+		{"client auth without tls", func(c *Config) { c.Server.TLS.ClientAuth = ClientAuthRequire }, "server.tls.client_auth"},
+		{"verified client auth without ca", func(c *Config) {
+			c.Server.TLS.CertFile, c.Server.TLS.KeyFile = "tls.crt", "tls.key"
+			c.Server.TLS.ClientAuth = ClientAuthRequireAndVerify
+		}, "server.tls.client_ca_file"},
+		{"unknown cipher suite", func(c *Config) { c.Server.TLS.CipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"} }, "server.tls.cipher_suites"},
+		{"zero body size", func(c *Config) { c.Server.MaxBodySize = 0 }, "server.max_body_size"},
+		{"negative write timeout", func(c *Config) { c.Server.WriteTimeout = -time.Second }, "server.write_timeout"},
+		{"negative drain delay", func(c *Config) { c.Server.DrainDelay = -time.Second }, "server.drain_delay"},
+		{"zero shutdown timeout", func(c *Config) { c.Server.ShutdownTimeout = 0 }, "server.shutdown_timeout"},
+		{"trusted proxy not an address", func(c *Config) { c.Server.TrustedProxies = []string{"proxy.local"} }, "server.trusted_proxies"},
+		{"trusted proxy bad cidr", func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.0/33"} }, "server.trusted_proxies"},
+		{"unknown exporter", func(c *Config) { c.OTEL.Exporter = "prometheus" }, "otel.exporter"},
+		{"missing service name", func(c *Config) { c.OTEL.ServiceName = " " }, "otel.service_name"},
+		{"endpoint bad scheme", func(c *Config) { c.OTEL.Endpoint = "ftp://collector" }, "otel.endpoint"},
+		{"endpoint without port", func(c *Config) { c.OTEL.Endpoint = "collector" }, "otel.endpoint"},
+		{"unknown compression", func(c *Config) { c.OTEL.Compression = "zstd" }, "otel.compression"},
+		{"sample ratio above one", func(c *Config) { c.OTEL.SampleRatio = 1.5 }, "otel.sample_ratio"},
+		{"zero stop timeout", func(c *Config) { c.Lifecycle.StopTimeout = 0 }, "lifecycle.stop_timeout"},
+		{"negative reload interval", func(c *Config) { c.ReloadInterval = -time.Second }, "reload_interval"},
+		{"zero timeout", func(c *Config) { c.OTEL.Timeout = 0 }, "otel.timeout"},
+		{"file exporter without path", func(c *Config) { c.OTEL.Exporter = ExporterFile; c.OTEL.FilePath = "" }, "otel.file_path"},
+		{"unknown log level", func(c *Config) { c.Logging.Level = "trace" }, "logging.level"},
+		{"access log sample ratio below zero", func(c *Config) { c.Logging.Access.SampleRatio = -0.1 }, "logging.access.sample_ratio"},
+		{"unknown log format", func(c *Config) { c.Logging.Format = "xml" }, "logging.format"},
+		{"unknown rate limit store", func(c *Config) { c.RateLimit.Store = "memcached" }, "rate_limit.store"},
+		{"auth failures not counted by ip", func(c *Config) {
+			c.RateLimit.Enabled, c.RateLimit.AuthFailures = true, RateLimitPolicy{Requests: 1, Window: time.Second, Burst: 1, Key: RateLimitKeyTenant}
+		}, "rate_limit.auth_failures"},
+		{"rate limit redis addr without port", func(c *Config) {
+			c.RateLimit.Enabled, c.RateLimit.Store, c.RateLimit.RedisAddr = true, "redis", "redis"
+		}, "rate_limit.redis_addr"},
+		{"negative rate limit redis db", func(c *Config) {
+			c.RateLimit.Enabled, c.RateLimit.RedisDB = true, -1
+		}, "rate_limit.redis_db"},
+		{"rate limit route without slash", func(c *Config) {
+			c.RateLimit.Enabled = true
+			c.RateLimit.Routes = map[string]RateLimitPolicy{"POST login": {Requests: 1, Window: time.Second}}
+		}, "rate_limit.routes"},
+		{"auth without keys", func(c *Config) { c.Auth = validAuth(); c.Auth.JWKSFile = "" }, "auth.jwks_url"},
+		{"auth jwks url not http", func(c *Config) { c.Auth = validAuth(); c.Auth.JWKSURL = "file:///jwks.json" }, "auth.jwks_url"},
+		{"auth hs256 without secret", func(c *Config) { c.Auth = validAuth(); c.Auth.Algorithms = []string{"HS256"} }, "auth.hmac_secret"},
+		{"auth without algorithms", func(c *Config) { c.Auth = validAuth(); c.Auth.Algorithms = nil }, "auth.algorithms"},
+		{"auth unknown algorithm", func(c *Config) { c.Auth = validAuth(); c.Auth.Algorithms = []string{"RS256", "none"} }, "auth.algorithms"},
+		{"auth negative clock skew", func(c *Config) { c.Auth = validAuth(); c.Auth.ClockSkew = -time.Second }, "auth.clock_skew"},
+		{"api keys file store without file", func(c *Config) {
+			c.Auth.APIKeys = APIKeyConfig{Enabled: true, Store: APIKeyStoreFile, Header: "X-API-Key"}
+		}, "auth.api_keys.file"},
+		{"api keys without source", func(c *Config) {
+			c.Auth.APIKeys = APIKeyConfig{Enabled: true, Store: APIKeyStoreFile, File: "keys.yaml"}
+		}, "auth.api_keys.header"},
+		{"api keys in db without dsn", func(c *Config) {
+			c.Auth.APIKeys = APIKeyConfig{Enabled: true, Header: "X-API-Key", Store: APIKeyStoreDB}
+		}, "db.dsn"},
+		{"api keys admin without token", func(c *Config) {
+			c.Auth.APIKeys = APIKeyConfig{Enabled: true, Header: "X-API-Key", Store: APIKeyStoreFile, File: "keys.yaml", Admin: true, AdminToken: "short"}
+		}, "auth.api_keys.admin_token"},
+		{"api keys unknown store", func(c *Config) { c.Auth.APIKeys.Store = "vault" }, "auth.api_keys.store"},
+		{"dsn without driver", func(c *Config) { c.DB.DSN = "postgres://localhost/app" }, "db.driver"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(&cfg)
+
+			err := cfg.Validate()
+
+			var verr *ValidationError
+			require.ErrorAs(t, err, &verr)
+			require.Len(t, verr.Errors, 1)
+			assert.Equal(t, tt.wantKey, verr.Errors[0].Key)
+		})
+	}
+}
+
+func TestValidate_AcceptsEndpointForms(t *testing.T) {
+	for _, endpoint := range []string{"collector:4317", "http://collector:4318", "https://otel.example.com/v1/traces"} {
+		cfg := validConfig()
+		cfg.OTEL.Endpoint = endpoint
+		assert.NoError(t, cfg.Validate(), endpoint)
+	}
+}
+
+func TestCipherSuite(t *testing.T) {
+	id, ok := CipherSuite("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+	assert.True(t, ok)
+	assert.Equal(t, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, id)
+
+	_, ok = CipherSuite("TLS_RSA_WITH_RC4_128_SHA")
+	assert.False(t, ok, "insecure suites are not accepted")
+}
+
+func TestIsEmpty(t *testing.T) {
+	assert.True(t, isEmpty(reflect.ValueOf(" ")))
+	assert.False(t, isEmpty(reflect.ValueOf("svc")))
+	assert.True(t, isEmpty(reflect.ValueOf(0)))
+	assert.False(t, isEmpty(reflect.ValueOf(time.Second)))
+}
+
+func TestServerConfig_TrustedProxyPrefixes(t *testing.T) {
+	// GIVEN trusted proxies given as CIDRs, addresses and an invalid entry
+	s := ServerConfig{TrustedProxies: []string{"10.1.2.3/8", "192.0.2.7", "::ffff:198.51.100.1", "proxy.local"}}
+
+	// WHEN they are parsed
+	prefixes := s.TrustedProxyPrefixes()
+
+	// THEN CIDRs are masked, addresses become single-host prefixes and the invalid entry is skipped
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.7/32"),
+		netip.MustParsePrefix("198.51.100.1/32"),
+	}, prefixes)
+}
+
+func TestValidationError_Message(t *testing.T) {
+	cfg := validConfig()
+	cfg.Logging.Level = "loud"
+
+	err := cfg.Validate()
+
+	assert.EqualError(t, err, "invalid configuration (1 problem):\n"+
+		`  - logging.level="loud": must be one of debug, info, warn, error`)
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	httpSwagger "github.com/swaggo/http-swagger"
+	"github.com/swaggo/swag"
+
+	"template-go/internal/auth"
+	"template-go/internal/authz"
+	"template-go/internal/config"
+	mw "template-go/internal/delivery/http/middleware"
+	"template-go/internal/delivery/http/routes"
+	"template-go/internal/health"
+)
+
+// NewAdminRouter returns the handler for the admin listener: metrics, API
+// docs, health probes, pprof and debug endpoints. It must not be exposed
+// publicly. checks backs the probe endpoints and settings returns the
+// current configuration for /debug/config. When auth.api_keys.admin is
+// set and apiKeys is not nil, API keys are managed under /admin/api-keys
+// with the admin token as bearer token. Unless public is nil, the API docs
+// only cover its modules and the admin operations, and the authorization
+// policies of its routes are listed at /debug/policies.
+func NewAdminRouter(checks *health.Registry, settings func() config.Config, apiKeys *auth.APIKeys, public *Router) http.Handler {
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(mw.AccessLog(settings().Logging.Access))
+	r.Use(mw.Recover())
+
+	// Serve metrics at /metrics
+	r.Handle("/metrics", promhttp.Handler())
+
+	// Serve the swagger documentation at /docs/index.html
+	r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/docs/index.html", http.StatusMovedPermanently)
+	})
+	r.Get("/docs/*", httpSwagger.WrapHandler)
+	if public != nil {
+		tags := append(public.SwaggerTags(), adminTag)
+		r.Get("/docs/doc.json", moduleDoc(tags, ""))
+		// Each version has its own document, e.g. /docs/v1/index.html
+		for _, v := range public.Versions() {
+			r.Get("/docs/"+v+"/doc.json", moduleDoc(tags, "/"+v))
+		}
+	}
+
+	// Health probes
+	r.Handle("/livez", checks.Handler(health.ScopeLive))
+	r.Handle("/readyz", checks.Handler(health.ScopeReady))
+	r.Handle("/healthz", checks.Handler(0))
+
+	// pprof and expvar under /debug, plus the effective configuration
+	r.Mount("/debug", middleware.Profiler())
+	r.Get("/debug/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(settings().Settings())
+	})
+	if public != nil {
+		r.Handle("/debug/policies", authz.Handler(public.authz))
+	}
+
+	// Key management mints credentials, so it is opt-in and needs the
+	// admin token even on the admin listener.
+	if kc := settings().Auth.APIKeys; apiKeys != nil && kc.Admin {
+		r.Route("/admin/api-keys", func(r chi.Router) {
+			r.Use(mw.RequireToken(kc.AdminToken))
+			r.Mount("/", routes.APIKeyRoutes(apiKeys))
+		})
+	}
+
+	return r
+}
+
+// readDoc returns the generated swagger document; tests replace it.
+var readDoc = func() (string, error) { return swag.ReadDoc() }
+
+// adminTag is the swagger tag of the admin listener's own operations.
+const adminTag = "Admin"
+
+// moduleDoc serves the swagger document without the operations whose tags
+// are all missing from tags, so unregistered modules stay undocumented.
+// Untagged operations are kept. A non-empty version narrows the document to
+// the paths under that prefix, which becomes its base path.
+func moduleDoc(tags []string, version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := readDoc()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var spec map[string]any
+		if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		paths, _ := spec["paths"].(map[string]any)
+		for path, item := range paths {
+			ops, _ := item.(map[string]any)
+			for method, op := range ops {
+				fields, _ := op.(map[string]any)
+				opTags, _ := fields["tags"].([]any)
+				if len(opTags) > 0 && !slices.ContainsFunc(opTags, func(t any) bool {
+					s, _ := t.(string)
+					return slices.Contains(tags, s)
+				}) {
+					delete(ops, method)
+				}
+			}
+			if len(ops) == 0 {
+				delete(paths, path)
+			}
+		}
+		if version != "" {
+			versioned := map[string]any{}
+			for path, item := range paths {
+				if rest, ok := strings.CutPrefix(path, version+"/"); ok {
+					versioned["/"+rest] = item
+				}
+			}
+			spec["paths"] = versioned
+			spec["basePath"] = version
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(spec)
+	}
+}
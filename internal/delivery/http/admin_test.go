@@ -0,0 +1,335 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"template-go/internal/auth"
+	"template-go/internal/authz"
+	"template-go/internal/config"
+	"template-go/internal/delivery/http/routes"
+	"template-go/internal/health"
+
+	_ "template-go/docs"
+)
+
+func newTestAdminRouter() http.Handler {
+	return NewAdminRouter(health.NewRegistry(), func() config.Config {
+		return config.Config{DB: config.DBConfig{Driver: "postgres", DSN: "postgres://user:pw@db/app"}}
+	}, nil, nil)
+}
+
+func TestAdminRouter_MetricsEndpoint(t *testing.T) {
+	router := newTestAdminRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "# HELP") {
+		t.Error("expected Prometheus metrics output")
+	}
+}
+
+func TestAdminRouter_DocsRedirect(t *testing.T) {
+	router := newTestAdminRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusMovedPermanently {
+		t.Fatalf("expected 301 redirect, got %d", resp.StatusCode)
+	}
+
+	location := resp.Header.Get("Location")
+	if location != "/docs/index.html" {
+		t.Fatalf("expected redirect to /docs/index.html, got %s", location)
+	}
+}
+
+func TestAdminRouter_SwaggerHandler(t *testing.T) {
+	router := newTestAdminRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/docs/index.html", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Errorf("failed to close response body: %v", err)
+		}
+	}()
+
+	// We don't expect 404 because httpSwagger.WrapHandler should be attached
+	if resp.StatusCode == http.StatusNotFound {
+		t.Fatal("swagger route returned 404; is httpSwagger.WrapHandler configured correctly?")
+	}
+}
+
+func TestAdminRouter_Probes(t *testing.T) {
+	probe := health.NewProbe()
+	checks := health.NewRegistry()
+	checks.Register("server", probe.Check)
+	router := NewAdminRouter(checks, func() config.Config { return config.Config{} }, nil, nil)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	if code := get("/livez"); code != http.StatusOK {
+		t.Errorf("expected /livez 200, got %d", code)
+	}
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz 503 before ready, got %d", code)
+	}
+	if code := get("/healthz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /healthz 503 before ready, got %d", code)
+	}
+	probe.SetReady(true)
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Errorf("expected /readyz 200 once ready, got %d", code)
+	}
+}
+
+func TestAdminRouter_Pprof(t *testing.T) {
+	router := newTestAdminRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 from pprof index, got %d", rec.Code)
+	}
+}
+
+func TestAdminRouter_DebugConfigRedactsSecrets(t *testing.T) {
+	router := newTestAdminRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var settings []config.Setting
+	if err := json.Unmarshal(rec.Body.Bytes(), &settings); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	values := map[string]string{}
+	for _, s := range settings {
+		values[s.Key] = s.Value
+	}
+	if values["db.driver"] != "postgres" {
+		t.Errorf("expected db.driver=postgres, got %q", values["db.driver"])
+	}
+	if values["db.dsn"] != config.RedactedValue {
+		t.Errorf("expected db.dsn to be redacted, got %q", values["db.dsn"])
+	}
+}
+
+func TestAdminRouter_APIKeys(t *testing.T) {
+	store, err := auth.NewFileKeyStore(t.TempDir() + "/api_keys.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := auth.NewAPIKeys(store, time.Minute)
+	const token = "0123456789abcdef0123456789abcdef"
+	settings := func(admin bool) func() config.Config {
+		return func() config.Config {
+			return config.Config{Auth: config.AuthConfig{APIKeys: config.APIKeyConfig{Admin: admin, AdminToken: token}}}
+		}
+	}
+	enabled := NewAdminRouter(health.NewRegistry(), settings(true), keys, nil)
+
+	tests := []struct {
+		name          string
+		router        http.Handler
+		authorization string
+		want          int
+	}{
+		{"admin token", enabled, "Bearer " + token, http.StatusOK},
+		{"without token", enabled, "", http.StatusUnauthorized},
+		{"wrong token", enabled, "Bearer nope", http.StatusUnauthorized},
+		{"not opted in", NewAdminRouter(health.NewRegistry(), settings(false), keys, nil), "Bearer " + token, http.StatusNotFound},
+		{"api keys disabled", newTestAdminRouter(), "Bearer " + token, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/api-keys", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected %d from /admin/api-keys, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAdminRouter_Policies(t *testing.T) {
+	router := NewAdminRouter(health.NewRegistry(), func() config.Config { return config.Config{} }, nil, newTestRouter())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/policies", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var routes []authz.RouteRules
+	if err := json.Unmarshal(rec.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	policies := map[string]int{}
+	for _, r := range routes {
+		policies[r.Method+" "+r.Route] = len(r.Policies)
+	}
+	if n, ok := policies["GET /"]; !ok || n != 0 {
+		t.Errorf("expected GET / to be listed without policies, got %v", routes)
+	}
+	if policies["GET /me"] != 1 {
+		t.Errorf("expected GET /me to be guarded by one policy, got %v", routes)
+	}
+}
+
+func TestAdminRouter_DocsCoverRegisteredModules(t *testing.T) {
+	tests := []struct {
+		name      string
+		public    *Router
+		wantPaths []string
+		wantGone  []string
+	}{
+		{"root module", newTestRouter(), []string{"/", "/me", "/admin/api-keys"}, nil},
+		{"other module", NewRouter(WithModules(ordersModule{})), []string{"/admin/api-keys"}, []string{"/", "/me"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewAdminRouter(health.NewRegistry(), func() config.Config { return config.Config{} }, nil, tt.public)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/doc.json", nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var spec struct {
+				Paths map[string]any `json:"paths"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			for _, p := range tt.wantPaths {
+				if _, ok := spec.Paths[p]; !ok {
+					t.Errorf("expected %s to be documented", p)
+				}
+			}
+			for _, p := range tt.wantGone {
+				if _, ok := spec.Paths[p]; ok {
+					t.Errorf("expected %s not to be documented", p)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminRouter_VersionDocs(t *testing.T) {
+	orig := readDoc
+	t.Cleanup(func() { readDoc = orig })
+	readDoc = func() (string, error) {
+		return `{"basePath":"/","paths":{
+			"/":{"get":{"tags":["Root"]}},
+			"/v1/orders/{id}":{"get":{"tags":["Orders"]}},
+			"/v2/orders/{id}":{"get":{"tags":["Orders"]},"delete":{"tags":["Unregistered"]}}
+		}}`, nil
+	}
+	public := NewRouter(WithVersions(
+		Version{Name: "v1", Modules: []routes.Module{ordersModule{}}},
+		Version{Name: "v2", Modules: []routes.Module{ordersV2Module{}}},
+	))
+	router := NewAdminRouter(health.NewRegistry(), func() config.Config { return config.Config{} }, nil, public)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/docs/doc.json", `{"basePath":"/","paths":{
+			"/v1/orders/{id}":{"get":{"tags":["Orders"]}},
+			"/v2/orders/{id}":{"get":{"tags":["Orders"]}}
+		}}`},
+		{"/docs/v1/doc.json", `{"basePath":"/v1","paths":{"/orders/{id}":{"get":{"tags":["Orders"]}}}}`},
+		{"/docs/v2/doc.json", `{"basePath":"/v2","paths":{"/orders/{id}":{"get":{"tags":["Orders"]}}}}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", tt.path, rec.Code)
+		}
+		var got, want any
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.path, err)
+		}
+		_ = json.Unmarshal([]byte(tt.want), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %s, got %s", tt.path, tt.want, rec.Body.String())
+		}
+	}
+}
+
+func TestAdminRouter_DocErrors(t *testing.T) {
+	orig := readDoc
+	t.Cleanup(func() { readDoc = orig })
+	router := NewAdminRouter(health.NewRegistry(), func() config.Config { return config.Config{} }, nil, NewRouter())
+
+	for name, read := range map[string]func() (string, error){
+		"unreadable": func() (string, error) { return "", errors.New("no swagger document") },
+		"malformed":  func() (string, error) { return "{", nil },
+	} {
+		t.Run(name, func(t *testing.T) {
+			readDoc = read
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/doc.json", nil))
+
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("expected 500, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package bind decodes HTTP requests into structs and validates them.
+//
+// Struct tags select where a field is read from:
+//
+//	json:"name"    request body (application/json, or another codec.Default encoding)
+//	form:"name"    request body (application/x-www-form-urlencoded or multipart/form-data)
+//	query:"name"   URL query parameter
+//	path:"id"      chi URL parameter
+//	validate:"..." go-playground/validator rules, checked after decoding
+//
+// Decoding failures are returned as apperr errors with CodeInvalidArgument
+// (400), CodeTooLarge (413) or CodeUnsupportedMedia (415); failed validation
+// rules as CodeValidation (422). Both carry []FieldError as details, so
+// handlers written as apperr.HandlerFunc can return them unchanged.
+package bind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"template-go/internal/delivery/http/codec"
+	"template-go/pkg/apperr"
+)
+
+// maxMemory is the part of a multipart form kept in memory; the rest is
+// spooled to temporary files.
+const maxMemory = 10 << 20
+
+// Request binds every source of r into dst: path parameters, query
+// parameters and, when r has a body, the body according to its content
+// type. dst is validated once all sources have been decoded. Tag fields
+// read from the path or query with json:"-" so the body cannot set them.
+func Request(r *http.Request, dst any) error {
+	if err := decodePath(r, dst); err != nil {
+		return err
+	}
+	if err := decodeQuery(r, dst); err != nil {
+		return err
+	}
+	if hasBody(r) {
+		var err error
+		switch codec.MediaType(r) {
+		case "application/x-www-form-urlencoded", "multipart/form-data":
+			err = decodeForm(r, dst)
+		default:
+			err = decodeBody(r, dst)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return Validate(dst)
+}
+
+// JSON decodes the JSON body of r into dst and validates it. The body must
+// hold exactly one JSON value and may not contain fields dst does not
+// declare.
+func JSON(r *http.Request, dst any) error {
+	if err := decodeJSON(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// Body decodes the body of r into dst in the encoding named by its
+// Content-Type, see codec.Default, and validates it. JSON bodies are decoded
+// as by JSON.
+func Body(r *http.Request, dst any) error {
+	if err := decodeBody(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// Form decodes the form body of r into dst and validates it.
+func Form(r *http.Request, dst any) error {
+	if err := decodeForm(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// Query decodes the URL query of r into dst and validates it.
+func Query(r *http.Request, dst any) error {
+	if err := decodeQuery(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// Path decodes the chi URL parameters of r into dst and validates it.
+func Path(r *http.Request, dst any) error {
+	if err := decodePath(r, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+func decodeBody(r *http.Request, dst any) error {
+	if codec.MediaType(r) == "application/json" {
+		return decodeJSON(r, dst)
+	}
+	return codec.Decode(r, dst)
+}
+
+func decodeJSON(r *http.Request, dst any) error {
+	if codec.MediaType(r) != "application/json" {
+		return apperr.New(apperr.CodeUnsupportedMedia, "Content-Type must be application/json.")
+	}
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return jsonError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if tooLarge(err) {
+			return jsonError(err)
+		}
+		return apperr.InvalidArgument("Request body must contain a single JSON value.")
+	}
+	return nil
+}
+
+// jsonError translates an encoding/json error into a client error.
+func jsonError(err error) error {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	case tooLarge(err):
+		return apperr.Wrap(err, apperr.CodeTooLarge, "Request body is too large.")
+	case errors.Is(err, io.EOF):
+		return apperr.InvalidArgument("Request body must not be empty.")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperr.InvalidArgument("Request body contains malformed JSON.")
+	case errors.As(err, &syntaxErr):
+		return apperr.Newf(apperr.CodeInvalidArgument, "Request body contains malformed JSON at offset %d.", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		field := jsonFieldPath(typeErr.Field)
+		if field == "" {
+			return apperr.Newf(apperr.CodeInvalidArgument, "Request body must be a JSON %s.", typeErr.Type.Kind())
+		}
+		return apperr.InvalidArgument("Request body contains an invalid value.").WithDetails([]FieldError{{
+			Field:   field,
+			Code:    "type",
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type),
+		}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apperr.InvalidArgument("Request body contains an unknown field.").WithDetails([]FieldError{{
+			Field:   field,
+			Code:    "unknown",
+			Message: "is not a known field",
+		}})
+	default:
+		return apperr.Wrap(err, apperr.CodeInvalidArgument, "Request body could not be decoded.")
+	}
+}
+
+func decodeForm(r *http.Request, dst any) error {
+	var err error
+	switch codec.MediaType(r) {
+	case "application/x-www-form-urlencoded":
+		err = r.ParseForm()
+	case "multipart/form-data":
+		err = r.ParseMultipartForm(maxMemory)
+	default:
+		return apperr.New(apperr.CodeUnsupportedMedia,
+			"Content-Type must be application/x-www-form-urlencoded or multipart/form-data.")
+	}
+	if err != nil {
+		if tooLarge(err) {
+			return apperr.Wrap(err, apperr.CodeTooLarge, "Request body is too large.")
+		}
+		return apperr.Wrap(err, apperr.CodeInvalidArgument, "Request body contains a malformed form.")
+	}
+	return decodeValues("form", r.PostForm, dst)
+}
+
+func decodeQuery(r *http.Request, dst any) error {
+	return decodeValues("query", r.URL.Query(), dst)
+}
+
+func decodePath(r *http.Request, dst any) error {
+	params := map[string][]string{}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		for i, key := range rctx.URLParams.Keys {
+			params[key] = []string{rctx.URLParams.Values[i]}
+		}
+	}
+	return decodeValues("path", params, dst)
+}
+
+// hasBody reports whether r carries a body to decode.
+func hasBody(r *http.Request) bool {
+	return r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0
+}
+
+// jsonFieldPath rewrites the dotted path encoding/json reports, such as
+// "items.0.sku", in the form validation errors use: "items[0].sku".
+func jsonFieldPath(path string) string {
+	parts := strings.Split(path, ".")
+	var b strings.Builder
+	for i, p := range parts {
+		switch {
+		case p != "" && strings.Trim(p, "0123456789") == "":
+			b.WriteString("[" + p + "]")
+		case i > 0:
+			b.WriteString("." + p)
+		default:
+			b.WriteString(p)
+		}
+	}
+	return b.String()
+}
+
+// tooLarge reports whether err was caused by http.MaxBytesReader.
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
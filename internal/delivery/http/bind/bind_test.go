@@ -0,0 +1,517 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+type createOrder struct {
+	Customer string      `json:"customer" validate:"required,email"`
+	Items    []orderItem `json:"items" validate:"required,min=1,dive"`
+	Note     string      `json:"note,omitempty" validate:"max=10"`
+}
+
+type orderItem struct {
+	SKU      string `json:"sku" validate:"required"`
+	Quantity int    `json:"quantity" validate:"gte=1"`
+}
+
+// asError asserts that err is an *apperr.Error with the given code.
+func asError(t *testing.T, err error, code apperr.Code) *apperr.Error {
+	t.Helper()
+	require.Error(t, err)
+	e := apperr.From(err)
+	require.Equal(t, code, e.Code, err.Error())
+	return e
+}
+
+func jsonRequest(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	return req
+}
+
+func TestJSON(t *testing.T) {
+	var dst createOrder
+	err := JSON(jsonRequest(`{"customer":"a@example.com","items":[{"sku":"x","quantity":2}]}`), &dst)
+
+	require.NoError(t, err)
+	assert.Equal(t, createOrder{Customer: "a@example.com", Items: []orderItem{{SKU: "x", Quantity: 2}}}, dst)
+}
+
+func TestJSON_DecodeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *http.Request
+		code    apperr.Code
+		details []FieldError
+	}{
+		{name: "empty body", req: jsonRequest(""), code: apperr.CodeInvalidArgument},
+		{name: "malformed", req: jsonRequest(`{"customer":`), code: apperr.CodeInvalidArgument},
+		{name: "syntax error", req: jsonRequest(`{"customer" "a"}`), code: apperr.CodeInvalidArgument},
+		{name: "trailing data", req: jsonRequest(`{"customer":"a@example.com","items":[{"sku":"x","quantity":1}]} {}`), code: apperr.CodeInvalidArgument},
+		{name: "not an object", req: jsonRequest(`[]`), code: apperr.CodeInvalidArgument},
+		{
+			name:    "wrong type",
+			req:     jsonRequest(`{"items":[{"sku":"x","quantity":"two"}]}`),
+			code:    apperr.CodeInvalidArgument,
+			details: []FieldError{{Field: "items[0].quantity", Code: "type", Message: "must be of type int"}},
+		},
+		{
+			name:    "unknown field",
+			req:     jsonRequest(`{"customer":"a@example.com","admin":true}`),
+			code:    apperr.CodeInvalidArgument,
+			details: []FieldError{{Field: "admin", Code: "unknown", Message: "is not a known field"}},
+		},
+		{
+			name: "wrong content type",
+			req: func() *http.Request {
+				req := jsonRequest(`{}`)
+				req.Header.Set("Content-Type", "text/plain")
+				return req
+			}(),
+			code: apperr.CodeUnsupportedMedia,
+		},
+		{
+			name: "too large",
+			req: func() *http.Request {
+				req := jsonRequest(`{"customer":"` + strings.Repeat("a", 64) + `"}`)
+				req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 16)
+				return req
+			}(),
+			code: apperr.CodeTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst createOrder
+			e := asError(t, JSON(tt.req, &dst), tt.code)
+			if tt.details != nil {
+				assert.Equal(t, tt.details, e.Details)
+			}
+		})
+	}
+}
+
+func TestJSON_ValidationErrors(t *testing.T) {
+	// GIVEN a well-formed body that breaks several rules
+	req := jsonRequest(`{"customer":"nope","items":[{"sku":"","quantity":0}],"note":"far too long"}`)
+
+	// WHEN it is bound
+	var dst createOrder
+	err := JSON(req, &dst)
+
+	// THEN every failed rule is reported by its JSON field path
+	e := asError(t, err, apperr.CodeValidation)
+	assert.Equal(t, http.StatusUnprocessableEntity, e.Status())
+	assert.Equal(t, []FieldError{
+		{Field: "customer", Code: "email", Message: "must be a valid email address"},
+		{Field: "items[0].sku", Code: "required", Message: "is required"},
+		{Field: "items[0].quantity", Code: "gte", Message: "must be at least 1"},
+		{Field: "note", Code: "max", Message: "must have at most 10 characters"},
+	}, e.Details)
+}
+
+type listOrders struct {
+	Status []string      `query:"status" validate:"dive,oneof=open closed"`
+	Limit  int           `query:"limit" validate:"omitempty,min=1,max=100"`
+	Since  time.Time     `query:"since"`
+	Wait   time.Duration `query:"wait"`
+	Mine   *bool         `query:"mine"`
+}
+
+func TestQuery(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/orders?status=open&status=closed&limit=20&since=2024-01-02T03:04:05Z&wait=2s&mine=true", nil)
+
+	var dst listOrders
+	require.NoError(t, Query(req, &dst))
+
+	assert.Equal(t, []string{"open", "closed"}, dst.Status)
+	assert.Equal(t, 20, dst.Limit)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), dst.Since)
+	assert.Equal(t, 2*time.Second, dst.Wait)
+	require.NotNil(t, dst.Mine)
+	assert.True(t, *dst.Mine)
+}
+
+func TestQuery_Errors(t *testing.T) {
+	t.Run("unparsable values", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/orders?limit=ten&since=yesterday", nil)
+
+		var dst listOrders
+		e := asError(t, Query(req, &dst), apperr.CodeInvalidArgument)
+
+		assert.Equal(t, []FieldError{
+			{Field: "limit", Code: "type", Message: "must be an integer"},
+			{Field: "since", Code: "type", Message: "must be a valid time.Time"},
+		}, e.Details)
+	})
+
+	t.Run("failed rules", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/orders?status=lost&limit=500", nil)
+
+		var dst listOrders
+		e := asError(t, Query(req, &dst), apperr.CodeValidation)
+
+		assert.Equal(t, []FieldError{
+			{Field: "status[0]", Code: "oneof", Message: "must be one of: open, closed"},
+			{Field: "limit", Code: "max", Message: "must be at most 100"},
+		}, e.Details)
+	})
+}
+
+func TestForm(t *testing.T) {
+	type login struct {
+		User     string `form:"user" validate:"required"`
+		Remember bool   `form:"remember"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("user=ada&remember=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var dst login
+	require.NoError(t, Form(req, &dst))
+
+	assert.Equal(t, login{User: "ada", Remember: true}, dst)
+}
+
+func TestPath(t *testing.T) {
+	type params struct {
+		ID int64 `path:"id" validate:"gt=0"`
+	}
+	bindPath := func(url string) (params, error) {
+		var dst params
+		var err error
+		r := chi.NewRouter()
+		r.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) { err = Path(r, &dst) })
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+		return dst, err
+	}
+
+	dst, err := bindPath("/orders/42")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), dst.ID)
+
+	_, err = bindPath("/orders/abc")
+	asError(t, err, apperr.CodeInvalidArgument)
+
+	_, err = bindPath("/orders/0")
+	asError(t, err, apperr.CodeValidation)
+}
+
+func TestRequest(t *testing.T) {
+	// GIVEN a route whose input comes from the path, the query and the body
+	type updateOrder struct {
+		ID     int64  `path:"id" json:"-" validate:"gt=0"`
+		DryRun bool   `query:"dry_run" json:"-"`
+		Note   string `json:"note" validate:"required"`
+	}
+	var (
+		dst updateOrder
+		err error
+	)
+	r := chi.NewRouter()
+	r.Put("/orders/{id}", func(w http.ResponseWriter, r *http.Request) { err = Request(r, &dst) })
+	req := httptest.NewRequest(http.MethodPut, "/orders/7?dry_run=true", strings.NewReader(`{"note":"gift"}`))
+
+	// WHEN it is bound
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// THEN every source is decoded into one struct
+	require.NoError(t, err)
+	assert.Equal(t, updateOrder{ID: 7, DryRun: true, Note: "gift"}, dst)
+}
+
+func TestRequest_WithoutBodyValidates(t *testing.T) {
+	type input struct {
+		Note string `json:"note" validate:"required"`
+	}
+	var dst input
+	e := asError(t, Request(httptest.NewRequest(http.MethodPut, "/", nil), &dst), apperr.CodeValidation)
+
+	assert.Equal(t, []FieldError{{Field: "note", Code: "required", Message: "is required"}}, e.Details)
+}
+
+func TestBind_RendersProblem(t *testing.T) {
+	// GIVEN an error-returning handler that binds its input
+	h := apperr.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var dst createOrder
+		if err := JSON(r, &dst); err != nil {
+			return err
+		}
+		w.WriteHeader(http.StatusCreated)
+		return nil
+	})
+	rec := httptest.NewRecorder()
+
+	// WHEN invalid input is posted
+	h.ServeHTTP(rec, jsonRequest(`{"customer":"a@example.com","items":[]}`))
+
+	// THEN the field errors are rendered as a 422 problem
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, apperr.ContentType, rec.Header().Get("Content-Type"))
+	var body struct {
+		Code    apperr.Code  `json:"code"`
+		Details []FieldError `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, apperr.CodeValidation, body.Code)
+	assert.Equal(t, []FieldError{{Field: "items", Code: "min", Message: "must have at least 1 items"}}, body.Details)
+}
+
+func TestBody(t *testing.T) {
+	valid := createOrder{Customer: "a@example.com", Items: []orderItem{{SKU: "x", Quantity: 2}}}
+	cborBody, err := cbor.Marshal(valid)
+	require.NoError(t, err)
+	invalid, err := cbor.Marshal(createOrder{Items: []orderItem{{SKU: "x", Quantity: 2}}})
+	require.NoError(t, err)
+	request := func(contentType string, body []byte) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	t.Run("cbor", func(t *testing.T) {
+		var dst createOrder
+		require.NoError(t, Body(request("application/cbor", cborBody), &dst))
+		assert.Equal(t, valid, dst)
+	})
+
+	t.Run("json stays strict", func(t *testing.T) {
+		var dst createOrder
+		asError(t, Body(request("application/json", []byte(`{"admin":true}`)), &dst), apperr.CodeInvalidArgument)
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		var dst createOrder
+		asError(t, Body(request("application/cbor", invalid), &dst), apperr.CodeValidation)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		var dst createOrder
+		asError(t, Body(request("text/csv", []byte("a,b")), &dst), apperr.CodeUnsupportedMedia)
+	})
+}
+
+func TestRequest_CBORCannotSetPathFields(t *testing.T) {
+	type updateOrder struct {
+		ID   int64  `path:"id" json:"-"`
+		Note string `json:"note"`
+	}
+	body, err := cbor.Marshal(map[string]any{"ID": 99, "-": 99, "note": "gift"})
+	require.NoError(t, err)
+	var dst updateOrder
+	r := chi.NewRouter()
+	r.Put("/orders/{id}", func(w http.ResponseWriter, r *http.Request) { err = Request(r, &dst) })
+	req := httptest.NewRequest(http.MethodPut, "/orders/7", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/cbor")
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, updateOrder{ID: 7, Note: "gift"}, dst)
+}
+
+func TestRequest_Errors(t *testing.T) {
+	type updateOrder struct {
+		ID     int64  `path:"id" form:"-" json:"-"`
+		DryRun bool   `query:"dry_run" form:"-" json:"-"`
+		Note   string `json:"note" form:"note"`
+	}
+	bindRequest := func(req *http.Request) (updateOrder, error) {
+		var (
+			dst updateOrder
+			err error
+		)
+		r := chi.NewRouter()
+		r.Put("/orders/{id}", func(w http.ResponseWriter, r *http.Request) { err = Request(r, &dst) })
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return dst, err
+	}
+
+	t.Run("path", func(t *testing.T) {
+		_, err := bindRequest(httptest.NewRequest(http.MethodPut, "/orders/abc", nil))
+		asError(t, err, apperr.CodeInvalidArgument)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		_, err := bindRequest(httptest.NewRequest(http.MethodPut, "/orders/7?dry_run=maybe", nil))
+		asError(t, err, apperr.CodeInvalidArgument)
+	})
+
+	t.Run("body", func(t *testing.T) {
+		_, err := bindRequest(httptest.NewRequest(http.MethodPut, "/orders/7", strings.NewReader(`{"note":`)))
+		asError(t, err, apperr.CodeInvalidArgument)
+	})
+
+	t.Run("form body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/orders/7", strings.NewReader("note=gift&id=9"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		dst, err := bindRequest(req)
+
+		require.NoError(t, err)
+		assert.Equal(t, updateOrder{ID: 7, Note: "gift"}, dst)
+	})
+}
+
+func TestJSON_TrailingDataTooLarge(t *testing.T) {
+	value := `{"customer":"a@example.com","items":[{"sku":"x","quantity":1}]}`
+	req := jsonRequest(value + "  {}")
+	req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, int64(len(value)+1))
+
+	var dst createOrder
+	asError(t, JSON(req, &dst), apperr.CodeTooLarge)
+}
+
+func TestJSON_InvalidDestination(t *testing.T) {
+	var dst createOrder
+	asError(t, JSON(jsonRequest(`{}`), dst), apperr.CodeInvalidArgument)
+}
+
+func TestForm_Multipart(t *testing.T) {
+	type upload struct {
+		Title string `form:"title" validate:"required"`
+	}
+	var body bytes.Buffer
+	mpw := multipart.NewWriter(&body)
+	require.NoError(t, mpw.WriteField("title", "report"))
+	require.NoError(t, mpw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/uploads", &body)
+	req.Header.Set("Content-Type", mpw.FormDataContentType())
+
+	var dst upload
+	require.NoError(t, Form(req, &dst))
+
+	assert.Equal(t, upload{Title: "report"}, dst)
+}
+
+func TestForm_Errors(t *testing.T) {
+	type login struct {
+		User string `form:"user"`
+	}
+	formRequest := func(contentType, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+	tooLarge := formRequest("application/x-www-form-urlencoded", "user="+strings.Repeat("a", 64))
+	tooLarge.Body = http.MaxBytesReader(httptest.NewRecorder(), tooLarge.Body, 16)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		dst  any
+		code apperr.Code
+	}{
+		{"unsupported content type", formRequest("application/json", `{}`), &login{}, apperr.CodeUnsupportedMedia},
+		{"malformed", formRequest("application/x-www-form-urlencoded", "user=%zz"), &login{}, apperr.CodeInvalidArgument},
+		{"malformed multipart", formRequest("multipart/form-data; boundary=x", "garbage"), &login{}, apperr.CodeInvalidArgument},
+		{"too large", tooLarge, &login{}, apperr.CodeTooLarge},
+		{"invalid destination", formRequest("application/x-www-form-urlencoded", "user=ada"), new(string), apperr.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asError(t, Form(tt.req, tt.dst), tt.code)
+		})
+	}
+}
+
+type pageParams struct {
+	Page uint `query:"page"`
+}
+
+type searchParams struct {
+	Paging   pageParams
+	internal string `query:"internal"`
+	Skip     string `query:"-"`
+	Term     string `query:""`
+	IDs      []int  `query:"ids"`
+	Count    *int   `query:"count"`
+	Exact    bool   `query:"exact"`
+	Ratio    float64
+	Score    float32           `query:"score"`
+	Wait     time.Duration     `query:"wait"`
+	Labels   map[string]string `query:"labels"`
+}
+
+func TestQuery_Kinds(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/search?page=2&internal=x&Skip=x&-=x&Term=go&ids=1&ids=2&count=3&score=0.5", nil)
+
+	var dst searchParams
+	require.NoError(t, Query(req, &dst))
+
+	three := 3
+	assert.Equal(t, searchParams{Paging: pageParams{Page: 2}, Term: "go", IDs: []int{1, 2}, Count: &three, Score: 0.5}, dst)
+}
+
+func TestQuery_KindErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/search?page=-1&ids=1&ids=x&count=x&exact=x&score=x&wait=x&labels=x", nil)
+
+	var dst searchParams
+	e := asError(t, Query(req, &dst), apperr.CodeInvalidArgument)
+
+	assert.Equal(t, []FieldError{
+		{Field: "page", Code: "type", Message: "must be a non-negative integer"},
+		{Field: "ids", Code: "type", Message: "must be an integer"},
+		{Field: "count", Code: "type", Message: "must be an integer"},
+		{Field: "exact", Code: "type", Message: "must be a boolean"},
+		{Field: "score", Code: "type", Message: "must be a number"},
+		{Field: "wait", Code: "type", Message: "must be a duration"},
+		{Field: "labels", Code: "type", Message: "has unsupported type map[string]string"},
+	}, e.Details)
+}
+
+func TestValidate_Messages(t *testing.T) {
+	type rules struct {
+		Code     string `json:"code" validate:"len=3"`
+		Digits   int    `json:"digits" validate:"len=4"`
+		Score    int    `json:"score" validate:"lt=10"`
+		Site     string `json:"site" validate:"url"`
+		ID       string `json:"id" validate:"uuid"`
+		At       string `json:"at" validate:"datetime=2006-01-02"`
+		Prefix   string `json:"prefix" validate:"startswith=x"`
+		Letters  string `json:"letters" validate:"alpha"`
+		Word     string `json:"word" validate:"excludesall= "`
+		Untagged string `validate:"required"`
+	}
+	input := rules{Code: "ab", Digits: 5, Score: 11, Site: "nope", ID: "nope", At: "yesterday", Prefix: "y", Letters: "a1", Word: "a b"}
+
+	e := asError(t, Validate(input), apperr.CodeValidation)
+
+	assert.Equal(t, []FieldError{
+		{Field: "code", Code: "len", Message: "must have exactly 3 characters"},
+		{Field: "digits", Code: "len", Message: "must be 4"},
+		{Field: "score", Code: "lt", Message: "must be less than 10"},
+		{Field: "site", Code: "url", Message: "must be a valid URL"},
+		{Field: "id", Code: "uuid", Message: "must be a valid UUID"},
+		{Field: "at", Code: "datetime", Message: "must be a date-time in the format 2006-01-02"},
+		{Field: "prefix", Code: "startswith", Message: "must satisfy startswith=x"},
+		{Field: "letters", Code: "alpha", Message: "must satisfy alpha"},
+		{Field: "word", Code: "excludesall", Message: `must not contain any of " "`},
+		{Field: "Untagged", Code: "required", Message: "is required"},
+	}, e.Details)
+}
+
+func TestValidate_InvalidValue(t *testing.T) {
+	asError(t, Validate(nil), apperr.CodeInternal)
+}
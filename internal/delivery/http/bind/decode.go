@@ -0,0 +1,144 @@
+package bind
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"template-go/pkg/apperr"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodeValues assigns values to the fields of the struct pointed to by dst
+// whose tag named tag matches a key. Nested structs without the tag are
+// descended into, so embedded parameter groups can be shared. Every value
+// that cannot be parsed is reported as a field error.
+func decodeValues(tag string, values map[string][]string, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return apperr.Internal(fmt.Errorf("bind: destination must be a non-nil struct pointer, got %T", dst))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	var errs []FieldError
+	walk(v.Elem(), tag, func(name string, f reflect.Value) {
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 {
+			return
+		}
+		if err := setValues(f, raw); err != nil {
+			errs = append(errs, FieldError{Field: name, Code: "type", Message: err.Error()})
+		}
+	})
+	if len(errs) > 0 {
+		return apperr.Newf(apperr.CodeInvalidArgument, "Request %s parameters are invalid.", tag).WithDetails(errs)
+	}
+	return nil
+}
+
+// walk calls fn for every exported field of v tagged with tag.
+func walk(v reflect.Value, tag string, fn func(name string, f reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, ok := sf.Tag.Lookup(tag)
+		if !ok {
+			if sf.Type.Kind() == reflect.Struct && !reflect.PointerTo(sf.Type).Implements(textUnmarshalerType) {
+				walk(v.Field(i), tag, fn)
+			}
+			continue
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fn(name, v.Field(i))
+	}
+}
+
+// setValues assigns raw to v. Slices take every value; other types take
+// the first one.
+func setValues(v reflect.Value, raw []string) error {
+	if v.Kind() == reflect.Slice && !v.Addr().Type().Implements(textUnmarshalerType) {
+		s := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, item := range raw {
+			if err := setValue(s.Index(i), item); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setValue(v, raw[0])
+}
+
+// setValue parses raw according to the type of v and assigns it.
+func setValue(v reflect.Value, raw string) error {
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return fmt.Errorf("must be a valid %s", v.Type())
+		}
+		return nil
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("must be a duration")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a non-negative integer")
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("has unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"template-go/pkg/apperr"
+)
+
+// FieldError describes why one request field was rejected.
+type FieldError struct {
+	// Field is the field name as the client sent it, with nested fields
+	// joined by "." and slice elements indexed, e.g. "items[0].sku".
+	Field string `json:"field" example:"email"`
+	// Code is the failed rule, e.g. "required" or "max", or "type" when
+	// the value could not be decoded.
+	Code    string `json:"code" example:"required"`
+	Message string `json:"message" example:"is required"`
+} // @name FieldError
+
+// validate is shared so struct metadata is parsed only once per type.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(fieldName)
+	return v
+}
+
+// fieldName reports a struct field under the name the client uses for it.
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query", "path"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// Validate checks the validate tags of v. Failed rules are returned as a
+// CodeValidation error with one FieldError per field.
+func Validate(v any) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return apperr.Internal(fmt.Errorf("bind: validate %T: %w", v, err))
+	}
+
+	details := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		details = append(details, FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Code:    fe.Tag(),
+			Message: message(fe),
+		})
+	}
+	return apperr.New(apperr.CodeValidation, "Request validation failed.").WithDetails(details)
+}
+
+// fieldPath strips the struct name validator puts in front of every
+// namespace.
+func fieldPath(ns string) string {
+	_, rest, _ := strings.Cut(ns, ".")
+	return rest
+}
+
+// message describes a failed rule in words, for the common rules.
+func message(fe validator.FieldError) string {
+	p := fe.Param()
+	kind := fe.Kind()
+	sized := kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+	unit := "items"
+	if kind == reflect.String {
+		unit = "characters"
+	}
+
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "len":
+		if sized {
+			return fmt.Sprintf("must have exactly %s %s", p, unit)
+		}
+		return "must be " + p
+	case "min", "gte":
+		if sized {
+			return fmt.Sprintf("must have at least %s %s", p, unit)
+		}
+		return "must be at least " + p
+	case "max", "lte":
+		if sized {
+			return fmt.Sprintf("must have at most %s %s", p, unit)
+		}
+		return "must be at most " + p
+	case "gt":
+		return "must be greater than " + p
+	case "lt":
+		return "must be less than " + p
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(p), ", ")
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "excludesall":
+		return fmt.Sprintf("must not contain any of %q", p)
+	case "datetime":
+		return "must be a date-time in the format " + p
+	default:
+		if p != "" {
+			return fmt.Sprintf("must satisfy %s=%s", fe.Tag(), p)
+		}
+		return "must satisfy " + fe.Tag()
+	}
+}
@@ -0,0 +1,230 @@
+// Package codec negotiates the encoding of request and response bodies. A
+// Registry holds the supported codecs; Render picks one from the Accept
+// header and the value to encode, Decode from the Content-Type header.
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+// Codec encodes and decodes values in one media type.
+type Codec interface {
+	// MediaTypes lists the media types the codec handles, the one sent as
+	// Content-Type first, e.g. "application/msgpack", "application/x-msgpack".
+	MediaTypes() []string
+	// CanEncode reports whether v can be encoded, e.g. only proto.Message
+	// values for Protobuf.
+	CanEncode(v any) bool
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+// Registry is an ordered set of codecs. Earlier codecs win when the client
+// accepts several equally, e.g. with a missing Accept or "*/*".
+type Registry struct {
+	codecs []Codec
+}
+
+// NewRegistry returns a registry of codecs, in order of preference.
+func NewRegistry(codecs ...Codec) *Registry {
+	return &Registry{codecs: codecs}
+}
+
+// Default is the registry used by Render and Decode: text/plain for strings
+// and byte slices, then JSON, CBOR, MessagePack and Protobuf. Register
+// further codecs during initialisation.
+var Default = NewRegistry(Text{}, JSON{}, CBOR{}, MsgPack{}, Protobuf{})
+
+// Register appends c, with the lowest preference. It is not safe to call
+// while requests are served.
+func (reg *Registry) Register(c Codec) {
+	reg.codecs = append(reg.codecs, c)
+}
+
+// MediaTypes returns the media types of every codec, for error messages
+// and documentation.
+func (reg *Registry) MediaTypes() []string {
+	var types []string
+	for _, c := range reg.codecs {
+		types = append(types, c.MediaTypes()...)
+	}
+	return types
+}
+
+// Render writes v with status in the encoding the client prefers among
+// those that can encode v. It fails with CodeNotAcceptable (406) when the
+// client accepts none of them; no response is written then, so handlers can
+// return the error as is. Failures to write the encoded body are logged.
+func (reg *Registry) Render(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	c, err := reg.Negotiate(r, v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := c.Encode(&buf, v); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", c.MediaTypes()[0])
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.Error(r.Context(), "failed to write response", zap.Error(err))
+	}
+	return nil
+}
+
+// Negotiate returns the codec Render would use for v.
+func (reg *Registry) Negotiate(r *http.Request, v any) (Codec, error) {
+	ranges := acceptRanges(r.Header.Values("Accept"))
+	var (
+		best  Codec
+		bestQ float64
+	)
+	for _, c := range reg.codecs {
+		if !c.CanEncode(v) {
+			continue
+		}
+		q := 1.0
+		if ranges != nil {
+			q = quality(ranges, c.MediaTypes())
+		}
+		if q > bestQ {
+			best, bestQ = c, q
+		}
+	}
+	if best == nil {
+		var offered []string
+		for _, c := range reg.codecs {
+			if c.CanEncode(v) {
+				offered = append(offered, c.MediaTypes()[0])
+			}
+		}
+		return nil, apperr.Newf(apperr.CodeNotAcceptable,
+			"None of the accepted media types can be produced; available are %s.", strings.Join(offered, ", "))
+	}
+	return best, nil
+}
+
+// Decode decodes the body of r into v with the codec handling its
+// Content-Type; a missing Content-Type means JSON. It fails with
+// CodeUnsupportedMedia (415) for other media types, CodeTooLarge (413) and
+// CodeInvalidArgument (400) for bodies that cannot be read or decoded.
+func (reg *Registry) Decode(r *http.Request, v any) error {
+	mt := MediaType(r)
+	i := slices.IndexFunc(reg.codecs, func(c Codec) bool { return slices.Contains(c.MediaTypes(), mt) })
+	if i < 0 {
+		return apperr.Newf(apperr.CodeUnsupportedMedia,
+			"Content-Type %s is not supported; use one of %s.", mt, strings.Join(reg.MediaTypes(), ", "))
+	}
+	err := reg.codecs[i].Decode(r.Body, v)
+	var maxErr *http.MaxBytesError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &maxErr):
+		return apperr.Wrap(err, apperr.CodeTooLarge, "Request body is too large.")
+	case errors.Is(err, errUnsupportedValue):
+		return apperr.Wrap(err, apperr.CodeUnsupportedMedia, "Content-Type "+mt+" is not supported by this endpoint.")
+	case errors.Is(err, io.EOF):
+		return apperr.InvalidArgument("Request body must not be empty.")
+	default:
+		return apperr.Wrap(err, apperr.CodeInvalidArgument, "Request body could not be decoded as "+mt+".")
+	}
+}
+
+// Render writes v with Default.
+func Render(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	return Default.Render(w, r, status, v)
+}
+
+// Decode decodes the body of r with Default.
+func Decode(r *http.Request, v any) error {
+	return Default.Decode(r, v)
+}
+
+// MediaType returns the media type of r without parameters, application/json
+// when it has no Content-Type. Structured syntax suffixes map to their base
+// type, so application/problem+json is application/json.
+func MediaType(r *http.Request) string {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return "application/json"
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ct
+	}
+	return withoutSuffix(mt)
+}
+
+// withoutSuffix maps "application/vnd.acme+json" to "application/json".
+func withoutSuffix(mt string) string {
+	if i := strings.LastIndexByte(mt, '+'); i >= 0 && strings.HasPrefix(mt, "application/") {
+		return "application/" + mt[i+1:]
+	}
+	return mt
+}
+
+// acceptRange is one media range of an Accept header.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// acceptRanges parses Accept header values; it returns nil when there are
+// none, meaning anything is accepted.
+func acceptRanges(values []string) []acceptRange {
+	var ranges []acceptRange
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			mt, params, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if s, ok := params["q"]; ok {
+				if f, err := strconv.ParseFloat(s, 64); err == nil && f >= 0 && f <= 1 {
+					q = f
+				}
+			}
+			ranges = append(ranges, acceptRange{mediaType: withoutSuffix(mt), q: q})
+		}
+	}
+	return ranges
+}
+
+// quality returns the q-value the client gives to types: that of the most
+// specific range matching one of them, 0 when none does.
+func quality(ranges []acceptRange, types []string) float64 {
+	q, specificity := 0.0, 0
+	for _, ar := range ranges {
+		for _, t := range types {
+			s := 0
+			major, _, _ := strings.Cut(t, "/")
+			switch ar.mediaType {
+			case t:
+				s = 3
+			case major + "/*":
+				s = 2
+			case "*/*":
+				s = 1
+			}
+			if s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+	}
+	return q
+}
@@ -0,0 +1,317 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+type item struct {
+	SKU     string    `json:"sku"`
+	Count   int       `json:"count"`
+	Note    string    `json:"note,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+var sample = item{SKU: "A-1", Count: 3, Created: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)}
+
+// assertSample checks got equals sample; decoders may set another location
+// on the time.
+func assertSample(t *testing.T, got item) {
+	t.Helper()
+	assert.True(t, sample.Created.Equal(got.Created), "created: %v", got.Created)
+	got.Created = sample.Created
+	assert.Equal(t, sample, got)
+}
+
+func codecError(t *testing.T, err error) apperr.Code {
+	t.Helper()
+	var appErr *apperr.Error
+	require.True(t, errors.As(err, &appErr), "got %v", err)
+	return appErr.Code
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		value  any
+		want   string
+	}{
+		{"no accept, string", "", "hi", "text/plain"},
+		{"no accept, struct", "", sample, "application/json"},
+		{"wildcard", "*/*", sample, "application/json"},
+		{"exact", "application/cbor", sample, "application/cbor"},
+		{"alias", "application/x-msgpack", sample, "application/msgpack"},
+		{"q-values", "application/json;q=0.5, application/msgpack", sample, "application/msgpack"},
+		{"specific beats wildcard", "application/*;q=0.9, application/json;q=0.1, */*;q=0", sample, "application/cbor"},
+		{"structured suffix", "application/vnd.acme.v2+json", sample, "application/json"},
+		{"string as json", "application/json", "hi", "application/json"},
+		{"protobuf message", "application/x-protobuf", wrapperspb.String("hi"), "application/x-protobuf"},
+		{"browser", "text/html,application/xhtml+xml,*/*;q=0.8", "hi", "text/plain"},
+		{"malformed range skipped", "/, application/cbor", sample, "application/cbor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			c, err := Default.Negotiate(req, tt.value)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.MediaTypes()[0])
+		})
+	}
+}
+
+func TestNegotiate_NotAcceptable(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		value  any
+	}{
+		{"unknown type", "application/xml", sample},
+		{"protobuf for a struct", "application/x-protobuf", sample},
+		{"text for a struct", "text/plain", sample},
+		{"refused", "application/json;q=0", sample},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+
+			_, err := Default.Negotiate(req, tt.value)
+
+			assert.Equal(t, apperr.CodeNotAcceptable, codecError(t, err))
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	decoders := map[string]func([]byte, any) error{
+		"application/json":    jsonUnmarshal,
+		"application/cbor":    cbor.Unmarshal,
+		"application/msgpack": msgpackUnmarshal,
+	}
+	for mediaType, decode := range decoders {
+		t.Run(mediaType, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", mediaType)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, Render(rec, req, http.StatusCreated, sample))
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, mediaType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+			var got item
+			require.NoError(t, decode(rec.Body.Bytes(), &got))
+			assertSample(t, got)
+		})
+	}
+
+	t.Run("application/x-protobuf", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "application/x-protobuf")
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, Render(rec, req, http.StatusOK, wrapperspb.String("hi")))
+
+		var got wrapperspb.StringValue
+		require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, "hi", got.GetValue())
+	})
+
+	t.Run("text/plain", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		require.NoError(t, Render(rec, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, "Hello"))
+
+		assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "Hello", rec.Body.String())
+	})
+}
+
+func TestRender_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		value  any
+		want   apperr.Code
+	}{
+		{"not acceptable", "application/xml", sample, apperr.CodeNotAcceptable},
+		{"unencodable value", "application/json", make(chan int), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+
+			err := Render(rec, req, http.StatusOK, tt.value)
+
+			require.Error(t, err)
+			if tt.want != "" {
+				assert.Equal(t, tt.want, codecError(t, err))
+			}
+			assert.Empty(t, rec.Header().Get("Content-Type"), "nothing is written")
+		})
+	}
+}
+
+// csv is a codec registered after the defaults.
+type csv struct{ Text }
+
+func (csv) MediaTypes() []string { return []string{"text/csv"} }
+
+func TestRegistry_Register(t *testing.T) {
+	// GIVEN a registry with a codec registered after JSON
+	reg := NewRegistry(JSON{})
+	reg.Register(csv{})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/csv, application/json;q=0.5")
+
+	// WHEN a string is negotiated
+	c, err := reg.Negotiate(req, "a,b")
+
+	// THEN the registered codec is used and listed
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv", c.MediaTypes()[0])
+	assert.Equal(t, []string{"application/json", "text/csv"}, reg.MediaTypes())
+}
+
+func TestText(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Text{}.Encode(&buf, []byte("raw ")))
+	require.NoError(t, Text{}.Encode(&buf, "text"))
+	assert.Equal(t, "raw text", buf.String())
+	assert.ErrorIs(t, Text{}.Encode(&buf, 42), errUnsupportedValue)
+
+	var s string
+	require.NoError(t, Text{}.Decode(strings.NewReader("hello"), &s))
+	assert.Equal(t, "hello", s)
+	var b []byte
+	require.NoError(t, Text{}.Decode(strings.NewReader("hello"), &b))
+	assert.Equal(t, []byte("hello"), b)
+	assert.ErrorIs(t, Text{}.Decode(strings.NewReader("hello"), &item{}), errUnsupportedValue)
+	assert.ErrorIs(t, Text{}.Decode(iotest.ErrReader(io.ErrUnexpectedEOF), &s), io.ErrUnexpectedEOF)
+}
+
+func TestProtobuf_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	assert.ErrorIs(t, Protobuf{}.Encode(&buf, sample), errUnsupportedValue)
+	assert.ErrorContains(t, Protobuf{}.Encode(&buf, wrapperspb.String("\xff")), "UTF-8")
+	var got wrapperspb.StringValue
+	assert.ErrorIs(t, Protobuf{}.Decode(iotest.ErrReader(io.ErrUnexpectedEOF), &got), io.ErrUnexpectedEOF)
+}
+
+func jsonUnmarshal(b []byte, v any) error { return JSON{}.Decode(bytes.NewReader(b), v) }
+
+func msgpackUnmarshal(b []byte, v any) error { return MsgPack{}.Decode(bytes.NewReader(b), v) }
+
+// failingWriter is a ResponseWriter whose Write always fails.
+type failingWriter struct{ httptest.ResponseRecorder }
+
+func (w *failingWriter) Write([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func TestRender_WriteErrorLogged(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(logger.Replace(zap.New(core)))
+	w := &failingWriter{ResponseRecorder: *httptest.NewRecorder()}
+
+	err := Render(w, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, "Hello")
+
+	assert.NoError(t, err, "the response is committed, so the error cannot be returned")
+	assert.Equal(t, 1, logs.FilterMessage("failed to write response").Len())
+}
+
+func TestDecode(t *testing.T) {
+	cborBody, err := cbor.Marshal(sample)
+	require.NoError(t, err)
+	var msgpackBody bytes.Buffer
+	require.NoError(t, MsgPack{}.Encode(&msgpackBody, sample))
+	tests := []struct {
+		contentType string
+		body        []byte
+	}{
+		{"", []byte(`{"sku":"A-1","count":3,"created":"2024-05-01T08:30:00Z"}`)},
+		{"application/merge-patch+json", []byte(`{"sku":"A-1","count":3,"created":"2024-05-01T08:30:00Z"}`)},
+		{"application/cbor", cborBody},
+		{"application/msgpack", msgpackBody.Bytes()},
+		{"application/vnd.msgpack", msgpackBody.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			var got item
+			require.NoError(t, Decode(req, &got))
+
+			assertSample(t, got)
+		})
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		dst         any
+		want        apperr.Code
+	}{
+		{"unknown media type", "application/xml", "<item/>", &item{}, apperr.CodeUnsupportedMedia},
+		{"malformed media type", "application/json; charset", "{}", &item{}, apperr.CodeUnsupportedMedia},
+		{"text into a struct", "text/plain", "hi", &item{}, apperr.CodeUnsupportedMedia},
+		{"protobuf into a struct", "application/x-protobuf", "\x0a\x02hi", &item{}, apperr.CodeUnsupportedMedia},
+		{"malformed cbor", "application/cbor", "\xff\xff", &item{}, apperr.CodeInvalidArgument},
+		{"empty body", "application/msgpack", "", &item{}, apperr.CodeInvalidArgument},
+		{"too large", "application/json", strings.Repeat(" ", 64) + "{}", &item{}, apperr.CodeTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 16)
+
+			err := Decode(req, tt.dst)
+
+			assert.Equal(t, tt.want, codecError(t, err))
+		})
+	}
+}
+
+func TestDecode_Protobuf(t *testing.T) {
+	body, err := proto.Marshal(wrapperspb.String("hi"))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/protobuf")
+
+	var got wrapperspb.StringValue
+	require.NoError(t, Decode(req, &got))
+
+	assert.Equal(t, "hi", got.GetValue())
+}
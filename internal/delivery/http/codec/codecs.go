@@ -0,0 +1,152 @@
+package codec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// errUnsupportedValue is returned when a codec cannot handle a value, e.g.
+// Protobuf with a plain struct.
+var errUnsupportedValue = errors.New("codec: unsupported value")
+
+// Text encodes strings and byte slices as text/plain.
+type Text struct{}
+
+func (Text) MediaTypes() []string { return []string{"text/plain"} }
+
+func (Text) CanEncode(v any) bool {
+	switch v.(type) {
+	case string, []byte:
+		return true
+	}
+	return false
+}
+
+func (Text) Encode(w io.Writer, v any) error {
+	switch v := v.(type) {
+	case string:
+		_, err := io.WriteString(w, v)
+		return err
+	case []byte:
+		_, err := w.Write(v)
+		return err
+	}
+	return fmt.Errorf("%w: %T as text/plain", errUnsupportedValue, v)
+}
+
+func (Text) Decode(r io.Reader, v any) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case *string:
+		*v = string(body)
+	case *[]byte:
+		*v = body
+	default:
+		return fmt.Errorf("%w: %T from text/plain", errUnsupportedValue, v)
+	}
+	return nil
+}
+
+// JSON encodes values with encoding/json.
+type JSON struct{}
+
+func (JSON) MediaTypes() []string { return []string{"application/json"} }
+
+func (JSON) CanEncode(any) bool { return true }
+
+func (JSON) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (JSON) Decode(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// CBOR encodes values as CBOR (RFC 8949). Struct fields are named by their
+// cbor tag, or json tag when absent; times are RFC 3339 strings.
+type CBOR struct{}
+
+var (
+	cborEnc, _ = cbor.EncOptions{Time: cbor.TimeRFC3339Nano}.EncMode()
+	cborDec, _ = cbor.DecOptions{}.DecMode()
+)
+
+func (CBOR) MediaTypes() []string { return []string{"application/cbor"} }
+
+func (CBOR) CanEncode(any) bool { return true }
+
+func (CBOR) Encode(w io.Writer, v any) error {
+	return cborEnc.NewEncoder(w).Encode(v)
+}
+
+func (CBOR) Decode(r io.Reader, v any) error {
+	return cborDec.NewDecoder(r).Decode(v)
+}
+
+// MsgPack encodes values as MessagePack. Struct fields are named by their
+// json tag, like in JSON.
+type MsgPack struct{}
+
+func (MsgPack) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (MsgPack) CanEncode(any) bool { return true }
+
+func (MsgPack) Encode(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func (MsgPack) Decode(r io.Reader, v any) error {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// Protobuf encodes proto.Message values in the Protobuf binary format.
+type Protobuf struct{}
+
+func (Protobuf) MediaTypes() []string {
+	return []string{"application/x-protobuf", "application/protobuf"}
+}
+
+func (Protobuf) CanEncode(v any) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+func (Protobuf) Encode(w io.Writer, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T as protobuf", errUnsupportedValue, v)
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (Protobuf) Decode(r io.Reader, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T from protobuf", errUnsupportedValue, v)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, m)
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+
+	"template-go/internal/config"
+	"template-go/pkg/logger"
+)
+
+// AccessLog logs one structured line per request through pkg/logger, so
+// entries carry the trace_id and span_id of the request span. Requests
+// answered with a 5xx status are logged at error level, 4xx at warn level
+// and the rest at info level. Successful requests are sampled according to
+// cfg.SampleRatio and paths in cfg.ExcludePaths are skipped entirely.
+func AccessLog(cfg config.AccessLogConfig) func(http.Handler) http.Handler {
+	return accessLog(cfg, rand.Float64)
+}
+
+// accessLog is AccessLog with an injectable random source for tests.
+func accessLog(cfg config.AccessLogConfig, random func() float64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if excluded(r.URL.Path, cfg.ExcludePaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			extra := &logFields{}
+			r = r.WithContext(context.WithValue(r.Context(), logFieldsKey{}, extra))
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // nothing written: net/http sends 200
+			}
+			if status < http.StatusBadRequest && random() >= cfg.SampleRatio {
+				return
+			}
+
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("route", RoutePattern(r)),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", status),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.Duration("latency", time.Since(start)),
+				zap.String("remote_ip", remoteIP(r)),
+				zap.String("user_agent", r.UserAgent()),
+				zap.String("request_id", middleware.GetReqID(r.Context())),
+			}
+			fields = append(fields, extra.fields...)
+			switch {
+			case status >= http.StatusInternalServerError:
+				logger.Error(r.Context(), "http request", fields...)
+			case status >= http.StatusBadRequest:
+				logger.Warn(r.Context(), "http request", fields...)
+			default:
+				logger.Info(r.Context(), "http request", fields...)
+			}
+		})
+	}
+}
+
+type logFieldsKey struct{}
+
+// logFields collects fields added by inner middlewares and handlers.
+type logFields struct {
+	fields []zap.Field
+}
+
+// AddLogFields attaches fields to the access log entry of the request
+// carrying ctx, e.g. the authenticated subject. It is a no-op outside
+// AccessLog.
+func AddLogFields(ctx context.Context, fields ...zap.Field) {
+	if lf, ok := ctx.Value(logFieldsKey{}).(*logFields); ok {
+		lf.fields = append(lf.fields, fields...)
+	}
+}
+
+// excluded reports whether path matches one of patterns. A pattern ending
+// in "*" matches every path with that prefix.
+func excluded(path string, patterns []string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutePattern returns the chi route that matched, e.g. "/users/{id}", so
+// logs and spans can be grouped without high-cardinality paths.
+func RoutePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// remoteIP strips the port from r.RemoteAddr, which RealIP may already have
+// replaced with a bare address.
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"template-go/internal/config"
+	"template-go/pkg/logger"
+)
+
+// observeLogs routes pkg/logger into an observer for the duration of t.
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(logger.Replace(zap.New(core)))
+	return logs
+}
+
+// accessLogRouter serves /users/{id} with the given status behind the
+// access log.
+func accessLogRouter(cfg config.AccessLogConfig, random func() float64, status int) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(accessLog(cfg, random))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("hello"))
+	})
+	r.Get("/livez", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/debug/pprof/*", func(w http.ResponseWriter, r *http.Request) {})
+	return r
+}
+
+func TestAccessLog_Fields(t *testing.T) {
+	// GIVEN a traced request to a parameterised route
+	logs := observeLogs(t)
+	router := accessLogRouter(config.AccessLogConfig{Enabled: true, SampleRatio: 1}, func() float64 { return 0 }, http.StatusOK)
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := trace.SpanIDFromHex("0102030405060708")
+	ctx := trace.ContextWithSpanContext(context.Background(),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled}))
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil).WithContext(ctx)
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("User-Agent", "curl/8")
+
+	// WHEN it is served
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// THEN one info entry describes it
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.InfoLevel, entry.Level)
+	assert.Equal(t, "http request", entry.Message)
+	fields := entry.ContextMap()
+	assert.Equal(t, "GET", fields["method"])
+	assert.Equal(t, "/users/{id}", fields["route"])
+	assert.Equal(t, "/users/42", fields["path"])
+	assert.Equal(t, int64(200), fields["status"])
+	assert.Equal(t, int64(5), fields["bytes"])
+	assert.Contains(t, fields, "latency")
+	assert.Equal(t, "10.0.0.1", fields["remote_ip"])
+	assert.Equal(t, "curl/8", fields["user_agent"])
+	assert.NotEmpty(t, fields["request_id"])
+	assert.Equal(t, traceID.String(), fields["trace_id"])
+	assert.Equal(t, spanID.String(), fields["span_id"])
+}
+
+func TestAccessLog_LevelsAndSampling(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		ratio     float64
+		wantLevel zapcore.Level
+		wantLog   bool
+	}{
+		{"success sampled in", http.StatusOK, 0.5, zapcore.InfoLevel, true},
+		{"success sampled out", http.StatusOK, 0.1, 0, false},
+		{"success never sampled", http.StatusOK, 0, 0, false},
+		{"client error always logged", http.StatusNotFound, 0, zapcore.WarnLevel, true},
+		{"server error always logged", http.StatusBadGateway, 0, zapcore.ErrorLevel, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeLogs(t)
+			cfg := config.AccessLogConfig{Enabled: true, SampleRatio: tt.ratio}
+			router := accessLogRouter(cfg, func() float64 { return 0.3 }, tt.status)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+			if !tt.wantLog {
+				assert.Zero(t, logs.Len())
+				return
+			}
+			require.Equal(t, 1, logs.Len())
+			assert.Equal(t, tt.wantLevel, logs.All()[0].Level)
+		})
+	}
+}
+
+func TestAccessLog_Exclusions(t *testing.T) {
+	logs := observeLogs(t)
+	cfg := config.AccessLogConfig{Enabled: true, SampleRatio: 1, ExcludePaths: []string{"/livez", "/debug/*"}}
+	router := accessLogRouter(cfg, func() float64 { return 0 }, http.StatusOK)
+
+	for _, path := range []string{"/livez", "/debug/pprof/heap", "/users/1"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "/users/1", logs.All()[0].ContextMap()["path"])
+}
+
+func TestAccessLog_Disabled(t *testing.T) {
+	logs := observeLogs(t)
+	router := accessLogRouter(config.AccessLogConfig{SampleRatio: 1}, func() float64 { return 0 }, http.StatusInternalServerError)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	assert.Zero(t, logs.Len())
+}
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"template-go/internal/auth"
+	"template-go/internal/config"
+	"template-go/pkg/apperr"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func hmacToken(t *testing.T, claims auth.Claims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return s
+}
+
+func validClaims() auth.Claims {
+	return auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		Scope:            "items:read",
+		TenantID:         "acme",
+	}
+}
+
+// authRouter serves /public and /private, the latter behind RequireAuth,
+// answering with the authenticated subject.
+func authRouter(t *testing.T) http.Handler {
+	t.Helper()
+	v, err := auth.NewVerifier(config.AuthConfig{Algorithms: []string{"HS256"}, HMACSecret: testSecret})
+	require.NoError(t, err)
+	subject := func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := auth.FromContext(r.Context()); ok {
+			_, _ = w.Write([]byte(c.Subject))
+		}
+	}
+	r := chi.NewRouter()
+	r.Use(AccessLog(config.AccessLogConfig{Enabled: true, SampleRatio: 1}))
+	r.Use(Authenticate(v))
+	r.Get("/public", subject)
+	r.With(RequireAuth).Get("/private", subject)
+	return r
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+		wantBody      string
+		wantChallenge string
+	}{
+		{"anonymous public", "/public", "", http.StatusOK, "", ""},
+		{"anonymous private", "/private", "", http.StatusUnauthorized, "", "Bearer"},
+		{"other scheme", "/private", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "", "Bearer"},
+		{"valid token", "/private", "Bearer " + hmacToken(t, validClaims()), http.StatusOK, "user-1", ""},
+		{"lowercase scheme", "/public", "bearer " + hmacToken(t, validClaims()), http.StatusOK, "user-1", ""},
+		{"invalid token", "/public", "Bearer garbage", http.StatusUnauthorized, "", `Bearer error="invalid_token"`},
+		{"empty token", "/public", "Bearer ", http.StatusUnauthorized, "", `Bearer error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observeLogs(t)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			authRouter(t).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantChallenge, rec.Header().Get("WWW-Authenticate"))
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			} else {
+				assert.Equal(t, apperr.ContentType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestAuthenticate_AnnotatesLogAndSpan(t *testing.T) {
+	// GIVEN a traced, access logged request with a valid token
+	logs := observeLogs(t)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := tp.Tracer("test").Start(t.Context(), "request")
+	req := httptest.NewRequest(http.MethodGet, "/private", nil).WithContext(ctx)
+	req.Header.Set("Authorization", "Bearer "+hmacToken(t, validClaims()))
+
+	// WHEN it is served
+	authRouter(t).ServeHTTP(httptest.NewRecorder(), req)
+	span.End()
+
+	// THEN the access log entry names the caller
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "user-1", fields["subject"])
+	assert.Equal(t, "acme", fields["tenant_id"])
+
+	// AND so does the span
+	require.Len(t, exporter.GetSpans(), 1)
+	attrs := map[string]string{}
+	for _, kv := range exporter.GetSpans()[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	assert.Equal(t, "user-1", attrs["enduser.id"])
+	assert.Equal(t, "items:read", attrs["enduser.scope"])
+	assert.Equal(t, "acme", attrs["tenant.id"])
+}
+
+// apiKeyRouter serves /items behind API key authentication and the
+// items:read scope; it returns the router and a key holding that scope.
+func apiKeyRouter(t *testing.T) (http.Handler, string) {
+	t.Helper()
+	store, err := auth.NewFileKeyStore(filepath.Join(t.TempDir(), "keys.yaml"))
+	require.NoError(t, err)
+	keys := auth.NewAPIKeys(store, time.Minute)
+	key, _, err := keys.Mint(context.Background(), auth.MintRequest{Name: "gw", Scopes: []string{"items:read"}})
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(APIKey(keys, config.APIKeyConfig{Header: "X-API-Key", QueryParam: "api_key"}))
+	r.With(RequireScope("items:read")).Get("/items", func(w http.ResponseWriter, r *http.Request) {
+		c, _ := auth.FromContext(r.Context())
+		_, _ = w.Write([]byte(c.Subject))
+	})
+	r.With(RequireScope("items:write")).Post("/items", func(w http.ResponseWriter, r *http.Request) {})
+	return r, key
+}
+
+func TestAPIKey(t *testing.T) {
+	observeLogs(t)
+	router, key := apiKeyRouter(t)
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		header     string
+		wantStatus int
+	}{
+		{"header", http.MethodGet, "/items", key, http.StatusOK},
+		{"query parameter", http.MethodGet, "/items?api_key=" + key, "", http.StatusOK},
+		{"missing key", http.MethodGet, "/items", "", http.StatusUnauthorized},
+		{"invalid key", http.MethodGet, "/items", "tg_123_nope", http.StatusUnauthorized},
+		{"missing scope", http.MethodPost, "/items", key, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if rec.Code == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "apikey:")
+			}
+		})
+	}
+}
+
+func TestAPIKeyOf(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/items?api_key=k1", nil)
+	req.Header.Set("X-API-Key", "k2")
+
+	assert.Equal(t, "k2", apiKeyOf(req, config.APIKeyConfig{Header: "X-API-Key", QueryParam: "api_key"}))
+	assert.Equal(t, "k1", apiKeyOf(req, config.APIKeyConfig{Header: "X-Other-Key", QueryParam: "api_key"}))
+	assert.Empty(t, apiKeyOf(req, config.APIKeyConfig{Header: "X-Other-Key"}), "query keys are ignored without query_param")
+}
+
+func TestRequireToken(t *testing.T) {
+	observeLogs(t)
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{"valid token", testSecret, "Bearer " + testSecret, http.StatusOK},
+		{"wrong token", testSecret, "Bearer " + testSecret + "x", http.StatusUnauthorized},
+		{"other scheme", testSecret, "Basic " + testSecret, http.StatusUnauthorized},
+		{"missing header", testSecret, "", http.StatusUnauthorized},
+		{"empty token", "", "Bearer ", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN a handler guarded by a static token
+			handler := RequireToken(tt.token)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			// WHEN a request is served
+			handler.ServeHTTP(rec, req)
+
+			// THEN only the exact token is let through
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if rec.Code == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"template-go/internal/config"
+	"template-go/pkg/apperr"
+)
+
+// BodyLimit caps request bodies at limit bytes. Reading past the limit
+// fails with *http.MaxBytesError, which the bind package answers with 413;
+// requests that declare a larger Content-Length are rejected up front. A
+// limit of zero or less disables the check.
+func BodyLimit(limit config.ByteSize) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > int64(limit) {
+				apperr.Render(w, r, apperr.New(apperr.CodeTooLarge, "Request body is too large."))
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/pkg/apperr"
+)
+
+func TestBodyLimit(t *testing.T) {
+	observeLogs(t)
+	var readErr error
+	handler := BodyLimit(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	t.Run("within limit", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("12345678")))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, readErr)
+	})
+
+	t.Run("declared length over limit", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("123456789")))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+		assert.Equal(t, apperr.ContentType, rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("streamed body over limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("123456789"))
+		req.ContentLength = -1
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		var maxErr *http.MaxBytesError
+		require.ErrorAs(t, readErr, &maxErr)
+	})
+}
+
+func TestBodyLimit_Disabled(t *testing.T) {
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	handler := BodyLimit(0)(next)
+
+	assert.NotNil(t, handler)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("anything")))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,63 @@
+// Package middleware holds the HTTP middlewares used by the routers.
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+)
+
+// ClientIdentity describes the verified certificate a client presented
+// during a mutual TLS handshake.
+type ClientIdentity struct {
+	// Subject is the distinguished name, e.g. "CN=billing,O=example".
+	Subject    string
+	CommonName string
+	DNSNames   []string
+	// URIs holds URI SANs such as SPIFFE IDs.
+	URIs   []string
+	Serial string
+	// Fingerprint is the hex encoded SHA-256 of the certificate.
+	Fingerprint string
+}
+
+type clientIdentityKey struct{}
+
+// ClientCert stores the identity of a verified client certificate in the
+// request context. Certificates that were presented but not verified
+// against the client CA bundle are ignored, so handlers can trust whatever
+// ClientIdentityFrom returns.
+func ClientCert(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			id := identityOf(r.TLS.VerifiedChains[0][0])
+			r = r.WithContext(context.WithValue(r.Context(), clientIdentityKey{}, id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIdentityFrom returns the verified client identity stored by
+// ClientCert, if any.
+func ClientIdentityFrom(ctx context.Context) (ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityKey{}).(ClientIdentity)
+	return id, ok
+}
+
+// identityOf extracts the identity fields of a leaf certificate.
+func identityOf(cert *x509.Certificate) ClientIdentity {
+	sum := sha256.Sum256(cert.Raw)
+	id := ClientIdentity{
+		Subject:     cert.Subject.String(),
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		Serial:      cert.SerialNumber.String(),
+		Fingerprint: hex.EncodeToString(sum[:]),
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	return id
+}
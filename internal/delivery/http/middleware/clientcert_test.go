@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCert(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/billing")
+	cert := &x509.Certificate{
+		Raw:          []byte("der"),
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "billing", Organization: []string{"example"}},
+		DNSNames:     []string{"billing.internal"},
+		URIs:         []*url.URL{spiffe},
+	}
+
+	tests := []struct {
+		name   string
+		state  *tls.ConnectionState
+		wantID bool
+	}{
+		{"plain http", nil, false},
+		{"tls without client cert", &tls.ConnectionState{}, false},
+		{"unverified client cert", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, false},
+		{"verified client cert", &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				got ClientIdentity
+				ok  bool
+			)
+			handler := ClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, ok = ClientIdentityFrom(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.TLS = tt.state
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantID, ok)
+			if tt.wantID {
+				assert.Equal(t, "billing", got.CommonName)
+				assert.Equal(t, "CN=billing,O=example", got.Subject)
+				assert.Equal(t, []string{"billing.internal"}, got.DNSNames)
+				assert.Equal(t, []string{"spiffe://example.org/billing"}, got.URIs)
+				assert.Equal(t, "42", got.Serial)
+				assert.Len(t, got.Fingerprint, 64)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// RealIP replaces r.RemoteAddr with the client address reported by a
+// trusted reverse proxy. Only requests whose peer is in trusted are
+// rewritten, so clients cannot choose their address by sending the headers
+// themselves. X-Forwarded-For is read from the right, skipping trusted
+// proxies, as the entries left of the last untrusted hop may be forged;
+// X-Real-IP is used when there is no X-Forwarded-For.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip, ok := forwardedIP(r, trusted); ok {
+				r.RemoteAddr = ip
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedIP returns the client address named by the proxy headers of r
+// when its peer is trusted.
+func forwardedIP(r *http.Request, trusted []netip.Prefix) (string, bool) {
+	isTrusted := func(addr netip.Addr) bool {
+		return slices.ContainsFunc(trusted, func(p netip.Prefix) bool { return p.Contains(addr.Unmap()) })
+	}
+	if peer, err := netip.ParseAddr(remoteIP(r)); err != nil || !isTrusted(peer) {
+		return "", false
+	}
+	if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				return "", false
+			}
+			if i == 0 || !isTrusted(addr) {
+				return addr.Unmap().String(), true
+			}
+		}
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap().String(), true
+	}
+	return "", false
+}
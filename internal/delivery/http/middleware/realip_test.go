@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32")}
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{"untrusted peer keeps its address", "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.9:1234"},
+		{"trusted peer without headers", "10.0.0.1:1234", nil, "10.0.0.1:1234"},
+		{"client behind one proxy", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"forged entries left of the client are ignored", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"chain of trusted proxies only", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 192.0.2.1"}, "10.0.0.3"},
+		{"malformed hop", "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, unknown"}, "10.0.0.1:1234"},
+		{"x-real-ip", "192.0.2.1:1234", map[string]string{"X-Real-IP": " 198.51.100.2 "}, "198.51.100.2"},
+		{"ipv4-mapped peer", "[::ffff:10.0.0.1]:1234", map[string]string{"X-Real-IP": "::ffff:198.51.100.3"}, "198.51.100.3"},
+		{"malformed x-real-ip", "10.0.0.1:1234", map[string]string{"X-Real-IP": "unknown"}, "10.0.0.1:1234"},
+		{"unparsable peer", "pipe", map[string]string{"X-Real-IP": "198.51.100.2"}, "pipe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN a request from peer with proxy headers
+			var got string
+			handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			// WHEN it is served
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			// THEN the remote address is only replaced for trusted peers
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
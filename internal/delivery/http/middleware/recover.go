@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+// Recover turns a panicking handler into a 500 problem response. The panic is
+// recorded as an exception on the request span, which is marked as failed,
+// logged with its stack through pkg/logger and counted in the
+// http.server.panics metric. http.ErrAbortHandler is re-panicked so
+// net/http can abort the connection as intended.
+func Recover() func(http.Handler) http.Handler {
+	panics, _ := otel.Meter("template-go/internal/delivery/http/middleware").Int64Counter("http.server.panics",
+		metric.WithDescription("Number of requests whose handler panicked."))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				ctx := r.Context()
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rec)
+				}
+				err = fmt.Errorf("panic: %w", err)
+				stack := string(debug.Stack())
+
+				span := trace.SpanFromContext(ctx)
+				span.RecordError(err, trace.WithAttributes(attribute.String("exception.stacktrace", stack)))
+				span.SetStatus(codes.Error, err.Error())
+				route := RoutePattern(r)
+				panics.Add(ctx, 1, metric.WithAttributes(attribute.String("http.route", route)))
+				logger.Error(ctx, "recovered from panic in HTTP handler",
+					zap.Error(err),
+					zap.String("method", r.Method),
+					zap.String("route", route),
+					zap.String("request_id", middleware.GetReqID(ctx)),
+					zap.String("stacktrace", stack),
+				)
+
+				if ww.Status() != 0 {
+					return // the response has started; the client sees a truncated body
+				}
+				apperr.WriteProblem(w, apperr.ProblemFor(r, apperr.Internal(err)))
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
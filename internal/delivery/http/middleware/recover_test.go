@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap/zapcore"
+
+	"template-go/pkg/apperr"
+)
+
+func TestRecover(t *testing.T) {
+	// GIVEN a traced route that panics, with metrics and logs observed
+	logs := observeLogs(t)
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(prev)
+	spans := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)).Tracer("test")
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(Recover())
+	r.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic(errors.New("nil map"))
+	})
+	ctx, span := tracer.Start(context.Background(), "request")
+	req := httptest.NewRequest(http.MethodGet, "/orders/7", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	// WHEN the route is served
+	r.ServeHTTP(rec, req)
+	span.End()
+
+	// THEN the client gets a problem 500 with the request ID but no panic details
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, apperr.ContentType, rec.Header().Get("Content-Type"))
+	var body apperr.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, apperr.CodeInternal, body.Code)
+	assert.Equal(t, "Internal Server Error", body.Title)
+	assert.NotEmpty(t, body.RequestID)
+	assert.NotContains(t, rec.Body.String(), "nil map")
+
+	// AND the span records the exception and fails
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+	require.NotEmpty(t, ended[0].Events())
+	assert.Equal(t, "exception", ended[0].Events()[0].Name)
+
+	// AND the panic is logged with its stack and request ID
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+	fields := entry.ContextMap()
+	assert.Equal(t, "panic: nil map", fields["error"])
+	assert.Equal(t, "/orders/{id}", fields["route"])
+	assert.Equal(t, body.RequestID, fields["request_id"])
+	assert.Contains(t, fields["stacktrace"], "recover_test.go")
+	assert.Equal(t, span.SpanContext().TraceID().String(), fields["trace_id"])
+
+	// AND the panic is counted
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	assert.Equal(t, "http.server.panics", rm.ScopeMetrics[0].Metrics[0].Name)
+	assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+}
+
+func TestRecover_ResponseAlreadyStarted(t *testing.T) {
+	observeLogs(t)
+	handler := Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("late failure")
+	}))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+}
+
+func TestRecover_AbortHandlerPropagates(t *testing.T) {
+	handler := Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestRecover_PassesThroughWithoutPanic(t *testing.T) {
+	handler := Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"template-go/pkg/apperr"
+)
+
+// VersionHeader is the request header naming the API version, e.g.
+// "API-Version: v2".
+const VersionHeader = "API-Version"
+
+// vendorVersion matches vendor media types carrying a version, e.g.
+// "application/vnd.acme.v2+json".
+var vendorVersion = regexp.MustCompile(`^application/vnd\.[^+]+\.(v[0-9]+)(\+[a-z0-9.-]+)?$`)
+
+type versionKey struct{}
+
+// Version negotiates the API version of a request among versions, e.g. "v1"
+// and "v2". A path starting with a version selects it. Otherwise the
+// VersionHeader, the version parameter of Accept ("application/json;
+// version=2") or a vendor media type ("application/vnd.acme.v2+json")
+// selects it and the path is rewritten to the version's prefix, so the
+// router serves /v2/orders for /orders. Requests naming an unknown version
+// get 406. Requests naming none pass through unchanged. The version is
+// stored in the context, see APIVersionFrom, and added to the request span
+// and the access log.
+func Version(versions ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			version, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+			if !slices.Contains(versions, version) {
+				w.Header().Add("Vary", VersionHeader)
+				w.Header().Add("Vary", "Accept")
+				version = requestedVersion(r)
+				if version == "" {
+					next.ServeHTTP(w, r)
+					return
+				}
+				if !slices.Contains(versions, version) {
+					apperr.Render(w, r, apperr.Newf(apperr.CodeNotAcceptable,
+						"API version %s is not supported; supported versions are %s.", version, strings.Join(versions, ", ")))
+					return
+				}
+				r = withPrefix(r, "/"+version)
+			}
+
+			ctx := context.WithValue(r.Context(), versionKey{}, version)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("api.version", version))
+			AddLogFields(ctx, zap.String("api_version", version))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// APIVersionFrom returns the API version negotiated by Version.
+func APIVersionFrom(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(versionKey{}).(string)
+	return v, ok
+}
+
+// Deprecation marks every response with a Deprecation header (RFC 9745)
+// dated deprecated and, when sunset is set, a Sunset header (RFC 8594)
+// announcing when the routes go away. link, when set, is sent as the
+// deprecation Link, e.g. a migration guide. A zero deprecated disables the
+// middleware.
+func Deprecation(deprecated, sunset time.Time, link string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if deprecated.IsZero() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecated.Unix()))
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+			if link != "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"; type="text/html"`, link))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestedVersion returns the version named by the request headers, as
+// "v<n>", or "" when there is none.
+func requestedVersion(r *http.Request) string {
+	if v := r.Header.Get(VersionHeader); v != "" {
+		return normalizeVersion(v)
+	}
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil {
+				continue
+			}
+			if v := params["version"]; v != "" {
+				return normalizeVersion(v)
+			}
+			if m := vendorVersion.FindStringSubmatch(mediaType); m != nil {
+				return m[1]
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeVersion turns "2", "V2" and "v2" into "v2".
+func normalizeVersion(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	return v
+}
+
+// withPrefix returns a copy of r with prefix prepended to its path.
+func withPrefix(r *http.Request, prefix string) *http.Request {
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = prefix + r.URL.Path
+	if r.URL.RawPath != "" {
+		r2.URL.RawPath = prefix + r.URL.RawPath
+	}
+	return r2
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/config"
+	"template-go/pkg/apperr"
+)
+
+// versionRouter serves /v1/orders, /v2/orders and /v2/orders/{id} behind
+// Version, answering with the route and the negotiated version.
+func versionRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(AccessLog(config.AccessLogConfig{Enabled: true, SampleRatio: 1}))
+	r.Use(Version("v1", "v2"))
+	answer := func(w http.ResponseWriter, r *http.Request) {
+		v, _ := APIVersionFrom(r.Context())
+		_, _ = w.Write([]byte(chi.RouteContext(r.Context()).RoutePattern() + " " + v))
+	}
+	r.Get("/v1/orders", answer)
+	r.Get("/v2/orders", answer)
+	r.Get("/v2/orders/{id}", answer)
+	r.Get("/health", answer)
+	return r
+}
+
+func TestVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		accept     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"path prefix", "/v1/orders", "", "", http.StatusOK, "/v1/orders v1"},
+		{"path prefix wins over header", "/v1/orders", "v2", "", http.StatusOK, "/v1/orders v1"},
+		{"version header", "/orders", "v2", "", http.StatusOK, "/v2/orders v2"},
+		{"bare number", "/orders", "1", "", http.StatusOK, "/v1/orders v1"},
+		{"accept parameter", "/orders", "", "application/json; version=2", http.StatusOK, "/v2/orders v2"},
+		{"vendor media type", "/orders", "", "text/html, application/vnd.acme.v1+json", http.StatusOK, "/v1/orders v1"},
+		{"malformed accept range skipped", "/orders", "", "/, application/vnd.acme.v2+json", http.StatusOK, "/v2/orders v2"},
+		{"escaped path", "/orders/a%2Fb", "v2", "", http.StatusOK, "/v2/orders/{id} v2"},
+		{"unversioned", "/health", "", "application/json", http.StatusOK, "/health "},
+		{"unknown version", "/orders", "v3", "", http.StatusNotAcceptable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observeLogs(t)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(VersionHeader, tt.header)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			versionRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			} else {
+				assert.Equal(t, apperr.ContentType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestVersion_VaryAndLog(t *testing.T) {
+	// GIVEN a request negotiating its version by header
+	logs := observeLogs(t)
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set(VersionHeader, "v2")
+	rec := httptest.NewRecorder()
+
+	// WHEN it is served
+	versionRouter().ServeHTTP(rec, req)
+
+	// THEN caches are told the response depends on the headers
+	assert.Equal(t, []string{VersionHeader, "Accept"}, rec.Header().Values("Vary"))
+	// AND the access log keeps the requested path and names the version
+	require.Equal(t, 1, logs.Len())
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "/orders", fields["path"])
+	assert.Equal(t, "v2", fields["api_version"])
+}
+
+func TestDeprecation(t *testing.T) {
+	deprecated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	ok := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+	t.Run("retired version", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Deprecation(deprecated, sunset, "https://example.com/migrate")(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, "@1767225600", rec.Header().Get("Deprecation"))
+		assert.Equal(t, "Wed, 01 Jul 2026 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"; type="text/html"`, rec.Header().Get("Link"))
+	})
+
+	t.Run("current version", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		Deprecation(time.Time{}, time.Time{}, "")(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+		assert.Empty(t, rec.Header().Get("Sunset"))
+	})
+}
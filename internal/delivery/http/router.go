@@ -1,43 +1,262 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	httpSwagger "github.com/swaggo/http-swagger"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
+	"template-go/internal/auth"
+	"template-go/internal/authz"
+	"template-go/internal/config"
+	mw "template-go/internal/delivery/http/middleware"
 	"template-go/internal/delivery/http/routes"
+	"template-go/internal/health"
+	"template-go/internal/ratelimit"
+	"template-go/pkg/apperr"
 )
 
-func NewRouter(serviceName string) http.Handler {
-	r := chi.NewRouter()
+// Router is the public API handler built by NewRouter.
+type Router struct {
+	chi.Router
+	authz    *authz.Router
+	modules  []routes.Module
+	versions []Version
+
+	mu      sync.Mutex // guards reloads
+	reloads []func(config.Config)
+}
+
+// Update applies the reloadable settings of cfg to subsequent requests:
+// the trusted proxies, the access log, the body size limit and where API
+// keys are read from. The other settings of WithConfig only take effect
+// through NewRouter.
+func (r *Router) Update(cfg config.Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, apply := range r.reloads {
+		apply(cfg)
+	}
+}
+
+// reloadable returns a middleware serving requests through the one build
+// returns for cfg, rebuilt from the new settings on every Update.
+func (r *Router) reloadable(cfg config.Config, build func(config.Config) func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		var current atomic.Pointer[http.Handler]
+		apply := func(cfg config.Config) {
+			h := build(cfg)(next)
+			current.Store(&h)
+		}
+		apply(cfg)
+		r.mu.Lock()
+		r.reloads = append(r.reloads, apply)
+		r.mu.Unlock()
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			(*current.Load()).ServeHTTP(w, req)
+		})
+	}
+}
+
+// SwaggerTags returns the swagger tags of the registered modules, of every
+// version.
+func (r *Router) SwaggerTags() []string {
+	var tags []string
+	for _, m := range r.modules {
+		tags = append(tags, m.SwaggerTags()...)
+	}
+	for _, v := range r.versions {
+		for _, m := range v.Modules {
+			tags = append(tags, m.SwaggerTags()...)
+		}
+	}
+	return tags
+}
+
+// Versions returns the names of the API versions, in registration order.
+func (r *Router) Versions() []string {
+	names := make([]string, len(r.versions))
+	for i, v := range r.versions {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// Version is one version of the public API. Its modules are mounted under
+// /<Name>, e.g. /v1/orders.
+type Version struct {
+	// Name is the path prefix and the negotiated value, "v" and a number.
+	Name    string
+	Modules []routes.Module
+	// Deprecated, when set, adds a Deprecation header to every response of
+	// the version. Sunset announces its removal in a Sunset header and Link
+	// points clients to a migration guide.
+	Deprecated time.Time
+	Sunset     time.Time
+	Link       string
+}
+
+// versionName matches valid Version names.
+var versionName = regexp.MustCompile(`^v[0-9]+$`)
+
+// Option configures NewRouter.
+type Option func(*routerOptions)
+
+type routerOptions struct {
+	cfg      config.Config
+	limiter  *ratelimit.Limiter
+	verifier *auth.Verifier
+	apiKeys  *auth.APIKeys
+	checks   *health.Registry
+	modules  []routes.Module
+	versions []Version
+}
+
+// WithConfig sets the service name used for spans, the trusted proxies,
+// the access log, the body size limit and the API key settings. See
+// Router.Update for those that can change at runtime.
+func WithConfig(cfg config.Config) Option {
+	return func(o *routerOptions) { o.cfg = cfg }
+}
+
+// WithRateLimiter rate limits every request with l.
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(o *routerOptions) { o.limiter = l }
+}
+
+// WithVerifier verifies bearer tokens with v.
+func WithVerifier(v *auth.Verifier) Option {
+	return func(o *routerOptions) { o.verifier = v }
+}
+
+// WithAPIKeys accepts API keys checked by keys.
+func WithAPIKeys(keys *auth.APIKeys) Option {
+	return func(o *routerOptions) { o.apiKeys = keys }
+}
+
+// WithHealthChecks registers the modules' health checks with checks.
+func WithHealthChecks(checks *health.Registry) Option {
+	return func(o *routerOptions) { o.checks = checks }
+}
+
+// WithModules mounts modules, in order, at their prefixes.
+func WithModules(modules ...routes.Module) Option {
+	return func(o *routerOptions) { o.modules = append(o.modules, modules...) }
+}
+
+// WithVersions serves versions side by side under their path prefixes.
+// Requests without one are routed by the API-Version or Accept header, see
+// middleware.Version, and otherwise to the unversioned modules. NewRouter
+// panics on a version name other than "v<n>".
+func WithVersions(versions ...Version) Option {
+	return func(o *routerOptions) { o.versions = append(o.versions, versions...) }
+}
+
+// NewRouter returns the public API handler serving the registered modules.
+// Operational endpoints live on the admin router. Token verification, API
+// keys and rate limiting are only enabled by their options.
+func NewRouter(opts ...Option) *Router {
+	var o routerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// Records the authorization policies of the routes for /debug/policies
+	r := authz.NewRouter()
+	router := &Router{Router: r, authz: r, modules: o.modules, versions: o.versions}
 
 	// OTel Middleware
 	// This should be the first middleware
 	r.Use(func(next http.Handler) http.Handler {
-		return otelhttp.NewHandler(next, serviceName)
+		return otelhttp.NewHandler(next, o.cfg.OTEL.ServiceName)
 	})
 
 	// Common middlewares
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Logger)    // logs every request
-	r.Use(middleware.Recoverer) // recovers from panics
+	r.Use(router.reloadable(o.cfg, func(cfg config.Config) func(http.Handler) http.Handler {
+		return mw.RealIP(cfg.Server.TrustedProxyPrefixes())
+	}))
+	r.Use(mw.ClientCert)
+	r.Use(router.reloadable(o.cfg, func(cfg config.Config) func(http.Handler) http.Handler {
+		return mw.AccessLog(cfg.Logging.Access) // logs every request
+	}))
+	r.Use(mw.Recover()) // recovers from panics
+	r.Use(router.reloadable(o.cfg, func(cfg config.Config) func(http.Handler) http.Handler {
+		return mw.BodyLimit(cfg.Server.MaxBodySize)
+	}))
+	if len(o.versions) > 0 {
+		// Before the limiters, so negotiated versions get the policies of
+		// their /v<n> routes
+		r.Use(mw.Version(router.Versions()...))
+	}
+	if o.limiter != nil {
+		// Before the authenticators, so rejected credentials are counted
+		r.Use(o.limiter.AuthFailures)
+	}
+	if o.verifier != nil {
+		r.Use(mw.Authenticate(o.verifier))
+	}
+	if o.apiKeys != nil {
+		r.Use(router.reloadable(o.cfg, func(cfg config.Config) func(http.Handler) http.Handler {
+			return mw.APIKey(o.apiKeys, cfg.Auth.APIKeys)
+		}))
+	}
+	if o.limiter != nil {
+		r.Use(o.limiter.Middleware)
+	}
 
-	// Serve metrics at /metrics
-	r.Handle("/metrics", promhttp.Handler())
+	// Unknown routes and methods answer with problem documents too
+	r.NotFound(apperr.HandlerFunc(func(http.ResponseWriter, *http.Request) error {
+		return apperr.NotFound("No route matches the request path.")
+	}).ServeHTTP)
+	r.MethodNotAllowed(apperr.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) error {
+		return apperr.Newf(apperr.CodeMethodNotAllowed, "Method %s is not allowed for this path.", r.Method)
+	}).ServeHTTP)
 
-	// Serve the swagger documentation at /docs/index.html
-	r.Get("/docs", func(w http.ResponseWriter, r *http.Request) {
-		http.Redirect(w, r, "/docs/index.html", http.StatusMovedPermanently)
-	})
-	r.Get("/docs/*", httpSwagger.WrapHandler)
+	// Attach modules, then versions
+	b := moduleBuilder{checks: o.checks, registered: map[string]bool{}}
+	for _, m := range o.modules {
+		b.mount(r, m)
+	}
+	for _, v := range o.versions {
+		if !versionName.MatchString(v.Name) {
+			panic(fmt.Sprintf("http: invalid API version name %q", v.Name))
+		}
+		r.Route("/"+v.Name, func(r chi.Router) {
+			r.Use(mw.Deprecation(v.Deprecated, v.Sunset, v.Link))
+			for _, m := range v.Modules {
+				b.mount(r, m)
+			}
+		})
+	}
 
-	// Attach root route
-	r.Mount("/", routes.RootRoutes())
+	return router
+}
+
+// moduleBuilder mounts modules and registers their health checks, once per
+// name as a module may serve several versions.
+type moduleBuilder struct {
+	checks     *health.Registry
+	registered map[string]bool
+}
 
-	return r
+func (b moduleBuilder) mount(r chi.Router, m routes.Module) {
+	r.Route(m.Prefix(), func(r chi.Router) {
+		r.Use(m.Middlewares()...)
+		r.Mount("/", m.Routes())
+	})
+	if b.checks == nil {
+		return
+	}
+	for _, c := range m.HealthChecks() {
+		if !b.registered[c.Name] {
+			b.checks.Register(c.Name, c.Check, c.Options...)
+			b.registered[c.Name] = true
+		}
+	}
 }
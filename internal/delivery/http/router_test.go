@@ -1,17 +1,57 @@
 package http
 
 import (
-	"io"
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"template-go/internal/auth"
+	"template-go/internal/config"
+	mw "template-go/internal/delivery/http/middleware"
+	"template-go/internal/delivery/http/routes"
+	"template-go/internal/health"
+	"template-go/internal/ratelimit"
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
 )
 
-func TestRouter_MetricsEndpoint(t *testing.T) {
-	router := NewRouter("test-service")
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+var testConfig = config.Config{OTEL: config.OTELConfig{ServiceName: "test-service"}}
+
+func newTestRouter() *Router {
+	return NewRouter(WithConfig(testConfig), WithModules(routes.Root()))
+}
+
+func TestRouter_OperationalEndpointsNotPublic(t *testing.T) {
+	router := newTestRouter()
+
+	for _, path := range []string{"/metrics", "/docs/index.html", "/debug/pprof/"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected %s to be absent from the public router, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestRouter_RootRouteMounted(t *testing.T) {
+	router := newTestRouter()
 
-	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	rec := httptest.NewRecorder()
 
 	router.ServeHTTP(rec, req)
@@ -23,84 +63,303 @@ func TestRouter_MetricsEndpoint(t *testing.T) {
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		t.Fatalf("expected 200 OK, got %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusOK {
+		return // good
 	}
 
-	body, _ := io.ReadAll(resp.Body)
-	if !strings.Contains(string(body), "# HELP") {
-		t.Error("expected Prometheus metrics output")
+	if resp.StatusCode == http.StatusNotFound {
+		t.Fatal("expected root route to be mounted but got 404")
 	}
+
+	t.Fatalf("unexpected status code: %d", resp.StatusCode)
 }
 
-func TestRouter_DocsRedirect(t *testing.T) {
-	router := NewRouter("test-service")
+func TestRouter_RateLimited(t *testing.T) {
+	policy, err := config.ParseRateLimitPolicy("1/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter, err := ratelimit.New(config.RateLimitConfig{Default: policy}, ratelimit.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(WithConfig(testConfig), WithRateLimiter(limiter), WithModules(routes.Root()))
 
-	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
-	rec := httptest.NewRecorder()
+	var codes []int
+	for range 2 {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes = append(codes, rec.Code)
+	}
 
-	router.ServeHTTP(rec, req)
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("expected the second request to be rate limited, got %v", codes)
+	}
+}
 
-	resp := rec.Result()
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			t.Errorf("failed to close response body: %v", err)
-		}
-	}()
+func TestRouter_Update(t *testing.T) {
+	cfg := testConfig
+	cfg.Server.MaxBodySize = 4
+	router := NewRouter(WithConfig(cfg), WithModules(routes.Root()))
 
-	if resp.StatusCode != http.StatusMovedPermanently {
-		t.Fatalf("expected 301 redirect, got %d", resp.StatusCode)
+	post := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/nope", strings.NewReader("too large")))
+		return rec.Code
 	}
+	before := post()
+	cfg.Server.MaxBodySize = 0
+	router.Update(cfg)
 
-	location := resp.Header.Get("Location")
-	if location != "/docs/index.html" {
-		t.Fatalf("expected redirect to /docs/index.html, got %s", location)
+	if before != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 before the update, got %d", before)
+	}
+	if got := post(); got != http.StatusNotFound {
+		t.Errorf("expected the body limit to be lifted by the update, got %d", got)
 	}
 }
 
-func TestRouter_SwaggerHandler(t *testing.T) {
-	router := NewRouter("test-service")
+func TestRouter_InvalidBearerToken(t *testing.T) {
+	verifier, err := auth.NewVerifier(config.AuthConfig{Algorithms: []string{"HS256"}, HMACSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(WithConfig(testConfig), WithVerifier(verifier), WithModules(routes.Root()))
 
-	req := httptest.NewRequest(http.MethodGet, "/docs/index.html", nil)
-	rec := httptest.NewRecorder()
+	anonymous := httptest.NewRecorder()
+	router.ServeHTTP(anonymous, httptest.NewRequest(http.MethodGet, "/", nil))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer invalid")
+	invalid := httptest.NewRecorder()
+	router.ServeHTTP(invalid, req)
 
-	router.ServeHTTP(rec, req)
+	if anonymous.Code != http.StatusOK {
+		t.Errorf("expected anonymous requests to pass, got %d", anonymous.Code)
+	}
+	if invalid.Code != http.StatusUnauthorized {
+		t.Errorf("expected an invalid token to be rejected, got %d", invalid.Code)
+	}
+}
 
-	resp := rec.Result()
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			t.Errorf("failed to close response body: %v", err)
+func TestRouter_AuthFailuresRateLimited(t *testing.T) {
+	policy, err := config.ParseRateLimitPolicy("2/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter, err := ratelimit.New(config.RateLimitConfig{AuthFailures: policy}, ratelimit.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := auth.NewVerifier(config.AuthConfig{Algorithms: []string{"HS256"}, HMACSecret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := auth.NewFileKeyStore(t.TempDir() + "/keys.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig
+	cfg.Auth.APIKeys = config.APIKeyConfig{Enabled: true, Header: "X-API-Key"}
+	router := NewRouter(WithConfig(cfg), WithRateLimiter(limiter), WithVerifier(verifier),
+		WithAPIKeys(auth.NewAPIKeys(store, time.Minute)), WithModules(routes.Root()))
+
+	var codes []int
+	for _, header := range []string{"Authorization", "X-API-Key", "Authorization"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(header, "Bearer guess")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}
+	if !slices.Equal(codes, want) {
+		t.Errorf("expected guessed tokens and API keys to be rate limited, got %v", codes)
+	}
+}
+
+// ordersModule is a module mounted at /orders with a header middleware and
+// a health check.
+type ordersModule struct{}
+
+func (ordersModule) Prefix() string { return "/orders" }
+
+func (ordersModule) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("order " + chi.URLParam(r, "id")))
+	})
+	return r
+}
+
+func (ordersModule) Middlewares() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Module", "orders")
+			next.ServeHTTP(w, r)
+		})
+	}}
+}
+
+func (ordersModule) HealthChecks() []routes.HealthCheck {
+	return []routes.HealthCheck{{Name: "orders-db", Check: func(context.Context) error { return nil }}}
+}
+
+func (ordersModule) SwaggerTags() []string { return []string{"Orders"} }
+
+func TestRouter_Modules(t *testing.T) {
+	checks := health.NewRegistry()
+	router := NewRouter(WithConfig(testConfig), WithHealthChecks(checks), WithModules(routes.Root(), ordersModule{}))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+		wantHeader string
+	}{
+		{"/", http.StatusOK, "Hello, World!", ""},
+		{"/orders/42", http.StatusOK, "order 42", "orders"},
+		{"/orders/42/items", http.StatusNotFound, "", "orders"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected %d, got %d", tt.path, tt.wantStatus, rec.Code)
 		}
-	}()
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: expected body %q, got %q", tt.path, tt.wantBody, rec.Body.String())
+		}
+		if got := rec.Header().Get("X-Module"); got != tt.wantHeader {
+			t.Errorf("%s: expected X-Module %q, got %q", tt.path, tt.wantHeader, got)
+		}
+		if rec.Code == http.StatusNotFound && rec.Header().Get("Content-Type") != apperr.ContentType {
+			t.Errorf("%s: expected a problem document, got %q", tt.path, rec.Header().Get("Content-Type"))
+		}
+	}
 
-	// We don't expect 404 because httpSwagger.WrapHandler should be attached
-	if resp.StatusCode == http.StatusNotFound {
-		t.Fatal("swagger route returned 404; is httpSwagger.WrapHandler configured correctly?")
+	report := checks.Run(t.Context(), 0)
+	if _, ok := report.Checks["orders-db"]; !ok {
+		t.Errorf("expected the module health check to be registered, got %v", report.Checks)
+	}
+	if tags := router.SwaggerTags(); !slices.Equal(tags, []string{"Root", "Orders"}) {
+		t.Errorf("expected the modules' swagger tags, got %v", tags)
 	}
 }
 
-func TestRouter_RootRouteMounted(t *testing.T) {
-	router := NewRouter("test-service")
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
 
-	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	NewRouter(WithConfig(testConfig), WithModules(ordersModule{})).ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/orders/42", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != apperr.ContentType {
+		t.Errorf("expected a problem document, got %q", got)
+	}
+}
+
+func TestRouter_NoModules(t *testing.T) {
 	rec := httptest.NewRecorder()
 
-	router.ServeHTTP(rec, req)
+	NewRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
 
-	resp := rec.Result()
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			t.Errorf("failed to close response body: %v", err)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without modules, got %d", rec.Code)
+	}
+}
+
+// ordersV2Module serves the v2 shape of /orders.
+type ordersV2Module struct{ ordersModule }
+
+func (ordersV2Module) Routes() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"` + chi.URLParam(r, "id") + `"}`))
+	})
+	return r
+}
+
+func TestRouter_Versions(t *testing.T) {
+	checks := health.NewRegistry()
+	router := NewRouter(WithConfig(testConfig), WithHealthChecks(checks), WithModules(routes.Root()), WithVersions(
+		Version{Name: "v1", Modules: []routes.Module{ordersModule{}}, Deprecated: time.Unix(1767225600, 0), Sunset: time.Unix(1782864000, 0)},
+		Version{Name: "v2", Modules: []routes.Module{ordersV2Module{}}},
+	))
+
+	tests := []struct {
+		name           string
+		path           string
+		version        string
+		wantBody       string
+		wantDeprecated bool
+	}{
+		{"v1 by path", "/v1/orders/7", "", "order 7", true},
+		{"v2 by path", "/v2/orders/7", "", `{"id":"7"}`, false},
+		{"v2 by header", "/orders/7", "v2", `{"id":"7"}`, false},
+		{"unversioned", "/", "", "Hello, World!", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.version != "" {
+			req.Header.Set(mw.VersionHeader, tt.version)
 		}
-	}()
+		rec := httptest.NewRecorder()
 
-	if resp.StatusCode == http.StatusOK {
-		return // good
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || rec.Body.String() != tt.wantBody {
+			t.Errorf("%s: expected 200 %q, got %d %q", tt.name, tt.wantBody, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get("Deprecation") != ""; got != tt.wantDeprecated {
+			t.Errorf("%s: expected deprecated=%v, got Deprecation %q", tt.name, tt.wantDeprecated, rec.Header().Get("Deprecation"))
+		}
 	}
 
-	if resp.StatusCode == http.StatusNotFound {
-		t.Fatal("expected root route to be mounted but got 404")
+	if !slices.Equal(router.Versions(), []string{"v1", "v2"}) {
+		t.Errorf("expected versions v1 and v2, got %v", router.Versions())
 	}
+	if report := checks.Run(t.Context(), 0); len(report.Checks) != 1 {
+		t.Errorf("expected the shared health check to be registered once, got %v", report.Checks)
+	}
+}
 
-	t.Fatalf("unexpected status code: %d", resp.StatusCode)
+func TestRouter_VersionedRateLimits(t *testing.T) {
+	policy, err := config.ParseRateLimitPolicy("1/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter, err := ratelimit.New(config.RateLimitConfig{
+		Routes: map[string]config.RateLimitPolicy{"/v2/orders/{id}": policy},
+	}, ratelimit.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := NewRouter(WithConfig(testConfig), WithRateLimiter(limiter), WithVersions(
+		Version{Name: "v2", Modules: []routes.Module{ordersV2Module{}}},
+	))
+
+	var codes []int
+	for _, path := range []string{"/v2/orders/7", "/orders/7"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(mw.VersionHeader, "v2")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("expected the header-negotiated request to share the /v2 policy, got %v", codes)
+	}
+}
+
+func TestRouter_InvalidVersionName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected NewRouter to panic on an invalid version name")
+		}
+	}()
+	NewRouter(WithVersions(Version{Name: "2024-01"}))
 }
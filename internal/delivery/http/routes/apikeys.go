@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"template-go/internal/auth"
+	"template-go/internal/delivery/http/bind"
+	"template-go/pkg/apperr"
+)
+
+// APIKeyRoutes serves the admin endpoints that list, mint and revoke API
+// keys. They are mounted on the admin listener only.
+func APIKeyRoutes(keys *auth.APIKeys) http.Handler {
+	h := apiKeyHandler{keys: keys}
+	r := chi.NewRouter()
+	r.Method(http.MethodGet, "/", apperr.HandlerFunc(h.list))
+	r.Method(http.MethodPost, "/", apperr.HandlerFunc(h.mint))
+	r.Method(http.MethodDelete, "/{id}", apperr.HandlerFunc(h.revoke))
+	return r
+}
+
+type apiKeyHandler struct {
+	keys *auth.APIKeys
+}
+
+// MintAPIKeyRequest describes the key to issue.
+type MintAPIKeyRequest struct {
+	Name     string   `json:"name" validate:"required,max=200" example:"edge-gateway-eu"`
+	Scopes   []string `json:"scopes" validate:"dive,min=1,excludesall= " example:"items:read"`
+	TenantID string   `json:"tenant_id" validate:"max=200" example:"acme"`
+	// ExpiresAt is optional; keys without it never expire.
+	ExpiresAt *time.Time `json:"expires_at"`
+} // @name MintAPIKeyRequest
+
+// MintAPIKeyResponse is the issued key. Key is shown only once.
+type MintAPIKeyResponse struct {
+	Key string `json:"key" example:"tg_3f9a1c2b7d4e_q7Xc..."`
+	auth.APIKey
+} // @name MintAPIKeyResponse
+
+// @Summary List API keys
+// @Tags Admin
+// @Produce json
+// @Success 200 {array} auth.APIKey
+// @Failure 401 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
+// @Router /admin/api-keys [get]
+func (h apiKeyHandler) list(w http.ResponseWriter, r *http.Request) error {
+	keys, err := h.keys.List(r.Context())
+	if err != nil {
+		return err
+	}
+	if keys == nil {
+		keys = []auth.APIKey{}
+	}
+	return writeJSON(w, http.StatusOK, keys)
+}
+
+// @Summary Mint an API key
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param request body MintAPIKeyRequest true "Key to issue"
+// @Success 201 {object} MintAPIKeyResponse
+// @Failure 401 {object} apperr.Problem
+// @Failure 400 {object} apperr.Problem
+// @Failure 422 {object} apperr.Problem
+// @Router /admin/api-keys [post]
+func (h apiKeyHandler) mint(w http.ResponseWriter, r *http.Request) error {
+	var req MintAPIKeyRequest
+	if err := bind.JSON(r, &req); err != nil {
+		return err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return apperr.New(apperr.CodeValidation, "Request validation failed.").WithDetails([]bind.FieldError{
+			{Field: "expires_at", Code: "future", Message: "must be in the future"},
+		})
+	}
+	key, rec, err := h.keys.Mint(r.Context(), auth.MintRequest{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		TenantID:  req.TenantID,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, MintAPIKeyResponse{Key: key, APIKey: rec})
+}
+
+// @Summary Revoke an API key
+// @Tags Admin
+// @Param id path string true "Key ID"
+// @Success 204
+// @Failure 401 {object} apperr.Problem
+// @Failure 404 {object} apperr.Problem
+// @Router /admin/api-keys/{id} [delete]
+func (h apiKeyHandler) revoke(w http.ResponseWriter, r *http.Request) error {
+	err := h.keys.Revoke(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, auth.ErrKeyNotFound) {
+		return apperr.NotFound("No API key has this ID.")
+	}
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// writeJSON answers with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(body, '\n'))
+	return nil
+}
@@ -0,0 +1,144 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/auth"
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+func newAPIKeyRoutes(t *testing.T) (http.Handler, *auth.APIKeys) {
+	t.Helper()
+	logger.Init()
+	store, err := auth.NewFileKeyStore(filepath.Join(t.TempDir(), "api_keys.yaml"))
+	require.NoError(t, err)
+	keys := auth.NewAPIKeys(store, time.Minute)
+	return APIKeyRoutes(keys), keys
+}
+
+func serve(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIKeyRoutes_Lifecycle(t *testing.T) {
+	h, keys := newAPIKeyRoutes(t)
+
+	// Act: mint a key
+	rec := serve(h, http.MethodPost, "/", `{"name":"edge-eu","scopes":["items:read"],"tenant_id":"acme"}`)
+
+	// Assert: the plaintext key is returned once and authenticates
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var minted MintAPIKeyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &minted))
+	assert.NotContains(t, rec.Body.String(), "hash")
+	assert.Equal(t, "edge-eu", minted.Name)
+	_, err := keys.Authenticate(context.Background(), minted.Key)
+	require.NoError(t, err)
+
+	// Act: list keys
+	rec = serve(h, http.MethodGet, "/", "")
+
+	// Assert: the key is listed without its secret
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list []auth.APIKey
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, minted.ID, list[0].ID)
+	assert.NotContains(t, rec.Body.String(), minted.Key)
+
+	// Act: revoke it
+	rec = serve(h, http.MethodDelete, "/"+minted.ID, "")
+
+	// Assert: the key no longer authenticates
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	_, err = keys.Authenticate(context.Background(), minted.Key)
+	assert.Error(t, err)
+}
+
+func TestAPIKeyRoutes_Errors(t *testing.T) {
+	h, _ := newAPIKeyRoutes(t)
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		wantCode apperr.Code
+	}{
+		{"missing name", http.MethodPost, "/", `{"scopes":["a"]}`, apperr.CodeValidation},
+		{"scope with space", http.MethodPost, "/", `{"name":"k","scopes":["a b"]}`, apperr.CodeValidation},
+		{"expiry in the past", http.MethodPost, "/", `{"name":"k","expires_at":"` + past + `"}`, apperr.CodeValidation},
+		{"unknown key", http.MethodDelete, "/0123456789ab", "", apperr.CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, tt.method, tt.target, tt.body)
+
+			var p apperr.Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+			assert.Equal(t, tt.wantCode, p.Code)
+		})
+	}
+}
+
+// brokenStore is a KeyStore whose every call fails.
+type brokenStore struct{ auth.KeyStore }
+
+func (brokenStore) List(context.Context) ([]auth.APIKey, error) { return nil, errors.New("disk full") }
+
+func (brokenStore) Create(context.Context, auth.APIKey) error { return errors.New("disk full") }
+
+func (brokenStore) Revoke(context.Context, string, time.Time) error { return errors.New("disk full") }
+
+func TestAPIKeyRoutes_StoreFailures(t *testing.T) {
+	logger.Init()
+	h := APIKeyRoutes(auth.NewAPIKeys(brokenStore{}, time.Minute))
+
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"list":   serve(h, http.MethodGet, "/", ""),
+		"mint":   serve(h, http.MethodPost, "/", `{"name":"k"}`),
+		"revoke": serve(h, http.MethodDelete, "/0123456789ab", ""),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "disk full")
+		})
+	}
+}
+
+func TestWriteJSON_MarshalError(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	err := writeJSON(rec, http.StatusCreated, make(chan int))
+
+	assert.Error(t, err)
+	assert.Empty(t, rec.Header().Get("Content-Type"), "nothing is written, so the error can still be served")
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestAPIKeyRoutes_ListEmpty(t *testing.T) {
+	h, _ := newAPIKeyRoutes(t)
+
+	rec := serve(h, http.MethodGet, "/", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"net/http"
+
+	"template-go/internal/auth"
+	"template-go/internal/authz"
+	"template-go/internal/delivery/http/codec"
+)
+
+// mePolicy admits any authenticated caller.
+var mePolicy = authz.New("me.read")
+
+// Me describes the authenticated caller.
+type Me struct {
+	Subject  string   `json:"subject" example:"user-1"`
+	TenantID string   `json:"tenant_id,omitempty" example:"acme"`
+	Scopes   []string `json:"scopes" example:"items:read"`
+	Roles    []string `json:"roles" example:"admin"`
+} // @name Me
+
+// @Summary Describe the authenticated caller
+// @Tags Root
+// @Produce json,application/cbor,application/msgpack
+// @Success 200 {object} Me
+// @Failure 401 {object} apperr.Problem
+// @Failure 406 {object} apperr.Problem
+// @Router /me [get]
+func me(w http.ResponseWriter, r *http.Request) error {
+	c, _ := auth.FromContext(r.Context())
+	return codec.Render(w, r, http.StatusOK, Me{
+		Subject:  c.Subject,
+		TenantID: c.TenantID,
+		Scopes:   append([]string{}, c.Scopes()...),
+		Roles:    append([]string{}, c.Roles...),
+	})
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/auth"
+	"template-go/internal/delivery/http/codec"
+	"template-go/pkg/logger"
+)
+
+func TestRootRoutes_Me(t *testing.T) {
+	logger.Init()
+	claims := &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1"},
+		Scope:            "items:read items:write",
+		TenantID:         "acme",
+	}
+
+	// Act: call /me as the caller
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req = req.WithContext(auth.NewContext(req.Context(), claims))
+	rec := httptest.NewRecorder()
+	RootRoutes().ServeHTTP(rec, req)
+
+	// Assert: the caller is described
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var got Me
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, Me{Subject: "user-1", TenantID: "acme", Scopes: []string{"items:read", "items:write"}, Roles: []string{}}, got)
+}
+
+func TestRootRoutes_MeAnonymous(t *testing.T) {
+	logger.Init()
+	rec := httptest.NewRecorder()
+
+	RootRoutes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestRootRoutes_MeMessagePack(t *testing.T) {
+	logger.Init()
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1"}}))
+	rec := httptest.NewRecorder()
+
+	RootRoutes().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/msgpack", rec.Header().Get("Content-Type"))
+	var got Me
+	require.NoError(t, codec.MsgPack{}.Decode(rec.Body, &got))
+	assert.Equal(t, "user-1", got.Subject)
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"net/http"
+
+	"template-go/internal/health"
+)
+
+// Module is a self-contained part of the public API. Services register
+// their modules with the router builder instead of editing the router.
+type Module interface {
+	// Prefix is the path the module is mounted at, e.g. "/orders". Each
+	// module needs its own prefix; "/" mounts it at the root.
+	Prefix() string
+	// Routes serves the module's endpoints, relative to Prefix.
+	Routes() http.Handler
+	// Middlewares wrap every route of the module, after the router's own.
+	// /debug/policies only lists the authorization policies attached with
+	// authz.Use or authz.With, so attach them in Routes.
+	Middlewares() []func(http.Handler) http.Handler
+	// HealthChecks are registered with the service's health registry.
+	HealthChecks() []HealthCheck
+	// SwaggerTags are the @Tags of the module's operations. The admin
+	// listener only documents operations of registered modules.
+	SwaggerTags() []string
+}
+
+// HealthCheck is a named health check contributed by a module.
+type HealthCheck struct {
+	Name    string
+	Check   health.Check
+	Options []health.CheckOption
+}
+
+// Root returns the module serving RootRoutes at "/".
+func Root() Module {
+	return root{}
+}
+
+type root struct{}
+
+func (root) Prefix() string                                 { return "/" }
+func (root) Routes() http.Handler                           { return RootRoutes() }
+func (root) Middlewares() []func(http.Handler) http.Handler { return nil }
+func (root) HealthChecks() []HealthCheck                    { return nil }
+func (root) SwaggerTags() []string                          { return []string{"Root"} }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRoot(t *testing.T) {
+	m := Root()
+
+	if m.Prefix() != "/" {
+		t.Errorf("expected prefix /, got %q", m.Prefix())
+	}
+	if m.Middlewares() != nil || m.HealthChecks() != nil {
+		t.Errorf("expected no middlewares and health checks")
+	}
+	if tags := m.SwaggerTags(); !slices.Equal(tags, []string{"Root"}) {
+		t.Errorf("expected the Root tag, got %v", tags)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the root routes to answer 200, got %d", rec.Code)
+	}
+}
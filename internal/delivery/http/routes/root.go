@@ -1,28 +1,31 @@
 package routes
 
 import (
-	"github.com/go-chi/chi/v5"
-	"go.uber.org/zap"
 	"net/http"
-	"template-go/pkg/logger"
+	"template-go/internal/authz"
+	"template-go/internal/delivery/http/codec"
+	"template-go/pkg/apperr"
 )
 
+// RootRoutes serves the root endpoints. Routes that need a caller declare
+// an authz policy with authz.With, so /debug/policies can list it.
 func RootRoutes() http.Handler {
-	r := chi.NewRouter()
+	r := authz.NewRouter()
 	r.Get("/", helloWorld)
+	authz.With(r, mePolicy).Method(http.MethodGet, "/me", apperr.HandlerFunc(me))
 	return r
 }
 
 // @Summary Hello World endpoint
+// @Description Answers in the encoding named by Accept, text/plain by default.
 // @Tags Root
-// @Produce plain
+// @Produce plain,json,application/cbor,application/msgpack
 // @Success 200 {string} string "Hello, World!"
+// @Failure 406 {object} apperr.Problem
+// @Failure 500 {object} apperr.Problem
 // @Router / [get]
 func helloWorld(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain")
-	_, err := w.Write([]byte("Hello, World!"))
-	if err != nil {
-		logger.Error(r.Context(), "failed to write response", zap.Error(err))
+	if err := codec.Render(w, r, http.StatusOK, "Hello, World!"); err != nil {
+		apperr.Render(w, r, err)
 	}
-
 }
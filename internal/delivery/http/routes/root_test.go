@@ -101,3 +101,36 @@ func TestHelloWorld_WriteError(t *testing.T) {
 		t.Errorf("expected content-type 'text/plain', got '%s'", contentType)
 	}
 }
+
+func TestRootRoutes_HelloWorldNegotiated(t *testing.T) {
+	logger.Init()
+	tests := []struct {
+		accept      string
+		wantStatus  int
+		wantType    string
+		wantPayload string
+	}{
+		{"text/plain", http.StatusOK, "text/plain", "Hello, World!"},
+		{"application/json", http.StatusOK, "application/json", "\"Hello, World!\"\n"},
+		{"application/cbor", http.StatusOK, "application/cbor", "\x6dHello, World!"},
+		{"application/msgpack", http.StatusOK, "application/msgpack", "\xadHello, World!"},
+		{"application/xml", http.StatusNotAcceptable, "application/problem+json", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		rec := httptest.NewRecorder()
+
+		RootRoutes().ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: expected status %d, got %d", tt.accept, tt.wantStatus, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+			t.Errorf("%s: expected content-type %q, got %q", tt.accept, tt.wantType, got)
+		}
+		if tt.wantPayload != "" && rec.Body.String() != tt.wantPayload {
+			t.Errorf("%s: expected body %q, got %q", tt.accept, tt.wantPayload, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Package feature holds runtime feature toggles that can be flipped by a
+// configuration reload without restarting the service.
+package feature
+
+import (
+	"maps"
+	"sync/atomic"
+)
+
+var flags atomic.Pointer[map[string]bool]
+
+// Set replaces all feature toggles.
+func Set(toggles map[string]bool) {
+	m := maps.Clone(toggles)
+	flags.Store(&m)
+}
+
+// Enabled reports whether the named feature is switched on. Unknown
+// features are off.
+func Enabled(name string) bool {
+	m := flags.Load()
+	return m != nil && (*m)[name]
+}
+
+// All returns a copy of the current toggles.
+func All() map[string]bool {
+	m := flags.Load()
+	if m == nil {
+		return map[string]bool{}
+	}
+	return maps.Clone(*m)
+}
@@ -0,0 +1,28 @@
+package feature
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnabled(t *testing.T) {
+	defer Set(nil)
+
+	assert.False(t, Enabled("checkout"), "unset toggles are off")
+
+	toggles := map[string]bool{"checkout": true, "search": false}
+	Set(toggles)
+	toggles["search"] = true // callers' maps must not leak into the live set
+
+	assert.True(t, Enabled("checkout"))
+	assert.False(t, Enabled("search"))
+	assert.False(t, Enabled("unknown"))
+	assert.Equal(t, map[string]bool{"checkout": true, "search": false}, All())
+}
+
+func TestAll_Empty(t *testing.T) {
+	flags.Store(nil)
+
+	assert.Empty(t, All())
+}
@@ -0,0 +1,26 @@
+// Package filestamp detects changes to files on disk, for settings and
+// certificates that are reloaded by polling.
+package filestamp
+
+import (
+	"os"
+	"time"
+)
+
+// Stamp identifies a version of a file on disk.
+type Stamp struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Of returns the stamp of every path; unreadable files get the zero stamp.
+// Compare the results with slices.Equal.
+func Of(paths []string) []Stamp {
+	stamps := make([]Stamp, len(paths))
+	for i, path := range paths {
+		if fi, err := os.Stat(path); err == nil {
+			stamps[i] = Stamp{Size: fi.Size(), ModTime: fi.ModTime()}
+		}
+	}
+	return stamps
+}
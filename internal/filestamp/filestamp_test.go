@@ -0,0 +1,32 @@
+package filestamp
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	// GIVEN an existing file and a missing one
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("a: 1"), 0o600))
+	paths := []string{path, filepath.Join(t.TempDir(), "missing.yaml")}
+	before := Of(paths)
+
+	// WHEN the file is rewritten
+	require.NoError(t, os.WriteFile(path, []byte("a: 22"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	after := Of(paths)
+
+	// THEN its stamp changes and the missing file keeps the zero stamp
+	assert.Equal(t, int64(4), before[0].Size)
+	assert.Equal(t, int64(5), after[0].Size)
+	assert.False(t, slices.Equal(before, after))
+	assert.Equal(t, Stamp{}, after[1])
+	assert.True(t, slices.Equal(after, Of(paths)))
+}
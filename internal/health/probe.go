@@ -0,0 +1,39 @@
+// Package health reports whether the service is alive and able to take
+// traffic.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// Probe is a readiness flag. It starts out not ready; the server marks it
+// ready once it is listening and not ready again before it drains.
+type Probe struct {
+	ready atomic.Bool
+}
+
+// NewProbe returns a Probe that is not ready.
+func NewProbe() *Probe {
+	return &Probe{}
+}
+
+// SetReady updates the readiness state.
+func (p *Probe) SetReady(ready bool) {
+	p.ready.Store(ready)
+}
+
+// Ready reports whether the service should receive traffic.
+func (p *Probe) Ready() bool {
+	return p.ready.Load()
+}
+
+// Check implements Check so the probe can be registered as a readiness
+// check: it fails while the server is starting up or draining.
+func (p *Probe) Check(context.Context) error {
+	if !p.Ready() {
+		return errors.New("not accepting traffic")
+	}
+	return nil
+}
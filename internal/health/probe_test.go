@@ -0,0 +1,21 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbe(t *testing.T) {
+	p := NewProbe()
+
+	assert.False(t, p.Ready(), "a new probe is not ready")
+	assert.Error(t, p.Check(context.Background()))
+
+	p.SetReady(true)
+	assert.NoError(t, p.Check(context.Background()))
+
+	p.SetReady(false)
+	assert.EqualError(t, p.Check(context.Background()), "not accepting traffic")
+}
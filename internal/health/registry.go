@@ -0,0 +1,231 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Check reports the health of one dependency; a non-nil error means unhealthy.
+type Check func(ctx context.Context) error
+
+// Status is the outcome of a check or of a whole report.
+type Status string
+
+// Statuses, from best to worst. A failing non-critical check degrades the
+// report to StatusWarn; a failing critical check fails it.
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+)
+
+// Scope selects the probe a check contributes to.
+type Scope uint8
+
+// Scopes. Checks belong to ScopeReady unless registered with WithScope.
+const (
+	ScopeLive Scope = 1 << iota
+	ScopeReady
+)
+
+// defaultTimeout bounds checks registered without WithTimeout.
+const defaultTimeout = 5 * time.Second
+
+// CheckOption customises a registered check.
+type CheckOption func(*check)
+
+// WithTimeout bounds a single run of the check.
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) { c.timeout = d }
+}
+
+// WithCritical controls whether a failure fails the probe (true, the
+// default) or only degrades it to "warn".
+func WithCritical(critical bool) CheckOption {
+	return func(c *check) { c.critical = critical }
+}
+
+// WithInterval caches the result for d so frequent probes do not hammer the
+// dependency. Zero runs the check on every request.
+func WithInterval(d time.Duration) CheckOption {
+	return func(c *check) { c.interval = d }
+}
+
+// WithScope selects the probes the check contributes to, e.g.
+// ScopeLive|ScopeReady. /healthz always runs every check.
+func WithScope(s Scope) CheckOption {
+	return func(c *check) { c.scope = s }
+}
+
+// check is a registered Check with its settings and cached result.
+type check struct {
+	name     string
+	fn       Check
+	timeout  time.Duration
+	critical bool
+	interval time.Duration
+	scope    Scope
+
+	mu   sync.Mutex // serialises runs and guards last
+	last *Result
+}
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status    Status    `json:"status"`
+	Critical  bool      `json:"critical"`
+	LatencyMS float64   `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report aggregates the results of the checks run for one probe.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Registry holds named checks and serves them as probe endpoints.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []*check
+
+	now      func() time.Time
+	status   metric.Int64Gauge
+	duration metric.Float64Histogram
+}
+
+// NewRegistry returns an empty registry whose results are recorded with the
+// global OpenTelemetry meter provider.
+func NewRegistry() *Registry {
+	meter := otel.Meter("template-go/internal/health")
+	status, _ := meter.Int64Gauge("health.check.status",
+		metric.WithDescription("Result of the last health check run: 1 healthy, 0 unhealthy."))
+	duration, _ := meter.Float64Histogram("health.check.duration",
+		metric.WithDescription("Duration of health check runs."), metric.WithUnit("s"))
+	return &Registry{now: time.Now, status: status, duration: duration}
+}
+
+// Register adds a check. It panics if name is already registered, as that
+// is a programming error.
+func (r *Registry) Register(name string, fn Check, opts ...CheckOption) {
+	c := &check{name: name, fn: fn, timeout: defaultTimeout, critical: true, scope: ScopeReady}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checks {
+		if existing.name == name {
+			panic(fmt.Sprintf("health: check %q registered twice", name))
+		}
+	}
+	r.checks = append(r.checks, c)
+}
+
+// Run runs, concurrently, every check in scope (or every check when scope is
+// zero) and aggregates their results.
+func (r *Registry) Run(ctx context.Context, scope Scope) Report {
+	r.mu.RLock()
+	var selected []*check
+	for _, c := range r.checks {
+		if scope == 0 || c.scope&scope != 0 {
+			selected = append(selected, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(selected))
+	var wg sync.WaitGroup
+	for i, c := range selected {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.result(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusPass, Checks: make(map[string]Result, len(selected))}
+	for i, c := range selected {
+		res := results[i]
+		report.Checks[c.name] = res
+		switch {
+		case res.Status == StatusPass:
+		case c.critical:
+			report.Status = StatusFail
+		case report.Status == StatusPass:
+			report.Status = StatusWarn
+		}
+	}
+	return report
+}
+
+// result returns the cached result of c if it is fresh, or runs it.
+func (r *Registry) result(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && c.interval > 0 && r.now().Sub(c.last.CheckedAt) < c.interval {
+		return *c.last
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := r.now()
+	err := runCheck(ctx, c.fn)
+	elapsed := r.now().Sub(start)
+
+	res := Result{Status: StatusPass, Critical: c.critical, LatencyMS: float64(elapsed.Microseconds()) / 1000, CheckedAt: start}
+	healthy := int64(1)
+	if err != nil {
+		res.Status, res.Error, healthy = StatusFail, err.Error(), 0
+	}
+	attrs := metric.WithAttributes(attribute.String("check", c.name), attribute.Bool("critical", c.critical))
+	r.status.Record(ctx, healthy, attrs)
+	r.duration.Record(ctx, elapsed.Seconds(), attrs)
+
+	c.last = &res
+	return res
+}
+
+// runCheck calls fn, giving up when ctx is done even if fn ignores it.
+func runCheck(ctx context.Context, fn Check) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+}
+
+// Handler serves the report for scope as JSON: 200 for pass and warn, 503
+// for fail. Scope zero runs every check.
+func (r *Registry) Handler(scope Scope) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context(), scope)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status == StatusFail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
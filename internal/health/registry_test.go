@@ -0,0 +1,197 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func pass(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("connection refused") }
+
+func TestRegistry_Aggregation(t *testing.T) {
+	tests := []struct {
+		name       string
+		register   func(r *Registry)
+		wantStatus Status
+	}{
+		{"no checks", func(r *Registry) {}, StatusPass},
+		{"all passing", func(r *Registry) {
+			r.Register("db", pass)
+			r.Register("cache", pass)
+		}, StatusPass},
+		{"non-critical failure", func(r *Registry) {
+			r.Register("db", pass)
+			r.Register("cache", fail, WithCritical(false))
+		}, StatusWarn},
+		{"critical failure", func(r *Registry) {
+			r.Register("db", fail)
+			r.Register("cache", fail, WithCritical(false))
+		}, StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			tt.register(r)
+
+			report := r.Run(context.Background(), ScopeReady)
+
+			assert.Equal(t, tt.wantStatus, report.Status)
+		})
+	}
+}
+
+func TestRegistry_Scopes(t *testing.T) {
+	// GIVEN a liveness-only, a readiness-only and a shared check
+	r := NewRegistry()
+	r.Register("deadlock", pass, WithScope(ScopeLive))
+	r.Register("db", pass)
+	r.Register("disk", pass, WithScope(ScopeLive|ScopeReady))
+
+	// WHEN each probe runs
+	live := r.Run(context.Background(), ScopeLive)
+	ready := r.Run(context.Background(), ScopeReady)
+	all := r.Run(context.Background(), 0)
+
+	// THEN each sees only its checks
+	assert.ElementsMatch(t, []string{"deadlock", "disk"}, slices.Collect(maps.Keys(live.Checks)))
+	assert.ElementsMatch(t, []string{"db", "disk"}, slices.Collect(maps.Keys(ready.Checks)))
+	assert.ElementsMatch(t, []string{"deadlock", "db", "disk"}, slices.Collect(maps.Keys(all.Checks)))
+}
+
+func TestRegistry_ResultDetails(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", fail, WithCritical(false))
+
+	res := r.Run(context.Background(), ScopeReady).Checks["db"]
+
+	assert.Equal(t, StatusFail, res.Status)
+	assert.False(t, res.Critical)
+	assert.Equal(t, "connection refused", res.Error)
+	assert.False(t, res.CheckedAt.IsZero())
+	assert.GreaterOrEqual(t, res.LatencyMS, 0.0)
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	// GIVEN a check that ignores its context
+	block := make(chan struct{})
+	defer close(block)
+	r := NewRegistry()
+	r.Register("stuck", func(context.Context) error { <-block; return nil }, WithTimeout(10*time.Millisecond))
+
+	// WHEN it runs
+	res := r.Run(context.Background(), ScopeReady).Checks["stuck"]
+
+	// THEN it fails once the timeout expires
+	assert.Equal(t, StatusFail, res.Status)
+	assert.Contains(t, res.Error, "timed out")
+}
+
+func TestRegistry_Panic(t *testing.T) {
+	r := NewRegistry()
+	r.Register("buggy", func(context.Context) error { panic("boom") })
+
+	res := r.Run(context.Background(), ScopeReady).Checks["buggy"]
+
+	assert.Equal(t, StatusFail, res.Status)
+	assert.Equal(t, "check panicked: boom", res.Error)
+}
+
+func TestRegistry_Caching(t *testing.T) {
+	// GIVEN a check cached for a minute
+	var calls atomic.Int32
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewRegistry()
+	r.now = func() time.Time { return now }
+	r.Register("db", func(context.Context) error { calls.Add(1); return nil }, WithInterval(time.Minute))
+
+	// WHEN probes run within and after the interval
+	r.Run(context.Background(), ScopeReady)
+	now = now.Add(30 * time.Second)
+	r.Run(context.Background(), ScopeReady)
+	assert.Equal(t, int32(1), calls.Load(), "result within the interval is cached")
+	now = now.Add(time.Minute)
+	r.Run(context.Background(), ScopeReady)
+
+	// THEN the check only reruns once the cached result is stale
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRegistry_RegisterTwicePanics(t *testing.T) {
+	r := NewRegistry()
+	r.Register("db", pass)
+
+	assert.Panics(t, func() { r.Register("db", pass) })
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	tests := []struct {
+		name     string
+		check    Check
+		critical bool
+		wantCode int
+	}{
+		{"pass", pass, true, http.StatusOK},
+		{"warn", fail, false, http.StatusOK},
+		{"fail", fail, true, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			r.Register("db", tt.check, WithCritical(tt.critical))
+			rec := httptest.NewRecorder()
+
+			r.Handler(ScopeReady).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, Status(tt.name), report.Status)
+			assert.Contains(t, report.Checks, "db")
+		})
+	}
+}
+
+func TestRegistry_Metrics(t *testing.T) {
+	// GIVEN a registry recording to a manual reader
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(prev)
+	r := NewRegistry()
+	r.Register("db", fail)
+
+	// WHEN the check runs
+	r.Run(context.Background(), ScopeReady)
+
+	// THEN its status and duration are exported
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	byName := map[string]metricdata.Metrics{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+	gauge, ok := byName["health.check.status"].Data.(metricdata.Gauge[int64])
+	require.True(t, ok, "status gauge is exported")
+	require.Len(t, gauge.DataPoints, 1)
+	assert.Equal(t, int64(0), gauge.DataPoints[0].Value)
+	name, _ := gauge.DataPoints[0].Attributes.Value("check")
+	assert.Equal(t, "db", name.AsString())
+	assert.Contains(t, byName, "health.check.duration")
+}
@@ -0,0 +1,24 @@
+package otel
+
+import (
+	"context"
+
+	"template-go/internal/app"
+	"template-go/internal/config"
+)
+
+// NewComponent returns an app.Component named "otel" that initialises
+// tracing and metrics on Start and flushes and shuts them down on Stop.
+func NewComponent(cfg config.Config) app.Component {
+	var shutdown func(context.Context) error
+	return app.Func("otel",
+		func(ctx context.Context) error {
+			var err error
+			shutdown, err = InitOtel(ctx, cfg)
+			return err
+		},
+		func(ctx context.Context) error {
+			return shutdown(ctx)
+		},
+	)
+}
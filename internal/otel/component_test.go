@@ -0,0 +1,27 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"template-go/internal/config"
+)
+
+func TestNewComponent(t *testing.T) {
+	// GIVEN an otel component with export disabled
+	c := NewComponent(config.Config{OTEL: config.OTELConfig{ServiceName: "test", Exporter: "none"}})
+
+	// WHEN it is started and stopped
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	err := c.Stop(context.Background())
+
+	// THEN both succeed
+	if c.Name() != "otel" {
+		t.Errorf("expected name otel, got %q", c.Name())
+	}
+	if err != nil {
+		t.Fatalf("unexpected stop error: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package otel
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor for OTLP/gRPC
+
+	"template-go/internal/config"
+)
+
+// newSpanExporter builds the trace exporter selected by cfg.OTEL.Exporter.
+// A nil exporter with a nil error means tracing export is disabled.
+func newSpanExporter(ctx context.Context, cfg config.Config) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(cfg.OTEL.Exporter) {
+	case "", config.ExporterOTLP, config.ExporterOTLPGRPC:
+		opts, err := grpcOptions(cfg)
+		if err != nil {
+			return nil, err
+		}
+		exp, err := newTraceExporter(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize OTLP trace exporter: %w", err)
+		}
+		return exp, nil
+	case config.ExporterOTLPHTTP:
+		opts, err := httpOptions(cfg)
+		if err != nil {
+			return nil, err
+		}
+		exp, err := newHTTPTraceExporter(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize OTLP/HTTP trace exporter: %w", err)
+		}
+		return exp, nil
+	case config.ExporterStdout:
+		exp, err := newStdoutExporter(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize stdout trace exporter: %w", err)
+		}
+		return exp, nil
+	case config.ExporterFile:
+		return newFileExporter(cfg.OTEL.FilePath)
+	case config.ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.OTEL.Exporter)
+	}
+}
+
+// grpcOptions maps the exporter settings in cfg to OTLP/gRPC options.
+func grpcOptions(cfg config.Config) ([]otlptracegrpc.Option, error) {
+	var opts []otlptracegrpc.Option
+	if cfg.OTEL.Endpoint != "" {
+		if strings.Contains(cfg.OTEL.Endpoint, "://") {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.OTEL.Endpoint))
+		} else {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTEL.Endpoint))
+		}
+	}
+	if len(cfg.OTEL.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.OTEL.Headers))
+	}
+	if cfg.OTEL.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	} else if cfg.OTEL.CertFile != "" {
+		tlsCfg, err := loadTLSConfig(cfg.OTEL.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+	}
+	if strings.EqualFold(cfg.OTEL.Compression, "gzip") {
+		opts = append(opts, otlptracegrpc.WithCompressor("gzip"))
+	}
+	if cfg.OTEL.Timeout > 0 {
+		opts = append(opts, otlptracegrpc.WithTimeout(cfg.OTEL.Timeout))
+	}
+	return opts, nil
+}
+
+// httpOptions maps the exporter settings in cfg to OTLP/HTTP options.
+func httpOptions(cfg config.Config) ([]otlptracehttp.Option, error) {
+	var opts []otlptracehttp.Option
+	if cfg.OTEL.Endpoint != "" {
+		if strings.Contains(cfg.OTEL.Endpoint, "://") {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTEL.Endpoint))
+		} else {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTEL.Endpoint))
+		}
+	}
+	if len(cfg.OTEL.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.OTEL.Headers))
+	}
+	if cfg.OTEL.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	} else if cfg.OTEL.CertFile != "" {
+		tlsCfg, err := loadTLSConfig(cfg.OTEL.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+	}
+	if strings.EqualFold(cfg.OTEL.Compression, "gzip") {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	} else {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.NoCompression))
+	}
+	if cfg.OTEL.Timeout > 0 {
+		opts = append(opts, otlptracehttp.WithTimeout(cfg.OTEL.Timeout))
+	}
+	return opts, nil
+}
+
+// loadTLSConfig builds a client TLS config trusting the CA bundle at path.
+func loadTLSConfig(path string) (*tls.Config, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OTLP certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// fileExporter writes one JSON-encoded span per line and closes the
+// underlying file on shutdown.
+type fileExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+// newFileExporter opens path for appending and wraps it in a stdout exporter.
+func newFileExporter(path string) (sdktrace.SpanExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+	exp, err := newStdoutExporter(stdouttrace.WithWriter(f))
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to initialize file trace exporter: %w", err)
+	}
+	return &fileExporter{Exporter: exp, file: f}, nil
+}
+
+// Shutdown flushes the exporter and closes the file.
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.Exporter.Shutdown(ctx), e.file.Close())
+}
@@ -0,0 +1,269 @@
+package otel
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"template-go/internal/config"
+)
+
+func TestNewSpanExporter_SelectsByName(t *testing.T) {
+	oldGRPC, oldHTTP, oldStdout := newTraceExporter, newHTTPTraceExporter, newStdoutExporter
+	defer func() {
+		newTraceExporter, newHTTPTraceExporter, newStdoutExporter = oldGRPC, oldHTTP, oldStdout
+	}()
+
+	var called string
+	newTraceExporter = func(ctx context.Context, opts ...otlptracegrpc.Option) (*otlptrace.Exporter, error) {
+		called = "grpc"
+		return otlptrace.NewUnstarted(nil), nil
+	}
+	newHTTPTraceExporter = func(ctx context.Context, opts ...otlptracehttp.Option) (*otlptrace.Exporter, error) {
+		called = "http"
+		return otlptrace.NewUnstarted(nil), nil
+	}
+	newStdoutExporter = func(opts ...stdouttrace.Option) (*stdouttrace.Exporter, error) {
+		called = "stdout"
+		return stdouttrace.New()
+	}
+
+	tests := []struct {
+		exporter string
+		want     string
+	}{
+		{"", "grpc"},
+		{"otlp", "grpc"},
+		{"otlp-grpc", "grpc"},
+		{"OTLP-HTTP", "http"},
+		{"stdout", "stdout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exporter, func(t *testing.T) {
+			called = ""
+			exp, err := newSpanExporter(context.Background(), config.Config{OTEL: config.OTELConfig{Exporter: tt.exporter}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if exp == nil {
+				t.Fatal("expected an exporter, got nil")
+			}
+			if called != tt.want {
+				t.Fatalf("expected %s constructor, got %q", tt.want, called)
+			}
+		})
+	}
+}
+
+func TestNewSpanExporter_None(t *testing.T) {
+	exp, err := newSpanExporter(context.Background(), config.Config{OTEL: config.OTELConfig{Exporter: "none"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp != nil {
+		t.Fatalf("expected no exporter, got %T", exp)
+	}
+}
+
+func TestNewSpanExporter_Unknown(t *testing.T) {
+	_, err := newSpanExporter(context.Background(), config.Config{OTEL: config.OTELConfig{Exporter: "zipkin"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown trace exporter") {
+		t.Fatalf("expected unknown exporter error, got: %v", err)
+	}
+}
+
+func TestNewSpanExporter_HTTPError(t *testing.T) {
+	old := newHTTPTraceExporter
+	defer func() { newHTTPTraceExporter = old }()
+	newHTTPTraceExporter = func(ctx context.Context, opts ...otlptracehttp.Option) (*otlptrace.Exporter, error) {
+		return nil, errors.New("http exporter fail")
+	}
+
+	_, err := newSpanExporter(context.Background(), config.Config{OTEL: config.OTELConfig{Exporter: "otlp-http"}})
+	if err == nil || !strings.Contains(err.Error(), "failed to initialize OTLP/HTTP trace exporter") {
+		t.Fatalf("expected OTLP/HTTP exporter error, got: %v", err)
+	}
+}
+
+func TestNewSpanExporter_FileWritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exp, err := newSpanExporter(context.Background(), config.Config{OTEL: config.OTELConfig{Exporter: "file", FilePath: path}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	_, span := tp.Tracer("test").Start(context.Background(), "first")
+	span.End()
+	_, span = tp.Tracer("test").Start(context.Background(), "second")
+	span.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read trace file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d: %s", len(lines), data)
+	}
+	if !strings.Contains(lines[0], `"Name":"first"`) {
+		t.Errorf("expected first span on first line, got %s", lines[0])
+	}
+}
+
+func TestNewSpanExporter_FileOpenError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "traces.jsonl")
+	_, err := newSpanExporter(context.Background(), config.Config{OTEL: config.OTELConfig{Exporter: "file", FilePath: path}})
+	if err == nil || !strings.Contains(err.Error(), "failed to open trace file") {
+		t.Fatalf("expected file open error, got: %v", err)
+	}
+}
+
+func TestExporterOptions_FromConfig(t *testing.T) {
+	cfg := config.Config{OTEL: config.OTELConfig{
+		Endpoint:    "collector:4317",
+		Headers:     map[string]string{"authorization": "Bearer x"},
+		Insecure:    true,
+		Compression: "gzip",
+		Timeout:     1,
+	}}
+
+	grpcOpts, err := grpcOptions(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(grpcOpts) != 5 {
+		t.Errorf("expected 5 gRPC options, got %d", len(grpcOpts))
+	}
+
+	httpOpts, err := httpOptions(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(httpOpts) != 5 {
+		t.Errorf("expected 5 HTTP options, got %d", len(httpOpts))
+	}
+}
+
+func TestExporterOptions_MissingCertificate(t *testing.T) {
+	cfg := config.Config{OTEL: config.OTELConfig{CertFile: filepath.Join(t.TempDir(), "ca.pem")}}
+
+	if _, err := grpcOptions(cfg); err == nil {
+		t.Error("expected gRPC options to fail on a missing certificate")
+	}
+	if _, err := httpOptions(cfg); err == nil {
+		t.Error("expected HTTP options to fail on a missing certificate")
+	}
+}
+
+func TestLoadTLSConfig_InvalidPEM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	_, err := loadTLSConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "no certificates found") {
+		t.Fatalf("expected invalid PEM error, got: %v", err)
+	}
+}
+
+func TestNewSpanExporter_Errors(t *testing.T) {
+	oldStdout := newStdoutExporter
+	defer func() { newStdoutExporter = oldStdout }()
+	newStdoutExporter = func(opts ...stdouttrace.Option) (*stdouttrace.Exporter, error) {
+		return nil, errors.New("stdout exporter fail")
+	}
+	missingCA := filepath.Join(t.TempDir(), "ca.pem")
+
+	tests := []struct {
+		name string
+		otel config.OTELConfig
+		want string
+	}{
+		{"grpc certificate", config.OTELConfig{Exporter: "otlp", CertFile: missingCA}, "failed to read OTLP certificate"},
+		{"http certificate", config.OTELConfig{Exporter: "otlp-http", CertFile: missingCA}, "failed to read OTLP certificate"},
+		{"stdout", config.OTELConfig{Exporter: "stdout"}, "failed to initialize stdout trace exporter"},
+		{"file", config.OTELConfig{Exporter: "file", FilePath: filepath.Join(t.TempDir(), "traces.jsonl")}, "failed to initialize file trace exporter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSpanExporter(context.Background(), config.Config{OTEL: tt.otel})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected %q error, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExporterOptions_Certificate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, selfSignedPEM(t), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	cfg := config.Config{OTEL: config.OTELConfig{CertFile: path}}
+
+	grpcOpts, err := grpcOptions(cfg)
+	if err != nil || len(grpcOpts) != 1 {
+		t.Errorf("expected 1 gRPC TLS option, got %d (%v)", len(grpcOpts), err)
+	}
+	httpOpts, err := httpOptions(cfg)
+	if err != nil || len(httpOpts) != 2 {
+		t.Errorf("expected HTTP TLS and compression options, got %d (%v)", len(httpOpts), err)
+	}
+}
+
+// selfSignedPEM returns a PEM encoded self-signed CA certificate.
+func selfSignedPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestExporterOptions_EndpointURL(t *testing.T) {
+	cfg := config.Config{OTEL: config.OTELConfig{Endpoint: "https://collector:4318/v1/traces"}}
+
+	grpcOpts, err := grpcOptions(cfg)
+	if err != nil || len(grpcOpts) != 1 {
+		t.Errorf("expected 1 gRPC endpoint option, got %d (%v)", len(grpcOpts), err)
+	}
+	httpOpts, err := httpOptions(cfg)
+	if err != nil || len(httpOpts) != 2 {
+		t.Errorf("expected HTTP endpoint and compression options, got %d (%v)", len(httpOpts), err)
+	}
+}
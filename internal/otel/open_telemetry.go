@@ -7,7 +7,9 @@ import (
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -27,10 +29,12 @@ type meterProvider interface {
 
 // --- Package-level constructors for testability ---
 var (
-	newResource       = resource.New
-	newTraceExporter  = otlptracegrpc.New
-	newPromExporter   = prometheus.New
-	newTracerProvider = func(opts ...sdktrace.TracerProviderOption) tracerProvider {
+	newResource          = resource.New
+	newTraceExporter     = otlptracegrpc.New
+	newHTTPTraceExporter = otlptracehttp.New
+	newStdoutExporter    = stdouttrace.New
+	newPromExporter      = prometheus.New
+	newTracerProvider    = func(opts ...sdktrace.TracerProviderOption) tracerProvider {
 		return sdktrace.NewTracerProvider(opts...)
 	}
 	newMeterProvider = func(opts ...metric.Option) meterProvider {
@@ -43,23 +47,35 @@ func InitOtel(ctx context.Context, cfg config.Config) (func(context.Context) err
 	res, err := newResource(
 		ctx,
 		resource.WithAttributes(
-			semconv.ServiceNameKey.String(cfg.OTELServiceName),
+			semconv.ServiceNameKey.String(cfg.OTEL.ServiceName),
+			semconv.DeploymentEnvironmentKey.String(cfg.Env),
 		),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	traceExporter, err := newTraceExporter(ctx)
+	traceExporter, err := newSpanExporter(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize OTLP trace exporter: %w", err)
+		return nil, err
 	}
 
-	// The type of `tp` is now our local `tracerProvider` interface
-	tp := newTracerProvider(
-		sdktrace.WithBatcher(traceExporter),
+	sampler.set(cfg.OTEL.SampleRatio)
+	tpOpts := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(res),
-	)
+		sdktrace.WithSampler(sampler),
+	}
+	switch {
+	case traceExporter == nil:
+	case cfg.IsDev():
+		// Export spans as soon as they end so they show up while debugging.
+		tpOpts = append(tpOpts, sdktrace.WithSyncer(traceExporter))
+	default:
+		tpOpts = append(tpOpts, sdktrace.WithBatcher(traceExporter))
+	}
+
+	// The type of `tp` is now our local `tracerProvider` interface
+	tp := newTracerProvider(tpOpts...)
 	// We need to cast back to the concrete type for the global setter
 	if realTP, ok := tp.(*sdktrace.TracerProvider); ok {
 		otel.SetTracerProvider(realTP)
@@ -28,7 +28,7 @@ func TestInitOtel_ResourceError(t *testing.T) {
 	}
 
 	// WHEN InitOtel is called
-	_, err := InitOtel(context.Background(), config.Config{OTELServiceName: "test"})
+	_, err := InitOtel(context.Background(), config.Config{OTEL: config.OTELConfig{ServiceName: "test"}})
 
 	// THEN the correct error is returned
 	if err == nil || !strings.Contains(err.Error(), "failed to create resource") {
@@ -45,7 +45,7 @@ func TestInitOtel_TraceExporterError(t *testing.T) {
 	}
 
 	// WHEN InitOtel is called
-	_, err := InitOtel(context.Background(), config.Config{OTELServiceName: "test"})
+	_, err := InitOtel(context.Background(), config.Config{OTEL: config.OTELConfig{ServiceName: "test"}})
 
 	// THEN the correct error is returned
 	if err == nil || !strings.Contains(err.Error(), "failed to initialize OTLP trace exporter") {
@@ -62,7 +62,7 @@ func TestInitOtel_MetricExporterError(t *testing.T) {
 	}
 
 	// WHEN InitOtel is called
-	_, err := InitOtel(context.Background(), config.Config{OTELServiceName: "test"})
+	_, err := InitOtel(context.Background(), config.Config{OTEL: config.OTELConfig{ServiceName: "test"}})
 
 	// THEN the correct error is returned
 	if err == nil || !strings.Contains(err.Error(), "failed to initialize Prometheus metric exporter") {
@@ -89,7 +89,7 @@ func TestInitOtel_SuccessfulInitAndShutdown(t *testing.T) {
 	}
 
 	// WHEN InitOtel is called and shutdown is executed
-	shutdown, err := InitOtel(context.Background(), config.Config{OTELServiceName: "test"})
+	shutdown, err := InitOtel(context.Background(), config.Config{OTEL: config.OTELConfig{ServiceName: "test"}})
 
 	// THEN initialization and shutdown succeed without errors
 	if err != nil {
@@ -131,7 +131,7 @@ func TestInitOtel_ShutdownTracerErrorOnly(t *testing.T) {
 	}
 
 	// WHEN the shutdown function is called
-	shutdown, err := InitOtel(context.Background(), config.Config{OTELServiceName: "test"})
+	shutdown, err := InitOtel(context.Background(), config.Config{OTEL: config.OTELConfig{ServiceName: "test"}})
 	if err != nil {
 		t.Fatalf("unexpected error during init: %v", err)
 	}
@@ -152,7 +152,7 @@ func TestInitOtel_ShutdownMeterErrorOnly(t *testing.T) {
 	}
 
 	// WHEN the shutdown function is called
-	shutdown, err := InitOtel(context.Background(), config.Config{OTELServiceName: "test"})
+	shutdown, err := InitOtel(context.Background(), config.Config{OTEL: config.OTELConfig{ServiceName: "test"}})
 	if err != nil {
 		t.Fatalf("unexpected error during init: %v", err)
 	}
@@ -180,7 +180,7 @@ func TestInitOtel_ShutdownBothError(t *testing.T) {
 	}
 
 	// WHEN the shutdown function is called
-	shutdown, err := InitOtel(context.Background(), config.Config{OTELServiceName: "test"})
+	shutdown, err := InitOtel(context.Background(), config.Config{OTEL: config.OTELConfig{ServiceName: "test"}})
 	if err != nil {
 		t.Fatalf("unexpected error during init: %v", err)
 	}
@@ -191,3 +191,45 @@ func TestInitOtel_ShutdownBothError(t *testing.T) {
 		t.Fatalf("expected both shutdown errors, got: %v", err)
 	}
 }
+
+func TestInitOtel_NoneExporter(t *testing.T) {
+	// GIVEN tracing export is disabled
+	cfg := config.Config{OTEL: config.OTELConfig{ServiceName: "test", Exporter: "none"}}
+
+	// WHEN InitOtel is called
+	shutdown, err := InitOtel(context.Background(), cfg)
+
+	// THEN initialization succeeds without dialing a collector
+	if err != nil {
+		t.Fatalf("unexpected error during InitOtel: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("expected no shutdown error, got: %v", err)
+	}
+}
+
+func TestInitOtel_DevProfileUsesSyncer(t *testing.T) {
+	// GIVEN the dev profile with the stdout exporter
+	var opts []sdktrace.TracerProviderOption
+	oldTracerProv := newTracerProvider
+	defer func() { newTracerProvider = oldTracerProv }()
+	newTracerProvider = func(o ...sdktrace.TracerProviderOption) tracerProvider {
+		opts = o
+		return sdktrace.NewTracerProvider(o...)
+	}
+	cfg := config.Config{Env: config.ProfileDev, OTEL: config.OTELConfig{ServiceName: "test", Exporter: "stdout", SampleRatio: 1}}
+
+	// WHEN InitOtel is called
+	shutdown, err := InitOtel(context.Background(), cfg)
+
+	// THEN it succeeds and registers resource, sampler and span processor
+	if err != nil {
+		t.Fatalf("unexpected error during InitOtel: %v", err)
+	}
+	if len(opts) != 3 {
+		t.Fatalf("expected 3 tracer provider options, got %d", len(opts))
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("expected no shutdown error, got: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package otel
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// ratioSampler is a parent-based trace ID ratio sampler whose ratio can be
+// changed while the tracer provider is running.
+type ratioSampler struct {
+	current atomic.Value // sdktrace.Sampler
+	ratio   atomic.Value // float64
+}
+
+// sampler is installed on the tracer provider by InitOtel.
+var sampler = newRatioSampler(1)
+
+// newRatioSampler returns a sampler that samples the given fraction of root traces.
+func newRatioSampler(ratio float64) *ratioSampler {
+	s := &ratioSampler{}
+	s.set(ratio)
+	return s
+}
+
+// set replaces the sampling ratio.
+func (s *ratioSampler) set(ratio float64) {
+	s.ratio.Store(ratio)
+	s.current.Store(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)))
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s *ratioSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return s.current.Load().(sdktrace.Sampler).ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler.
+func (s *ratioSampler) Description() string {
+	return fmt.Sprintf("DynamicRatio{%g}", s.ratio.Load().(float64))
+}
+
+// SetSampleRatio changes the fraction of new root traces that are sampled.
+// Child spans keep following their parent's decision.
+func SetSampleRatio(ratio float64) {
+	sampler.set(ratio)
+}
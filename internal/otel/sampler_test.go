@@ -0,0 +1,44 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sampleRoot asks s for a decision on a new root span.
+func sampleRoot(s sdktrace.Sampler) sdktrace.SamplingDecision {
+	traceID, _ := trace.TraceIDFromHex("8c3c1e95c9a0989f4e42a448557b4914")
+	return s.ShouldSample(sdktrace.SamplingParameters{
+		ParentContext: context.Background(),
+		TraceID:       traceID,
+		Name:          "root",
+	}).Decision
+}
+
+func TestRatioSampler_ChangesAtRuntime(t *testing.T) {
+	s := newRatioSampler(1)
+	if got := sampleRoot(s); got != sdktrace.RecordAndSample {
+		t.Fatalf("expected ratio 1 to sample, got %v", got)
+	}
+
+	s.set(0)
+	if got := sampleRoot(s); got != sdktrace.Drop {
+		t.Fatalf("expected ratio 0 to drop, got %v", got)
+	}
+	if s.Description() != "DynamicRatio{0}" {
+		t.Errorf("unexpected description %q", s.Description())
+	}
+}
+
+func TestSetSampleRatio_UpdatesGlobalSampler(t *testing.T) {
+	defer SetSampleRatio(1)
+
+	SetSampleRatio(0.25)
+
+	if s := sampler.Description(); s != "DynamicRatio{0.25}" {
+		t.Fatalf("expected global sampler ratio 0.25, got %q", s)
+	}
+}
@@ -0,0 +1,310 @@
+// Package ratelimit limits request rates per client with token bucket or
+// sliding window policies, keeping counters in memory or in Redis.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"template-go/internal/auth"
+	"template-go/internal/config"
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+// KeyFunc identifies the client a request is counted against. It returns
+// "" when the request carries no such identity, in which case the client
+// IP is used instead.
+type KeyFunc func(r *http.Request) string
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithKeyFunc registers fn under name so policies can select it with
+// key=<name>, e.g. to limit by an account taken from the caller's claims.
+// Derive keys from authenticated data: a value the client chooses freely
+// gives it a fresh quota per value.
+func WithKeyFunc(name string, fn KeyFunc) Option {
+	return func(l *Limiter) { l.keys[name] = fn }
+}
+
+// Policy names used for routes without their own and for failed
+// authentication.
+const (
+	defaultRoute       = "default"
+	authFailuresPolicy = "auth_failures"
+)
+
+// Limiter enforces the configured policies. Use Middleware to apply it.
+type Limiter struct {
+	store   Store
+	rules   atomic.Pointer[rules]
+	keys    map[string]KeyFunc
+	now     func() time.Time
+	limited metric.Int64Counter
+}
+
+// rules are the policies of a Limiter, replaced as a whole by Update.
+type rules struct {
+	def      config.RateLimitPolicy
+	failures config.RateLimitPolicy
+	routes   *chi.Mux
+	policies map[string]config.RateLimitPolicy
+}
+
+// New returns a Limiter applying cfg.Default, cfg.Routes and
+// cfg.AuthFailures with counters kept in store. It fails if a policy names an unknown key or a route
+// pattern is invalid.
+func New(cfg config.RateLimitConfig, store Store, opts ...Option) (*Limiter, error) {
+	l := &Limiter{
+		store: store,
+		keys: map[string]KeyFunc{
+			config.RateLimitKeyIP:     clientIP,
+			config.RateLimitKeyAPIKey: apiKeyID,
+			config.RateLimitKeyTenant: tenantID,
+		},
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.limited, _ = otel.Meter("template-go/internal/ratelimit").Int64Counter("http.server.rate_limited",
+		metric.WithDescription("Number of requests rejected by a rate limit."))
+
+	if err := l.Update(cfg); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Update replaces the policies with those of cfg, e.g. after a
+// configuration reload. The store is kept, so counters carry over; its
+// settings and cfg.Enabled only take effect through New. On error the
+// current policies stay in force.
+func (l *Limiter) Update(cfg config.RateLimitConfig) error {
+	rs, err := l.compile(cfg)
+	if err != nil {
+		return err
+	}
+	l.rules.Store(rs)
+	return nil
+}
+
+// compile checks the policies of cfg and indexes the route patterns.
+func (l *Limiter) compile(cfg config.RateLimitConfig) (rs *rules, err error) {
+	rs = &rules{
+		def:      cfg.Default,
+		failures: cfg.AuthFailures,
+		routes:   chi.NewRouter(),
+		policies: make(map[string]config.RateLimitPolicy, len(cfg.Routes)),
+	}
+	if err := l.checkKey(cfg.Default); err != nil {
+		return nil, fmt.Errorf("ratelimit: default policy: %w", err)
+	}
+	defer func() {
+		// chi panics on malformed patterns
+		if rec := recover(); rec != nil {
+			rs, err = nil, fmt.Errorf("ratelimit: routes: %v", rec)
+		}
+	}()
+	// The routes are only matched, never served.
+	noop := http.NotFoundHandler()
+	for route, p := range cfg.Routes {
+		if err := l.checkKey(p); err != nil {
+			return nil, fmt.Errorf("ratelimit: route %q: %w", route, err)
+		}
+		method, pattern := splitRoute(route)
+		if method == "" {
+			rs.routes.Handle(pattern, noop)
+		} else {
+			rs.routes.Method(method, pattern, noop)
+		}
+		rs.policies[route] = p
+	}
+	return rs, nil
+}
+
+// checkKey reports whether p's key can be extracted.
+func (l *Limiter) checkKey(p config.RateLimitPolicy) error {
+	if !p.Enabled() || strings.HasPrefix(p.Key, "header:") {
+		return nil
+	}
+	if _, ok := l.keys[p.Key]; !ok {
+		return fmt.Errorf("unknown key %q", p.Key)
+	}
+	return nil
+}
+
+// Middleware counts every request against the policy of its route and
+// answers 429 with a problem document once the limit is reached. RateLimit
+// headers are set on every limited route, plus Retry-After on rejections.
+// If the store fails the request is let through.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, p := l.policyFor(r)
+		if !p.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		res, err := l.store.Allow(ctx, name+":"+l.key(p.Key, r), p, l.now())
+		if err != nil {
+			logger.Warn(ctx, "rate limit store failed; allowing request", zap.Error(err), zap.String("policy", name))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+		h.Set("RateLimit-Policy", policyHeader(p))
+		if !res.Allowed {
+			l.reject(w, r, name, res, "Too many requests, retry later.")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthFailures counts the requests answered with 401 per client IP against
+// the auth failures policy. Register it before the authenticators: once a
+// client used up the policy its requests are rejected with 429 before its
+// credentials are checked, so they cannot be guessed faster than the policy
+// allows. If the store fails the request is let through.
+func (l *Limiter) AuthFailures(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := l.rules.Load().failures
+		if !p.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		key := authFailuresPolicy + ":" + config.RateLimitKeyIP + ":" + clientIP(r)
+		res, err := l.store.Peek(ctx, key, p, l.now())
+		if err != nil {
+			logger.Warn(ctx, "rate limit store failed; allowing request", zap.Error(err), zap.String("policy", authFailuresPolicy))
+		} else if !res.Allowed {
+			l.reject(w, r, authFailuresPolicy, res, "Too many failed authentication attempts, retry later.")
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if ww.Status() != http.StatusUnauthorized {
+			return
+		}
+		if _, err := l.store.Allow(ctx, key, p, l.now()); err != nil {
+			logger.Warn(ctx, "rate limit store failed; failed authentication not counted", zap.Error(err))
+		}
+	})
+}
+
+// reject answers 429 with a problem telling the client when to retry.
+func (l *Limiter) reject(w http.ResponseWriter, r *http.Request, policy string, res Result, msg string) {
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, seconds(res.RetryAfter))))
+	l.limited.Add(r.Context(), 1, metric.WithAttributes(attribute.String("policy", policy)))
+	apperr.Render(w, r, apperr.New(apperr.CodeRateLimited, msg))
+}
+
+// policyFor returns the name and policy of the route r targets. A
+// method-specific policy wins over one for the same pattern without a method.
+func (l *Limiter) policyFor(r *http.Request) (string, config.RateLimitPolicy) {
+	rs := l.rules.Load()
+	if len(rs.policies) > 0 {
+		if pattern := rs.routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+			if p, ok := rs.policies[r.Method+" "+pattern]; ok {
+				return r.Method + " " + pattern, p
+			}
+			if p, ok := rs.policies[pattern]; ok {
+				return pattern, p
+			}
+		}
+	}
+	return defaultRoute, rs.def
+}
+
+// key extracts the client identity named by name, falling back to the IP.
+func (l *Limiter) key(name string, r *http.Request) string {
+	fn, ok := l.keys[name]
+	if h, isHeader := strings.CutPrefix(name, "header:"); isHeader {
+		fn, ok = header(h), true
+	}
+	if ok {
+		if k := fn(r); k != "" {
+			return name + ":" + k
+		}
+	}
+	return config.RateLimitKeyIP + ":" + clientIP(r)
+}
+
+// splitRoute splits "POST /login" into its method and pattern.
+func splitRoute(route string) (method, pattern string) {
+	if m, rest, ok := strings.Cut(route, " "); ok {
+		return m, strings.TrimSpace(rest)
+	}
+	return "", route
+}
+
+// clientIP returns the address middleware.RealIP left in r.RemoteAddr,
+// without port.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// apiKeyID returns the ID of the API key the request was authenticated
+// with.
+func apiKeyID(r *http.Request) string {
+	if claims, ok := auth.FromContext(r.Context()); ok {
+		return claims.APIKeyID()
+	}
+	return ""
+}
+
+// tenantID returns the tenant of the authenticated caller.
+func tenantID(r *http.Request) string {
+	if claims, ok := auth.FromContext(r.Context()); ok {
+		return claims.TenantID
+	}
+	return ""
+}
+
+// header returns a KeyFunc reading the named request header. The header is
+// not verified, so it should be set by a trusted gateway.
+func header(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// policyHeader describes p in the RateLimit-Policy header syntax, e.g.
+// "100;w=60" or "10;w=1;burst=30".
+func policyHeader(p config.RateLimitPolicy) string {
+	s := strconv.Itoa(p.Requests) + ";w=" + strconv.Itoa(max(1, seconds(p.Window)))
+	if p.Algorithm != config.AlgorithmSlidingWindow && p.Burst != p.Requests {
+		s += ";burst=" + strconv.Itoa(p.Burst)
+	}
+	return s
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
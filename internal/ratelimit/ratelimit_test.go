@@ -0,0 +1,318 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"template-go/internal/auth"
+	"template-go/internal/config"
+	"template-go/pkg/apperr"
+	"template-go/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// limitedRouter serves GET /items, GET /items/{id} and POST /login behind l.
+func limitedRouter(l *Limiter) http.Handler {
+	r := chi.NewRouter()
+	r.Use(l.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.Get("/items", ok)
+	r.Get("/items/{id}", ok)
+	r.Post("/login", ok)
+	return r
+}
+
+func newLimiter(t *testing.T, cfg config.RateLimitConfig, opts ...Option) *Limiter {
+	t.Helper()
+	l, err := New(cfg, NewMemoryStore(), opts...)
+	require.NoError(t, err)
+	l.now = func() time.Time { return epoch }
+	return l
+}
+
+// do serves a request from ip with the given headers.
+func do(h http.Handler, method, path, ip string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLimiter_Headers(t *testing.T) {
+	// GIVEN a default policy of 2 requests per minute
+	h := limitedRouter(newLimiter(t, config.RateLimitConfig{Default: policy(t, "2/1m")}))
+
+	// WHEN a client sends three requests
+	first := do(h, http.MethodGet, "/items", "10.0.0.1")
+	do(h, http.MethodGet, "/items", "10.0.0.1")
+	third := do(h, http.MethodGet, "/items", "10.0.0.1")
+
+	// THEN allowed responses describe the quota
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", first.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", first.Header().Get("RateLimit-Policy"))
+	assert.Empty(t, first.Header().Get("Retry-After"))
+
+	// AND the rejection is a 429 problem telling the client when to retry
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	assert.Equal(t, "0", third.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", third.Header().Get("Retry-After"))
+	assert.Equal(t, apperr.ContentType, third.Header().Get("Content-Type"))
+	var body apperr.Problem
+	require.NoError(t, json.Unmarshal(third.Body.Bytes(), &body))
+	assert.Equal(t, apperr.CodeRateLimited, body.Code)
+
+	// AND other clients are unaffected
+	assert.Equal(t, http.StatusOK, do(h, http.MethodGet, "/items", "10.0.0.2").Code)
+}
+
+func TestLimiter_BurstPolicyHeader(t *testing.T) {
+	h := limitedRouter(newLimiter(t, config.RateLimitConfig{Default: policy(t, "10/1s burst=30")}))
+
+	rec := do(h, http.MethodGet, "/items", "10.0.0.1")
+
+	assert.Equal(t, "10;w=1;burst=30", rec.Header().Get("RateLimit-Policy"))
+}
+
+func TestLimiter_RemoteAddrWithoutPort(t *testing.T) {
+	h := limitedRouter(newLimiter(t, config.RateLimitConfig{Default: policy(t, "1/1m")}))
+	request := func(addr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, request("198.51.100.1"))
+
+	assert.Equal(t, http.StatusTooManyRequests, request("198.51.100.1:4321"), "addresses with and without port share a quota")
+}
+
+func TestLimiter_RoutePolicies(t *testing.T) {
+	// GIVEN a strict login policy, a relaxed item policy and no default
+	h := limitedRouter(newLimiter(t, config.RateLimitConfig{
+		Default: policy(t, "none"),
+		Routes: map[string]config.RateLimitPolicy{
+			"POST /login":  policy(t, "1/1m"),
+			"/items/{id}":  policy(t, "5/1s algorithm=sliding_window"),
+			"GET /items/*": policy(t, "none"),
+		},
+	}))
+
+	// WHEN each route is called
+	login := []int{do(h, http.MethodPost, "/login", "10.0.0.1").Code, do(h, http.MethodPost, "/login", "10.0.0.1").Code}
+	item := do(h, http.MethodGet, "/items/7", "10.0.0.1")
+	list := do(h, http.MethodGet, "/items", "10.0.0.1")
+
+	// THEN each is limited by its own policy
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, login)
+	assert.Equal(t, http.StatusOK, item.Code)
+	assert.Equal(t, "5;w=1", item.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "4", item.Header().Get("RateLimit-Remaining"))
+	assert.Empty(t, list.Header().Get("RateLimit-Limit"), "routes without a policy are not limited")
+}
+
+func TestLimiter_Update(t *testing.T) {
+	// GIVEN a limiter allowing 1 login per minute
+	l := newLimiter(t, config.RateLimitConfig{
+		Default: policy(t, "none"),
+		Routes:  map[string]config.RateLimitPolicy{"POST /login": policy(t, "1/1m")},
+	})
+	h := limitedRouter(l)
+	do(h, http.MethodPost, "/login", "10.0.0.1")
+
+	// WHEN the policies are reloaded, first with an invalid route
+	err := l.Update(config.RateLimitConfig{Routes: map[string]config.RateLimitPolicy{"/{id": policy(t, "1/1m")}})
+	blocked := do(h, http.MethodPost, "/login", "10.0.0.1").Code
+	require.NoError(t, l.Update(config.RateLimitConfig{
+		Default: policy(t, "none"),
+		Routes:  map[string]config.RateLimitPolicy{"/items/{id}": policy(t, "1/1m")},
+	}))
+
+	// THEN only the valid policies replace the running ones
+	assert.ErrorContains(t, err, "ratelimit: routes")
+	assert.Equal(t, http.StatusTooManyRequests, blocked)
+	assert.Equal(t, http.StatusOK, do(h, http.MethodPost, "/login", "10.0.0.1").Code)
+	assert.Equal(t, "1;w=60", do(h, http.MethodGet, "/items/7", "10.0.0.1").Header().Get("RateLimit-Policy"))
+}
+
+// authenticated stands in for the authenticators: it attaches claims for
+// the Test-Subject and Test-Tenant headers.
+func authenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sub := r.Header.Get("Test-Subject"); sub != "" {
+			claims := &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: sub}, TenantID: r.Header.Get("Test-Tenant")}
+			r = r.WithContext(auth.NewContext(r.Context(), claims))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestLimiter_Keys(t *testing.T) {
+	tests := []struct {
+		key     string
+		a, b    []string // headers of two requests from different IPs
+		sharing bool     // whether both requests count against one quota
+	}{
+		{key: "ip", a: nil, b: nil, sharing: false},
+		{key: "api_key", a: []string{"Test-Subject", "apikey:k1"}, b: []string{"Test-Subject", "apikey:k1"}, sharing: true},
+		{key: "api_key", a: []string{"Test-Subject", "apikey:k1"}, b: []string{"Test-Subject", "apikey:k2"}, sharing: false},
+		{key: "api_key", a: []string{"Test-Subject", "user-1"}, b: []string{"Test-Subject", "user-1"}, sharing: false},
+		{key: "tenant", a: []string{"Test-Subject", "u1", "Test-Tenant", "acme"}, b: []string{"Test-Subject", "u2", "Test-Tenant", "acme"}, sharing: true},
+		{key: "header:X-Client", a: []string{"X-Client", "c"}, b: []string{"X-Client", "c"}, sharing: true},
+		{key: "account", a: []string{"X-Account", "7"}, b: []string{"X-Account", "7"}, sharing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			l := newLimiter(t, config.RateLimitConfig{Default: policy(t, "1/1m key="+tt.key)}, WithKeyFunc("account", func(r *http.Request) string {
+				return r.Header.Get("X-Account")
+			}))
+			h := authenticated(limitedRouter(l))
+
+			require.Equal(t, http.StatusOK, do(h, http.MethodGet, "/items", "10.0.0.1", tt.a...).Code)
+			second := do(h, http.MethodGet, "/items", "10.0.0.2", tt.b...)
+
+			assert.Equal(t, tt.sharing, second.Code == http.StatusTooManyRequests)
+		})
+	}
+}
+
+func TestLimiter_UnauthenticatedKeysFallBackToIP(t *testing.T) {
+	// GIVEN policies keyed by API key and tenant
+	for _, key := range []string{"api_key", "tenant"} {
+		t.Run(key, func(t *testing.T) {
+			h := authenticated(limitedRouter(newLimiter(t, config.RateLimitConfig{Default: policy(t, "1/1m key="+key)})))
+
+			// WHEN an unauthenticated client varies the headers naming a key or tenant
+			do(h, http.MethodGet, "/items", "10.0.0.1", "X-API-Key", "k1", "X-Tenant-ID", "t1")
+			second := do(h, http.MethodGet, "/items", "10.0.0.1", "X-API-Key", "k2", "X-Tenant-ID", "t2")
+
+			// THEN it is still counted by IP and gets no fresh quota
+			assert.Equal(t, http.StatusTooManyRequests, second.Code)
+			assert.Equal(t, http.StatusOK, do(h, http.MethodGet, "/items", "10.0.0.2").Code)
+		})
+	}
+}
+
+func TestLimiter_CountsRejections(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	defer otel.SetMeterProvider(prev)
+	h := limitedRouter(newLimiter(t, config.RateLimitConfig{Default: policy(t, "1/1m")}))
+
+	do(h, http.MethodGet, "/items", "10.0.0.1")
+	do(h, http.MethodGet, "/items", "10.0.0.1")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, "http.server.rate_limited", rm.ScopeMetrics[0].Metrics[0].Name)
+	sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+	assert.Equal(t, int64(1), sum.DataPoints[0].Value)
+}
+
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, config.RateLimitPolicy, time.Time) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func (failingStore) Peek(context.Context, string, config.RateLimitPolicy, time.Time) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func TestLimiter_StoreFailureAllows(t *testing.T) {
+	l, err := New(config.RateLimitConfig{Default: policy(t, "1/1m")}, failingStore{})
+	require.NoError(t, err)
+	h := limitedRouter(l)
+
+	for range 3 {
+		assert.Equal(t, http.StatusOK, do(h, http.MethodGet, "/items", "10.0.0.1").Code)
+	}
+}
+
+// guarded answers 401 unless the request carries "Authorization: good".
+func guarded(l *Limiter) http.Handler {
+	return l.AuthFailures(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+}
+
+func TestLimiter_AuthFailures(t *testing.T) {
+	// GIVEN 2 failed authentications allowed per minute
+	h := guarded(newLimiter(t, config.RateLimitConfig{AuthFailures: policy(t, "2/1m")}))
+
+	// WHEN a client keeps guessing credentials
+	codes := make([]int, 3)
+	for i := range codes {
+		codes[i] = do(h, http.MethodGet, "/items", "10.0.0.1", "Authorization", "guess").Code
+	}
+	blocked := do(h, http.MethodGet, "/items", "10.0.0.1", "Authorization", "good")
+
+	// THEN it is rejected once the failures are used up, even with valid credentials
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, http.StatusTooManyRequests, blocked.Code)
+	assert.Equal(t, "30", blocked.Header().Get("Retry-After"))
+	var body apperr.Problem
+	require.NoError(t, json.Unmarshal(blocked.Body.Bytes(), &body))
+	assert.Equal(t, apperr.CodeRateLimited, body.Code)
+
+	// AND successful authentications of other clients do not count
+	for range 5 {
+		assert.Equal(t, http.StatusOK, do(h, http.MethodGet, "/items", "10.0.0.2", "Authorization", "good").Code)
+	}
+}
+
+func TestLimiter_AuthFailuresDisabledOrStoreDown(t *testing.T) {
+	disabled := newLimiter(t, config.RateLimitConfig{AuthFailures: policy(t, "none")})
+	down, err := New(config.RateLimitConfig{AuthFailures: policy(t, "1/1m")}, failingStore{})
+	require.NoError(t, err)
+
+	for name, l := range map[string]*Limiter{"disabled": disabled, "store down": down} {
+		t.Run(name, func(t *testing.T) {
+			h := guarded(l)
+			for range 3 {
+				assert.Equal(t, http.StatusUnauthorized, do(h, http.MethodGet, "/items", "10.0.0.1").Code)
+			}
+		})
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := New(config.RateLimitConfig{Default: policy(t, "1/1m key=account")}, NewMemoryStore())
+	assert.ErrorContains(t, err, `unknown key "account"`)
+
+	_, err = New(config.RateLimitConfig{Routes: map[string]config.RateLimitPolicy{"/x": policy(t, "1/1m key=nope")}}, NewMemoryStore())
+	assert.ErrorContains(t, err, `route "/x"`)
+
+	_, err = New(config.RateLimitConfig{Routes: map[string]config.RateLimitPolicy{"/{id": policy(t, "1/1m")}}, NewMemoryStore())
+	assert.ErrorContains(t, err, "ratelimit: routes")
+}
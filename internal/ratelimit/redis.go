@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"template-go/internal/config"
+)
+
+// tokenBucketScript is the Redis counterpart of entry.tokenBucket.
+//
+//	KEYS[1] bucket key
+//	ARGV    capacity, refill rate in tokens per ms, now in Unix ms, cost
+//
+// It returns {allowed, remaining, reset ms, retry after ms}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil or last == nil then
+  tokens = capacity
+  last = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - last) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - cost
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate)
+end
+local reset = math.ceil((capacity - tokens) / rate)
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'last', now)
+redis.call('PEXPIRE', KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// slidingWindowScript is the Redis counterpart of entry.slidingWindow.
+//
+//	KEYS[1] window key
+//	ARGV    requests per window, window in ms, now in Unix ms, cost
+//
+// It returns {allowed, remaining, reset ms, retry after ms}.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local size = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local start = now - (now % size)
+
+local state = redis.call('HMGET', KEYS[1], 'window', 'curr', 'prev')
+local window = tonumber(state[1])
+local curr = tonumber(state[2]) or 0
+local prev = tonumber(state[3]) or 0
+if window ~= start then
+  if window == start - size then
+    prev = curr
+  else
+    prev = 0
+  end
+  curr = 0
+end
+
+local elapsed = now - start
+local estimate = prev * (1 - elapsed / size) + curr
+local allowed = 0
+local retry = 0
+if estimate + 1 <= limit then
+  curr = curr + cost
+  estimate = estimate + cost
+  allowed = 1
+elseif curr >= limit then
+  retry = size - elapsed + math.ceil(size * (1 - (limit - 1) / curr))
+else
+  retry = math.max(1, math.ceil(size * (1 - (limit - 1 - curr) / prev) - elapsed))
+end
+
+redis.call('HMSET', KEYS[1], 'window', start, 'curr', curr, 'prev', prev)
+redis.call('PEXPIRE', KEYS[1], start + 2 * size - now)
+return {allowed, math.max(0, math.floor(limit - estimate)), start + size - now, retry}
+`)
+
+// RedisStore keeps counters in Redis so that every replica enforces the
+// same limit. Each decision is a single Lua script, so it is atomic. The
+// time is taken from the caller; keep replica clocks in sync.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore returns a store using client. Keys are prefixed with prefix.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Allow implements Store.
+func (s *RedisStore) Allow(ctx context.Context, key string, p config.RateLimitPolicy, now time.Time) (Result, error) {
+	return s.take(ctx, key, p, now, 1)
+}
+
+// Peek implements Store.
+func (s *RedisStore) Peek(ctx context.Context, key string, p config.RateLimitPolicy, now time.Time) (Result, error) {
+	return s.take(ctx, key, p, now, 0)
+}
+
+// take counts cost requests, 0 or 1, against key if they are allowed.
+func (s *RedisStore) take(ctx context.Context, key string, p config.RateLimitPolicy, now time.Time, cost int) (Result, error) {
+	var (
+		script *redis.Script
+		args   []any
+		limit  int
+	)
+	if p.Algorithm == config.AlgorithmSlidingWindow {
+		script, limit = slidingWindowScript, p.Requests
+		args = []any{p.Requests, p.Window.Milliseconds(), now.UnixMilli(), cost}
+	} else {
+		script, limit = tokenBucketScript, p.Burst
+		rate := float64(p.Requests) / float64(p.Window.Milliseconds())
+		args = []any{p.Burst, strconv.FormatFloat(rate, 'g', -1, 64), now.UnixMilli(), cost}
+	}
+
+	out, err := script.Run(ctx, s.client, []string{s.prefix + key}, args...).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: redis: %w", err)
+	}
+	if len(out) != 4 {
+		return Result{}, fmt.Errorf("ratelimit: redis: unexpected script result %v", out)
+	}
+	return Result{
+		Allowed:    out[0] == 1,
+		Limit:      limit,
+		Remaining:  int(out[1]),
+		Reset:      time.Duration(out[2]) * time.Millisecond,
+		RetryAfter: time.Duration(out[3]) * time.Millisecond,
+	}, nil
+}
+
+// Ping reports whether Redis is reachable; use it as a health check.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
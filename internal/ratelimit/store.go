@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"template-go/internal/config"
+)
+
+// Result is the outcome of counting one request against a policy.
+type Result struct {
+	Allowed bool
+	// Limit is the number of requests the policy allows at once: the burst
+	// of a token bucket or the requests per window of a sliding window.
+	Limit int
+	// Remaining is how many further requests would be allowed right now.
+	Remaining int
+	// Reset is how long until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is how long a rejected client should wait; zero when the
+	// request was allowed.
+	RetryAfter time.Duration
+}
+
+// Store counts requests per key. Implementations must apply the policy
+// atomically so that replicas sharing a store enforce a single limit.
+type Store interface {
+	Allow(ctx context.Context, key string, p config.RateLimitPolicy, now time.Time) (Result, error)
+	// Peek reports whether one more request would be allowed without
+	// counting it.
+	Peek(ctx context.Context, key string, p config.RateLimitPolicy, now time.Time) (Result, error)
+}
+
+// sweepInterval is how often MemoryStore drops idle keys.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps counters in process memory. Limits are per replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	nextSweep int64
+}
+
+// entry holds the state of either algorithm; times are Unix milliseconds.
+type entry struct {
+	// token bucket
+	tokens float64
+	last   int64
+	// sliding window
+	window     int64
+	curr, prev int
+
+	expires int64
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*entry)}
+}
+
+// Allow implements Store.
+func (s *MemoryStore) Allow(_ context.Context, key string, p config.RateLimitPolicy, now time.Time) (Result, error) {
+	return s.take(key, p, now, 1), nil
+}
+
+// Peek implements Store.
+func (s *MemoryStore) Peek(_ context.Context, key string, p config.RateLimitPolicy, now time.Time) (Result, error) {
+	return s.take(key, p, now, 0), nil
+}
+
+// take counts cost requests, 0 or 1, against key if they are allowed.
+func (s *MemoryStore) take(key string, p config.RateLimitPolicy, now time.Time, cost int) Result {
+	ms := now.UnixMilli()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ms >= s.nextSweep {
+		for k, e := range s.entries {
+			if e.expires <= ms {
+				delete(s.entries, k)
+			}
+		}
+		s.nextSweep = ms + sweepInterval.Milliseconds()
+	}
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = &entry{tokens: float64(p.Burst), last: ms, window: -1}
+		s.entries[key] = e
+	}
+	if p.Algorithm == config.AlgorithmSlidingWindow {
+		return e.slidingWindow(p, ms, cost)
+	}
+	return e.tokenBucket(p, ms, cost)
+}
+
+// tokenBucket refills Requests tokens per Window up to Burst and takes
+// cost. It mirrors tokenBucketScript.
+func (e *entry) tokenBucket(p config.RateLimitPolicy, now int64, cost int) Result {
+	capacity := float64(p.Burst)
+	rate := float64(p.Requests) / float64(p.Window.Milliseconds()) // tokens per ms
+
+	elapsed := max(0, now-e.last)
+	e.tokens = min(capacity, e.tokens+float64(elapsed)*rate)
+	e.last = now
+
+	res := Result{Limit: p.Burst}
+	if e.tokens >= 1 {
+		e.tokens -= float64(cost)
+		res.Allowed = true
+	} else {
+		res.RetryAfter = millis(math.Ceil((1 - e.tokens) / rate))
+	}
+	res.Remaining = int(math.Floor(e.tokens))
+	res.Reset = millis(math.Ceil((capacity - e.tokens) / rate))
+	e.expires = now + max(res.Reset.Milliseconds(), 1)
+	return res
+}
+
+// slidingWindow estimates the requests in the last Window from the count
+// of the current fixed window and the previous one, weighted by how much of
+// it still overlaps, and counts cost more. It mirrors slidingWindowScript.
+func (e *entry) slidingWindow(p config.RateLimitPolicy, now int64, cost int) Result {
+	size := p.Window.Milliseconds()
+	start := now - now%size
+	if e.window != start {
+		if e.window == start-size {
+			e.prev = e.curr
+		} else {
+			e.prev = 0
+		}
+		e.curr = 0
+		e.window = start
+	}
+
+	elapsed := now - start
+	estimate := float64(e.prev)*(1-float64(elapsed)/float64(size)) + float64(e.curr)
+	limit := float64(p.Requests)
+
+	res := Result{Limit: p.Requests, Reset: millis(float64(start + size - now))}
+	if estimate+1 <= limit {
+		e.curr += cost
+		estimate += float64(cost)
+		res.Allowed = true
+	} else {
+		res.RetryAfter = millis(slidingRetry(float64(e.prev), float64(e.curr), limit, float64(elapsed), float64(size)))
+	}
+	res.Remaining = int(max(0, math.Floor(limit-estimate)))
+	e.expires = start + 2*size
+	return res
+}
+
+// slidingRetry returns the milliseconds until the estimate drops low enough
+// for one more request.
+func slidingRetry(prev, curr, limit, elapsed, size float64) float64 {
+	if curr >= limit {
+		// Wait for the next window, then for curr to decay as its previous.
+		return size - elapsed + math.Ceil(size*(1-(limit-1)/curr))
+	}
+	return math.Max(1, math.Ceil(size*(1-(limit-1-curr)/prev)-elapsed))
+}
+
+func millis(ms float64) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
@@ -0,0 +1,223 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/config"
+)
+
+// stores returns every Store implementation, the Redis one backed by an
+// in-process fake server.
+func stores(t *testing.T) map[string]Store {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"redis":  NewRedisStore(client, "test:"),
+	}
+}
+
+func policy(t *testing.T, s string) config.RateLimitPolicy {
+	t.Helper()
+	p, err := config.ParseRateLimitPolicy(s)
+	require.NoError(t, err)
+	return p
+}
+
+// epoch is aligned to a minute so sliding windows start at a known time.
+var epoch = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestStore_TokenBucket(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN a bucket of 3 tokens refilled at 1 token per second
+			p := policy(t, "1/1s burst=3")
+			ctx := context.Background()
+
+			// WHEN the burst is spent at once
+			for i := range 3 {
+				res, err := store.Allow(ctx, "k", p, epoch)
+				require.NoError(t, err)
+				assert.True(t, res.Allowed, "request %d", i)
+				assert.Equal(t, 2-i, res.Remaining)
+				assert.Equal(t, 3, res.Limit)
+			}
+
+			// THEN the next request is rejected until a token is refilled
+			res, err := store.Allow(ctx, "k", p, epoch.Add(400*time.Millisecond))
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 0, res.Remaining)
+			assert.Equal(t, 600*time.Millisecond, res.RetryAfter)
+			assert.Equal(t, 2600*time.Millisecond, res.Reset)
+
+			res, err = store.Allow(ctx, "k", p, epoch.Add(time.Second))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+
+			// AND other keys have their own bucket
+			res, err = store.Allow(ctx, "other", p, epoch.Add(time.Second))
+			require.NoError(t, err)
+			assert.Equal(t, 2, res.Remaining)
+		})
+	}
+}
+
+func TestStore_TokenBucketRefillsToBurst(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			p := policy(t, "10/1s burst=2")
+			ctx := context.Background()
+			for range 2 {
+				_, err := store.Allow(ctx, "k", p, epoch)
+				require.NoError(t, err)
+			}
+
+			res, err := store.Allow(ctx, "k", p, epoch.Add(time.Hour))
+
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 1, res.Remaining, "refill is capped at the burst")
+		})
+	}
+}
+
+func TestStore_SlidingWindow(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			// GIVEN 4 requests per 10s, all used at the start of a window
+			p := policy(t, "4/10s algorithm=sliding_window")
+			ctx := context.Background()
+			for i := range 4 {
+				res, err := store.Allow(ctx, "k", p, epoch)
+				require.NoError(t, err)
+				require.True(t, res.Allowed)
+				assert.Equal(t, 3-i, res.Remaining)
+			}
+
+			// WHEN another arrives within the window
+			res, err := store.Allow(ctx, "k", p, epoch.Add(5*time.Second))
+
+			// THEN it waits for the next window plus the decay of this one
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 4, res.Limit)
+			assert.Equal(t, 5*time.Second, res.Reset)
+			assert.Equal(t, 5*time.Second+2500*time.Millisecond, res.RetryAfter)
+
+			// AND the previous window still counts in proportion to its overlap
+			res, err = store.Allow(ctx, "k", p, epoch.Add(12*time.Second)) // 80% overlap: 3.2 used
+			require.NoError(t, err)
+			assert.False(t, res.Allowed)
+			assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+			res, err = store.Allow(ctx, "k", p, epoch.Add(12500*time.Millisecond)) // 75%: 3 used
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 0, res.Remaining)
+
+			// AND after two idle windows the history is gone
+			res, err = store.Allow(ctx, "k", p, epoch.Add(40*time.Second))
+			require.NoError(t, err)
+			assert.True(t, res.Allowed)
+			assert.Equal(t, 3, res.Remaining)
+		})
+	}
+}
+
+func TestStore_Peek(t *testing.T) {
+	for name, store := range stores(t) {
+		for _, p := range []config.RateLimitPolicy{policy(t, "2/1m"), policy(t, "2/1m algorithm=sliding_window")} {
+			t.Run(name+"/"+p.Algorithm, func(t *testing.T) {
+				// GIVEN a policy of 2 requests per minute
+				ctx := context.Background()
+				key := "peek:" + p.Algorithm
+
+				// WHEN the quota is peeked at repeatedly
+				for range 3 {
+					res, err := store.Peek(ctx, key, p, epoch)
+					require.NoError(t, err)
+					assert.True(t, res.Allowed)
+					assert.Equal(t, 2, res.Remaining)
+				}
+
+				// THEN nothing is counted until requests are allowed
+				for range 2 {
+					res, err := store.Allow(ctx, key, p, epoch)
+					require.NoError(t, err)
+					require.True(t, res.Allowed)
+				}
+				res, err := store.Peek(ctx, key, p, epoch)
+				require.NoError(t, err)
+				assert.False(t, res.Allowed)
+				assert.Equal(t, 0, res.Remaining)
+				assert.Positive(t, res.RetryAfter)
+			})
+		}
+	}
+}
+
+func TestMemoryStore_SweepsIdleKeys(t *testing.T) {
+	store := NewMemoryStore()
+	p := policy(t, "1/1s")
+	_, _ = store.Allow(context.Background(), "idle", p, epoch)
+
+	_, _ = store.Allow(context.Background(), "busy", p, epoch.Add(2*sweepInterval))
+
+	assert.NotContains(t, store.entries, "idle")
+	assert.Contains(t, store.entries, "busy")
+}
+
+func TestRedisStore_Errors(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr(), MaxRetries: -1})
+	defer func() { _ = client.Close() }()
+	store := NewRedisStore(client, "test:")
+	require.NoError(t, store.Ping(context.Background()))
+
+	srv.Close()
+
+	_, err := store.Allow(context.Background(), "k", policy(t, "1/1s"), epoch)
+	assert.ErrorContains(t, err, "ratelimit: redis")
+	assert.Error(t, store.Ping(context.Background()))
+}
+
+// resultHook replaces the result of every script with val.
+type resultHook struct{ val any }
+
+func (resultHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h resultHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		c, ok := cmd.(*redis.Cmd)
+		if !ok {
+			return next(ctx, cmd)
+		}
+		c.SetVal(h.val)
+		return nil
+	}
+}
+
+func (resultHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRedisStore_UnexpectedScriptResult(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	defer func() { _ = client.Close() }()
+	client.AddHook(resultHook{val: []any{int64(1)}})
+
+	_, err := NewRedisStore(client, "test:").Allow(context.Background(), "k", policy(t, "1/1s"), epoch)
+
+	assert.ErrorContains(t, err, "unexpected script result [1]")
+}
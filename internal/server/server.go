@@ -0,0 +1,141 @@
+// Package server runs the HTTP listener and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"template-go/internal/config"
+	"template-go/internal/health"
+	"template-go/pkg/logger"
+)
+
+// Server wraps an http.Server with the drain sequence expected by
+// Kubernetes: on shutdown it first reports not ready, keeps serving for the
+// drain delay so endpoints are removed from load balancers, and then stops
+// accepting connections and waits for in-flight requests.
+type Server struct {
+	name            string
+	srv             *http.Server
+	probe           *health.Probe
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+	tls             config.TLSConfig
+
+	ln   net.Listener
+	done chan error // receives the result of Serve
+}
+
+// New returns a Server for handler configured from cfg. probe may be nil
+// when readiness is not reported.
+func New(cfg config.ServerConfig, handler http.Handler, probe *health.Probe) *Server {
+	if probe == nil {
+		probe = health.NewProbe()
+	}
+	return &Server{
+		name: "http-server",
+		srv: &http.Server{
+			Addr:              cfg.ListenAddr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		probe:           probe,
+		drainDelay:      cfg.DrainDelay,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		tls:             cfg.TLS,
+	}
+}
+
+// NewAdmin returns the "admin-server" listening on cfg.AdminAddr. It serves
+// plain HTTP for in-cluster probes and scrapers and has no drain delay: it should be stopped after the public server so the readiness
+// probe it serves keeps answering while the public server drains.
+func NewAdmin(cfg config.ServerConfig, handler http.Handler) *Server {
+	cfg.ListenAddr = cfg.AdminAddr
+	cfg.DrainDelay = 0
+	cfg.TLS = config.TLSConfig{}
+	s := New(cfg, handler, nil)
+	s.name = "admin-server"
+	return s
+}
+
+// Name implements app.Component.
+func (s *Server) Name() string { return s.name }
+
+// Addr returns the address the server is listening on, which differs from
+// the configured one when it uses port 0. It is empty before Start.
+func (s *Server) Addr() string {
+	if s.ln == nil {
+		return ""
+	}
+	return s.ln.Addr().String()
+}
+
+// Start listens on the configured address, serves in the background and
+// marks the probe ready. When TLS is configured the certificates are loaded
+// first and a failure to load them is returned.
+func (s *Server) Start(context.Context) error {
+	serve := s.srv.Serve
+	if s.tls.Enabled() {
+		tlsCfg, err := newTLSConfig(s.tls)
+		if err != nil {
+			return err
+		}
+		s.srv.TLSConfig = tlsCfg
+		serve = func(ln net.Listener) error { return s.srv.ServeTLS(ln, "", "") }
+	}
+
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.srv.Addr, err)
+	}
+	s.ln = ln
+	s.done = make(chan error, 1)
+	go func() {
+		err := serve(ln)
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.probe.SetReady(false)
+			logger.Error(context.Background(), "http server stopped unexpectedly", zap.Error(err))
+		}
+		s.done <- err
+	}()
+	s.probe.SetReady(true)
+	return nil
+}
+
+// Stop runs the drain sequence: it marks the probe not ready, waits for the
+// drain delay, then shuts the server down and waits up to the shutdown
+// timeout, or until ctx is done if sooner, for in-flight requests.
+func (s *Server) Stop(ctx context.Context) error {
+	if s.done == nil {
+		return nil
+	}
+	s.probe.SetReady(false)
+	logger.Info(ctx, "shutting down, draining connections",
+		zap.Duration("drain_delay", s.drainDelay), zap.Duration("timeout", s.shutdownTimeout))
+	select {
+	case <-time.After(s.drainDelay):
+	case <-ctx.Done():
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+	err := s.srv.Shutdown(ctx)
+	if err != nil {
+		// Requests still running after the deadline are cut off.
+		_ = s.srv.Close()
+		err = fmt.Errorf("in-flight requests did not finish in time: %w", err)
+	}
+	if serveErr := <-s.done; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/config"
+	"template-go/internal/health"
+	"template-go/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init()
+	os.Exit(m.Run())
+}
+
+// start starts s on a random local port and returns its base URL.
+func start(t *testing.T, s *Server) string {
+	t.Helper()
+	s.srv.Addr = "127.0.0.1:0"
+	require.NoError(t, s.Start(context.Background()))
+	return "http://" + s.Addr()
+}
+
+func TestServer_DrainsInFlightRequests(t *testing.T) {
+	// GIVEN a server with a request in flight
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+	probe := health.NewProbe()
+	s := New(config.ServerConfig{DrainDelay: 20 * time.Millisecond, ShutdownTimeout: 5 * time.Second}, handler, probe)
+	url := start(t, s)
+
+	type result struct {
+		body string
+		err  error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		resCh <- result{string(body), err}
+	}()
+	<-started
+	assert.True(t, probe.Ready())
+
+	// WHEN the server is stopped
+	done := make(chan error, 1)
+	go func() { done <- s.Stop(context.Background()) }()
+
+	// THEN the probe flips to not ready while the request is still running
+	assert.Eventually(t, func() bool { return !probe.Ready() }, time.Second, time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("server stopped before in-flight request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// AND the request completes before Stop returns cleanly
+	close(release)
+	res := <-resCh
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body)
+	assert.NoError(t, <-done)
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	// GIVEN a request that outlives the shutdown timeout
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	s := New(config.ServerConfig{ShutdownTimeout: 20 * time.Millisecond}, handler, nil)
+	url := start(t, s)
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// WHEN the server is stopped
+	err := s.Stop(context.Background())
+
+	// THEN Stop reports the deadline instead of hanging
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestServer_StopCancelledDuringDrain(t *testing.T) {
+	// GIVEN a started server with a long drain delay
+	s := New(config.ServerConfig{DrainDelay: time.Hour, ShutdownTimeout: time.Second}, http.NotFoundHandler(), nil)
+	assert.Empty(t, s.Addr(), "no address before Start")
+	start(t, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// WHEN it is stopped with a cancelled context
+	begin := time.Now()
+	err := s.Stop(ctx)
+
+	// THEN the drain delay is cut short
+	assert.NoError(t, err, "idle servers shut down at once")
+	assert.Less(t, time.Since(begin), time.Minute)
+}
+
+func TestServer_ServeError(t *testing.T) {
+	// GIVEN a running server whose listener fails
+	probe := health.NewProbe()
+	s := New(config.ServerConfig{ShutdownTimeout: time.Second}, http.NotFoundHandler(), probe)
+	start(t, s)
+	require.NoError(t, s.ln.Close())
+
+	// THEN it reports not ready
+	assert.Eventually(t, func() bool { return !probe.Ready() }, time.Second, time.Millisecond)
+
+	// AND stopping it returns the serve error
+	assert.ErrorContains(t, s.Stop(context.Background()), "use of closed network connection")
+}
+
+func TestServer_StartListenError(t *testing.T) {
+	s := New(config.ServerConfig{ListenAddr: "256.0.0.1:0"}, http.NotFoundHandler(), nil)
+
+	err := s.Start(context.Background())
+
+	assert.ErrorContains(t, err, "failed to listen")
+	assert.NoError(t, s.Stop(context.Background()), "stopping a server that never started is a no-op")
+}
+
+func TestNew_AppliesTimeouts(t *testing.T) {
+	cfg := config.ServerConfig{
+		ListenAddr:        ":8080",
+		ReadTimeout:       time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      3 * time.Second,
+		IdleTimeout:       4 * time.Second,
+	}
+
+	s := New(cfg, http.NotFoundHandler(), nil)
+
+	assert.Equal(t, ":8080", s.srv.Addr)
+	assert.Equal(t, time.Second, s.srv.ReadTimeout)
+	assert.Equal(t, 2*time.Second, s.srv.ReadHeaderTimeout)
+	assert.Equal(t, 3*time.Second, s.srv.WriteTimeout)
+	assert.Equal(t, 4*time.Second, s.srv.IdleTimeout)
+}
+
+func TestNewAdmin(t *testing.T) {
+	cfg := config.ServerConfig{ListenAddr: ":8080", AdminAddr: ":9090", DrainDelay: 5 * time.Second, WriteTimeout: time.Second}
+
+	s := NewAdmin(cfg, http.NotFoundHandler())
+
+	assert.Equal(t, "admin-server", s.Name())
+	assert.Equal(t, ":9090", s.srv.Addr)
+	assert.Zero(t, s.drainDelay)
+	assert.Equal(t, time.Second, s.srv.WriteTimeout)
+}
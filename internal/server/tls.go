@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"template-go/internal/config"
+	"template-go/internal/filestamp"
+	"template-go/pkg/logger"
+)
+
+// tlsVersions maps config values to protocol versions.
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// clientAuthTypes maps config values to client certificate policies.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	config.ClientAuthNone:             tls.NoClientCert,
+	config.ClientAuthRequest:          tls.RequestClientCert,
+	config.ClientAuthRequire:          tls.RequireAnyClientCert,
+	config.ClientAuthVerifyIfGiven:    tls.VerifyClientCertIfGiven,
+	config.ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
+}
+
+// certReloader serves the certificate and client CA bundle from disk and
+// re-reads them when the files change, so rotated certificates are picked up
+// by new handshakes without restarting or dropping existing connections.
+type certReloader struct {
+	cfg  config.TLSConfig
+	base *tls.Config
+	now  func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    []filestamp.Stamp
+	checked   time.Time
+}
+
+// newTLSConfig returns the tls.Config for the public listener. Certificates
+// are loaded immediately so misconfiguration fails at startup.
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	r := &certReloader{cfg: cfg, now: time.Now}
+
+	base := &tls.Config{
+		MinVersion: tlsVersions[cfg.MinVersion],
+		ClientAuth: clientAuthTypes[cfg.ClientAuth],
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if base.MinVersion == 0 {
+		base.MinVersion = tls.VersionTLS12
+	}
+	for _, name := range cfg.CipherSuites {
+		id, ok := config.CipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite %q", name)
+		}
+		base.CipherSuites = append(base.CipherSuites, id)
+	}
+	r.base = base
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:         base.MinVersion,
+		NextProtos:         base.NextProtos,
+		GetConfigForClient: r.getConfigForClient,
+	}, nil
+}
+
+// files returns the paths watched for changes.
+func (r *certReloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// load reads the key pair and client CA bundle. The caller must hold mu or
+// be the only user of r.
+func (r *certReloader) load() error {
+	stamps := filestamp.Of(r.files())
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("client CA file contains no PEM certificates")
+		}
+	}
+	r.cert, r.clientCAs, r.stamps = &cert, pool, stamps
+	return nil
+}
+
+// current returns the certificate and CA pool to use for a handshake,
+// reloading them first if the files changed since the last check.
+func (r *certReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if interval := r.cfg.ReloadInterval; interval > 0 && r.now().Sub(r.checked) >= interval {
+		r.checked = r.now()
+		if !slices.Equal(filestamp.Of(r.files()), r.stamps) {
+			if err := r.load(); err != nil {
+				// Files may be mid-rotation; keep serving the previous pair.
+				logger.Error(context.Background(), "failed to reload TLS certificates, keeping current ones", zap.Error(err))
+			} else {
+				logger.Info(context.Background(), "reloaded TLS certificates")
+			}
+		}
+	}
+	return r.cert, r.clientCAs
+}
+
+// getConfigForClient implements tls.Config.GetConfigForClient.
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	cert, pool := r.current()
+	cfg := r.base.Clone()
+	cfg.Certificates = []tls.Certificate{*cert}
+	cfg.ClientCAs = pool
+	return cfg, nil
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/internal/config"
+	mw "template-go/internal/delivery/http/middleware"
+)
+
+// testCA issues certificates for tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for cn, valid for 127.0.0.1.
+func (ca *testCA) issue(t *testing.T, cn string, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFiles writes name/content pairs into dir.
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0o600))
+	}
+}
+
+// tlsClient returns a client trusting ca and presenting the given key pair,
+// if any.
+func tlsClient(t *testing.T, ca *testCA, certPEM, keyPEM []byte) *http.Client {
+	t.Helper()
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	cfg := &tls.Config{RootCAs: roots}
+	if certPEM != nil {
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}, Timeout: 5 * time.Second}
+}
+
+func TestServer_MutualTLS(t *testing.T) {
+	// GIVEN a server requiring verified client certificates
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	srvCert, srvKey := ca.issue(t, "server", 2, x509.ExtKeyUsageServerAuth)
+	writeFiles(t, dir, map[string][]byte{"tls.crt": srvCert, "tls.key": srvKey, "ca.crt": ca.pem})
+	cfg := config.ServerConfig{
+		ShutdownTimeout: time.Second,
+		TLS: config.TLSConfig{
+			CertFile:     filepath.Join(dir, "tls.crt"),
+			KeyFile:      filepath.Join(dir, "tls.key"),
+			ClientCAFile: filepath.Join(dir, "ca.crt"),
+			ClientAuth:   config.ClientAuthRequireAndVerify,
+			MinVersion:   "1.2",
+			CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		},
+	}
+	handler := mw.ClientCert(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := mw.ClientIdentityFrom(r.Context())
+		_, _ = w.Write([]byte(id.CommonName))
+	}))
+	s := New(cfg, handler, nil)
+	start(t, s)
+	url := "https://" + s.Addr()
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	// WHEN a client presents a certificate issued by the CA
+	clientCert, clientKey := ca.issue(t, "billing", 3, x509.ExtKeyUsageClientAuth)
+	resp, err := tlsClient(t, ca, clientCert, clientKey).Get(url)
+
+	// THEN the handler sees its verified identity
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "billing", string(body))
+
+	// AND a client without a certificate is rejected during the handshake
+	_, err = tlsClient(t, ca, nil, nil).Get(url)
+	assert.Error(t, err)
+}
+
+func TestServer_TLSCertificateReload(t *testing.T) {
+	// GIVEN a TLS server whose certificate files are checked on every handshake
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	first, firstKey := ca.issue(t, "first", 10, x509.ExtKeyUsageServerAuth)
+	writeFiles(t, dir, map[string][]byte{"tls.crt": first, "tls.key": firstKey})
+	cfg := config.ServerConfig{
+		ShutdownTimeout: time.Second,
+		TLS: config.TLSConfig{
+			CertFile:       filepath.Join(dir, "tls.crt"),
+			KeyFile:        filepath.Join(dir, "tls.key"),
+			ReloadInterval: time.Nanosecond,
+		},
+	}
+	s := New(cfg, http.NotFoundHandler(), nil)
+	start(t, s)
+	addr := s.Addr()
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	servedSerial := func() int64 {
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.cert)
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots})
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+		return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	require.Equal(t, int64(10), servedSerial())
+
+	// WHEN the files are rotated
+	second, secondKey := ca.issue(t, "second", 11, x509.ExtKeyUsageServerAuth)
+	writeFiles(t, dir, map[string][]byte{"tls.crt": second, "tls.key": secondKey})
+	future := time.Now().Add(time.Minute)
+	for _, name := range []string{"tls.crt", "tls.key"} {
+		require.NoError(t, os.Chtimes(filepath.Join(dir, name), future, future))
+	}
+
+	// THEN new handshakes use the new certificate
+	assert.Equal(t, int64(11), servedSerial())
+
+	// AND a broken rotation keeps the last good certificate
+	writeFiles(t, dir, map[string][]byte{"tls.key": []byte("garbage")})
+	assert.Equal(t, int64(11), servedSerial())
+}
+
+func TestServer_TLSStartErrors(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	srvCert, srvKey := ca.issue(t, "server", 2, x509.ExtKeyUsageServerAuth)
+	writeFiles(t, dir, map[string][]byte{"tls.crt": srvCert, "tls.key": srvKey, "empty.pem": nil})
+
+	tests := []struct {
+		name    string
+		tls     config.TLSConfig
+		wantErr string
+	}{
+		{"missing key pair", config.TLSConfig{CertFile: filepath.Join(dir, "nope.crt"), KeyFile: filepath.Join(dir, "nope.key")}, "failed to load TLS key pair"},
+		{"missing client CA file", config.TLSConfig{
+			CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key"), ClientCAFile: filepath.Join(dir, "nope.pem"),
+		}, "failed to read client CA file"},
+		{"empty client CA bundle", config.TLSConfig{
+			CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key"), ClientCAFile: filepath.Join(dir, "empty.pem"),
+		}, "client CA file contains no PEM certificates"},
+		{"unknown cipher suite", config.TLSConfig{
+			CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key"), CipherSuites: []string{"TLS_FAKE"},
+		}, `unsupported cipher suite "TLS_FAKE"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(config.ServerConfig{ListenAddr: "127.0.0.1:0", TLS: tt.tls}, http.NotFoundHandler(), nil)
+
+			err := s.Start(context.Background())
+
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
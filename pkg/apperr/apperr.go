@@ -0,0 +1,141 @@
+// Package apperr defines the error type returned by handlers and renders it
+// as an RFC 7807 application/problem+json response.
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code classifies an error. It is stable, machine readable and returned to
+// clients in the "code" member of the problem document.
+type Code string
+
+// Error codes and the HTTP status each maps to.
+const (
+	CodeInvalidArgument  Code = "invalid_argument"       // 400
+	CodeUnauthenticated  Code = "unauthenticated"        // 401
+	CodePermissionDenied Code = "permission_denied"      // 403
+	CodeNotFound         Code = "not_found"              // 404
+	CodeMethodNotAllowed Code = "method_not_allowed"     // 405
+	CodeNotAcceptable    Code = "not_acceptable"         // 406
+	CodeConflict         Code = "conflict"               // 409
+	CodeTooLarge         Code = "payload_too_large"      // 413
+	CodeUnsupportedMedia Code = "unsupported_media_type" // 415
+	CodeValidation       Code = "validation_failed"      // 422
+	CodeRateLimited      Code = "rate_limited"           // 429
+	CodeInternal         Code = "internal"               // 500
+	CodeUnavailable      Code = "unavailable"            // 503
+	CodeTimeout          Code = "timeout"                // 504
+)
+
+var statusByCode = map[Code]int{
+	CodeInvalidArgument:  http.StatusBadRequest,
+	CodeUnauthenticated:  http.StatusUnauthorized,
+	CodePermissionDenied: http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+	CodeNotAcceptable:    http.StatusNotAcceptable,
+	CodeConflict:         http.StatusConflict,
+	CodeTooLarge:         http.StatusRequestEntityTooLarge,
+	CodeUnsupportedMedia: http.StatusUnsupportedMediaType,
+	CodeValidation:       http.StatusUnprocessableEntity,
+	CodeRateLimited:      http.StatusTooManyRequests,
+	CodeInternal:         http.StatusInternalServerError,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+	CodeTimeout:          http.StatusGatewayTimeout,
+}
+
+// Status returns the HTTP status for c; unknown codes map to 500.
+func (c Code) Status() int {
+	if s, ok := statusByCode[c]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error with the information needed to answer a request. Message
+// and Details are shown to clients; Cause is only logged.
+type Error struct {
+	Code    Code
+	Message string
+	// Details carries structured, client-safe data such as field errors.
+	Details any
+	Cause   error
+}
+
+// New returns an error with the given code and client-facing message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf is New with a formatted message.
+func Newf(code Code, format string, args ...any) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap returns an error with the given code and message caused by cause.
+func Wrap(cause error, code Code, message string) *Error {
+	return &Error{Code: code, Message: message, Cause: cause}
+}
+
+// WithDetails returns a copy of e carrying details.
+func (e *Error) WithDetails(details any) *Error {
+	cp := *e
+	cp.Details = details
+	return &cp
+}
+
+// Status returns the HTTP status of e.
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	msg := string(e.Code)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the cause.
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Convenience constructors for the most common codes.
+
+// InvalidArgument reports a malformed request.
+func InvalidArgument(message string) *Error { return New(CodeInvalidArgument, message) }
+
+// NotFound reports a missing resource.
+func NotFound(message string) *Error { return New(CodeNotFound, message) }
+
+// Internal wraps an unexpected failure. The cause is logged, never shown.
+func Internal(cause error) *Error {
+	return Wrap(cause, CodeInternal, "An unexpected error occurred.")
+}
+
+// From returns err as an *Error. Errors that are not already one are
+// classified: context deadlines become CodeTimeout, cancellations
+// CodeUnavailable and everything else CodeInternal.
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(err, CodeTimeout, "The request timed out.")
+	case errors.Is(err, context.Canceled):
+		return Wrap(err, CodeUnavailable, "The request was canceled.")
+	default:
+		return Internal(err)
+	}
+}
@@ -0,0 +1,58 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, CodeNotFound.Status())
+	assert.Equal(t, http.StatusUnprocessableEntity, CodeValidation.Status())
+	assert.Equal(t, http.StatusInternalServerError, Code("made_up").Status())
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("sql: no rows")
+	err := Wrap(cause, CodeNotFound, "user 42 does not exist")
+
+	assert.Equal(t, "not_found: user 42 does not exist: sql: no rows", err.Error())
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, http.StatusNotFound, err.Status())
+	assert.Equal(t, "invalid_argument: bad id 7", Newf(CodeInvalidArgument, "bad id %d", 7).Error())
+}
+
+func TestWithDetails(t *testing.T) {
+	base := InvalidArgument("bad input")
+
+	withDetails := base.WithDetails(map[string]string{"name": "required"})
+
+	assert.Nil(t, base.Details, "the original is not modified")
+	assert.Equal(t, map[string]string{"name": "required"}, withDetails.Details)
+}
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("missing")
+	tests := []struct {
+		name     string
+		err      error
+		wantCode Code
+	}{
+		{"app error", notFound, CodeNotFound},
+		{"wrapped app error", fmt.Errorf("loading user: %w", notFound), CodeNotFound},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), CodeTimeout},
+		{"canceled", context.Canceled, CodeUnavailable},
+		{"plain error", errors.New("boom"), CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+
+			assert.Equal(t, tt.wantCode, got.Code)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package apperr
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"template-go/pkg/logger"
+)
+
+// ContentType is the media type of problem documents.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem document with this service's extension
+// members. Reference it from swagger annotations as apperr.Problem.
+type Problem struct {
+	// Type identifies the problem type; "about:blank" means the title is
+	// the HTTP status text.
+	Type   string `json:"type" example:"about:blank"`
+	Title  string `json:"title" example:"Not Found"`
+	Status int    `json:"status" example:"404"`
+	// Detail is a human readable explanation of this occurrence.
+	Detail string `json:"detail,omitempty" example:"user 42 does not exist"`
+	// Instance is the request path.
+	Instance string `json:"instance,omitempty" example:"/users/42"`
+	// Code is the machine readable error code.
+	Code      Code   `json:"code" example:"not_found" swaggertype:"string"`
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+	// Details carries structured data such as field errors.
+	Details any `json:"details,omitempty" swaggertype:"object"`
+} // @name Problem
+
+// ProblemFor builds the problem document describing e for r.
+func ProblemFor(r *http.Request, e *Error) Problem {
+	p := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status()),
+		Status:    e.Status(),
+		Detail:    e.Message,
+		Instance:  r.URL.Path,
+		Code:      e.Code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Details:   e.Details,
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		p.TraceID = sc.TraceID().String()
+	}
+	return p
+}
+
+// WriteProblem writes p as the response.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// Render answers r with err as a problem document. Server errors (5xx) are
+// logged at error level with their cause and mark the request span as
+// failed; client errors are logged at debug level. The error is recorded on
+// the span in both cases.
+func Render(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	ctx := r.Context()
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetAttributes(attribute.String("error.code", string(e.Code)))
+
+	fields := []zap.Field{
+		zap.Error(err),
+		zap.String("code", string(e.Code)),
+		zap.Int("status", e.Status()),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.String("request_id", middleware.GetReqID(ctx)),
+	}
+	if e.Status() >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, string(e.Code))
+		logger.Error(ctx, "request failed", fields...)
+	} else {
+		logger.Debug(ctx, "request rejected", fields...)
+	}
+
+	WriteProblem(w, ProblemFor(r, e))
+}
+
+// HandlerFunc is an http.Handler that returns an error instead of writing
+// it. A non-nil error is passed to Render, so the handler must not have
+// written a response yet.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP implements http.Handler.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		Render(w, r, err)
+	}
+}
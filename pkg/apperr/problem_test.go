@@ -0,0 +1,106 @@
+package apperr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"template-go/pkg/logger"
+)
+
+// serve runs h for a traced GET /users/42 with a request ID.
+func serve(t *testing.T, h http.Handler) (*httptest.ResponseRecorder, *observer.ObservedLogs, tracetest.SpanStubs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	t.Cleanup(logger.Replace(zap.New(core)))
+	spans := tracetest.NewInMemoryExporter()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)).Tracer("test")
+
+	ctx, span := tracer.Start(context.Background(), "request")
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, "req-1")
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	span.End()
+	return rec, logs, spans.GetSpans()
+}
+
+func TestHandlerFunc_ClientError(t *testing.T) {
+	// GIVEN a handler rejecting the request with details
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return NotFound("user 42 does not exist").WithDetails(map[string]any{"id": 42})
+	})
+
+	// WHEN it is served
+	rec, logs, spans := serve(t, h)
+
+	// THEN a problem document describes the error
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	var p Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, "about:blank", p.Type)
+	assert.Equal(t, "Not Found", p.Title)
+	assert.Equal(t, http.StatusNotFound, p.Status)
+	assert.Equal(t, "user 42 does not exist", p.Detail)
+	assert.Equal(t, "/users/42", p.Instance)
+	assert.Equal(t, CodeNotFound, p.Code)
+	assert.Equal(t, "req-1", p.RequestID)
+	assert.Equal(t, spans[0].SpanContext.TraceID().String(), p.TraceID)
+	assert.Equal(t, map[string]any{"id": float64(42)}, p.Details)
+
+	// AND it is logged at debug level without failing the span
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, zapcore.DebugLevel, logs.All()[0].Level)
+	assert.NotEqual(t, codes.Error, spans[0].Status.Code)
+	assert.Len(t, spans[0].Events, 1, "the error is recorded on the span")
+}
+
+func TestHandlerFunc_ServerError(t *testing.T) {
+	// GIVEN a handler failing with an unexpected error
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("connection reset by peer")
+	})
+
+	// WHEN it is served
+	rec, logs, spans := serve(t, h)
+
+	// THEN the client gets a generic 500 without the cause
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var p Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, CodeInternal, p.Code)
+	assert.NotContains(t, rec.Body.String(), "connection reset")
+
+	// AND the cause is logged at error level and the span fails
+	require.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, entry.Level)
+	assert.Contains(t, entry.ContextMap()["error"], "connection reset by peer")
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
+
+func TestHandlerFunc_NoError(t *testing.T) {
+	h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})
+
+	rec, logs, _ := serve(t, h)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Zero(t, logs.Len())
+}
@@ -2,35 +2,123 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-var log *zap.Logger
+// log holds the global logger; it is swapped atomically so that Replace is
+// safe while other goroutines log.
+var log atomic.Pointer[zap.Logger]
+
+// level is shared by every logger built by newLogger so it can be changed
+// at runtime with SetLevel.
+var level = zap.NewAtomicLevelAt(zap.InfoLevel)
+
+// settings holds the options applied by the most recent Init call.
+var settings options
+
+// options controls how Init builds the logger. The zero value is the
+// production setup: JSON encoding at info level.
+type options struct {
+	format      string
+	development bool
+}
+
+// Option customises the logger built by Init.
+type Option func(*options)
+
+// WithFormat selects the encoding, "json" or "console".
+func WithFormat(format string) Option {
+	return func(o *options) { o.format = format }
+}
+
+// WithDevelopment enables zap's development mode: stack traces on warnings,
+// panics on DPanic and caller-friendly output.
+func WithDevelopment(development bool) Option {
+	return func(o *options) { o.development = development }
+}
+
+// WithLevel sets the initial minimum level. Invalid levels are ignored.
+func WithLevel(l string) Option {
+	return func(*options) { _ = SetLevel(l) }
+}
 
 // newLogger is a function variable for creating a new zap.Logger.
-// It defaults to zap.NewProduction but can be overridden in tests.
-var newLogger = zap.NewProduction
+// It defaults to building a logger from settings but can be overridden in tests.
+var newLogger = func(opts ...zap.Option) (*zap.Logger, error) {
+	return zapConfig(settings).Build(opts...)
+}
+
+// zapConfig translates options into a zap configuration sharing level.
+func zapConfig(o options) zap.Config {
+	cfg := zap.NewProductionConfig()
+	if o.development {
+		cfg = zap.NewDevelopmentConfig()
+	}
+	switch o.format {
+	case "console":
+		cfg.Encoding = "console"
+		cfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	case "json":
+		cfg.Encoding = "json"
+		cfg.EncoderConfig = zap.NewProductionEncoderConfig()
+	}
+	cfg.Level = level
+	return cfg
+}
 
-// Init initializes the global production logger.
-func Init() {
-	var err error
-	log, err = newLogger()
+// Init initializes the global logger. Without options it builds the
+// production logger.
+func Init(opts ...Option) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	settings = o
+
+	l, err := newLogger()
 	if err != nil {
 		panic("cannot initialize zap logger: " + err.Error())
 	}
+	log.Store(l)
+}
+
+// Replace swaps the global logger, e.g. for an observer in tests, and
+// returns a function restoring the previous one.
+func Replace(l *zap.Logger) (restore func()) {
+	prev := log.Swap(l)
+	return func() { log.Store(prev) }
+}
+
+// SetLevel changes the minimum enabled level ("debug", "info", "warn" or
+// "error") of the global logger without rebuilding it.
+func SetLevel(l string) error {
+	parsed, err := zapcore.ParseLevel(l)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", l, err)
+	}
+	level.SetLevel(parsed)
+	return nil
+}
+
+// Level returns the current minimum enabled level.
+func Level() string {
+	return level.Level().String()
 }
 
 // Sync flushes any buffered log entries.
 func Sync() {
 	// It's a good practice to call this before the application exits.
-	_ = log.Sync()
+	_ = getLogger().Sync()
 }
 
 // getLogger returns the global logger instance.
 func getLogger() *zap.Logger {
-	return log
+	return log.Load()
 }
 
 // Info logs a message at the info level.
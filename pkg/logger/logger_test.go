@@ -32,7 +32,7 @@ func setupTestLogger(buffer *syncer) {
 		zap.DebugLevel,
 	)
 	zapLogger := zap.New(core)
-	log = zapLogger
+	log.Store(zapLogger)
 }
 
 func TestInit(t *testing.T) {
@@ -116,3 +116,105 @@ func TestInjectTrace(t *testing.T) {
 		assert.Equal(t, "8177353f49635e07", result[1].String)
 	})
 }
+
+func TestSetLevel(t *testing.T) {
+	defer func() { _ = SetLevel("info") }()
+	Init()
+
+	require.NoError(t, SetLevel("warn"))
+	assert.Equal(t, "warn", Level())
+	assert.False(t, getLogger().Core().Enabled(zap.InfoLevel))
+	assert.True(t, getLogger().Core().Enabled(zap.WarnLevel))
+
+	require.NoError(t, SetLevel("debug"))
+	assert.True(t, getLogger().Core().Enabled(zap.DebugLevel))
+
+	assert.Error(t, SetLevel("loud"))
+	assert.Equal(t, "debug", Level())
+}
+
+func TestZapConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     options
+		encoding string
+		dev      bool
+	}{
+		{"production default", options{}, "json", false},
+		{"console", options{format: "console"}, "console", false},
+		{"development json", options{format: "json", development: true}, "json", true},
+		{"development default", options{development: true}, "console", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := zapConfig(tt.opts)
+
+			assert.Equal(t, tt.encoding, cfg.Encoding)
+			assert.Equal(t, tt.dev, cfg.Development)
+			assert.Equal(t, level, cfg.Level)
+		})
+	}
+}
+
+func TestInitWithOptions(t *testing.T) {
+	defer func() {
+		_ = SetLevel("info")
+		Init()
+	}()
+
+	Init(WithFormat("console"), WithDevelopment(true), WithLevel("debug"))
+
+	assert.Equal(t, options{format: "console", development: true}, settings)
+	assert.Equal(t, "debug", Level())
+	assert.True(t, getLogger().Core().Enabled(zap.DebugLevel))
+}
+
+func TestReplace(t *testing.T) {
+	// Arrange: an existing logger and a replacement
+	var original, replacement syncer
+	setupTestLogger(&original)
+	restore := Replace(zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), &replacement, zapcore.DebugLevel)))
+
+	// Act: log through the replacement, then restore
+	Info(context.Background(), "to replacement")
+	restore()
+	Info(context.Background(), "to original")
+
+	// Assert: each message went to the logger active at the time
+	assert.Contains(t, replacement.String(), "to replacement")
+	assert.NotContains(t, replacement.String(), "to original")
+	assert.Contains(t, original.String(), "to original")
+}
+
+func TestReplace_WhileLogging(t *testing.T) {
+	// Arrange: a goroutine logging through the global logger
+	var buffer syncer
+	setupTestLogger(&buffer)
+	original := getLogger()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				Info(context.Background(), "busy")
+			}
+		}
+	}()
+
+	// Act: swap and restore the logger meanwhile
+	for range 100 {
+		Replace(zap.NewNop())()
+	}
+	close(stop)
+	<-done
+
+	// Assert: the original logger is back (run with -race to catch races)
+	assert.Same(t, original, getLogger())
+	Info(context.Background(), "restored")
+	assert.Contains(t, buffer.String(), "restored")
+}
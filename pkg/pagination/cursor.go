@@ -0,0 +1,90 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// cursor is the signed payload of a cursor.
+type cursor struct {
+	// Query fingerprints the sort and filters the cursor belongs to.
+	Query string `json:"q"`
+	// Values are the formatted sort values of the last row of the page.
+	Values []string `json:"v"`
+}
+
+// encodeCursor returns the cursor following a row with the sort values
+// values, as "<payload>.<signature>" in unpadded base64url.
+func (p *Paginator) encodeCursor(q Query, values []any) string {
+	c := cursor{Query: fingerprint(q), Values: make([]string, len(values))}
+	for i, v := range values {
+		c.Values[i] = formatValue(v)
+	}
+	payload, _ := json.Marshal(c)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(p.sign(payload))
+}
+
+// decodeCursor verifies s and returns its sort values parsed for q.Sort.
+func (p *Paginator) decodeCursor(s string, q Query) ([]any, error) {
+	enc := base64.RawURLEncoding
+	rawPayload, rawSig, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, errors.New("pagination: malformed cursor")
+	}
+	payload, err1 := enc.DecodeString(rawPayload)
+	sig, err2 := enc.DecodeString(rawSig)
+	if err := errors.Join(err1, err2); err != nil {
+		return nil, fmt.Errorf("pagination: malformed cursor: %w", err)
+	}
+	if !hmac.Equal(sig, p.sign(payload)) {
+		return nil, errors.New("pagination: cursor signature mismatch")
+	}
+	var c cursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, fmt.Errorf("pagination: malformed cursor: %w", err)
+	}
+	if c.Query != fingerprint(q) || len(c.Values) != len(q.Sort) {
+		return nil, errors.New("pagination: cursor belongs to another sort or filter")
+	}
+	values := make([]any, len(c.Values))
+	for i, raw := range c.Values {
+		v, err := parseValue(p.fields[q.Sort[i].Field].Kind, raw)
+		if err != nil {
+			return nil, fmt.Errorf("pagination: cursor value: %w", err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (p *Paginator) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// fingerprint identifies the sort and filters of q.
+func fingerprint(q Query) string {
+	var b strings.Builder
+	for _, s := range q.Sort {
+		if s.Desc {
+			b.WriteByte('-')
+		}
+		b.WriteString(s.Field)
+		b.WriteByte(',')
+	}
+	for _, f := range q.Filters {
+		fmt.Fprintf(&b, ";%s:%s", f.Field, f.Op)
+		for _, v := range f.Values {
+			fmt.Fprintf(&b, ":%q", formatValue(v))
+		}
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
@@ -0,0 +1,54 @@
+package pagination
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Page is the envelope of list responses.
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// NextCursor fetches the next page; it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	Limit      int    `json:"limit" example:"20"`
+}
+
+// NewPage returns the page of q made of rows, which must have been fetched
+// with q applied and one row more than q.Limit, as the db adapter does; the
+// extra row only tells that a next page exists. value returns the value of
+// a sort field of a row, e.g. item.CreatedAt for "created_at".
+func NewPage[T any](q Query, rows []T, value func(row T, field string) any) Page[T] {
+	page := Page[T]{Items: rows, Limit: q.Limit}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if len(rows) <= q.Limit {
+		return page
+	}
+	page.Items = rows[:q.Limit]
+	last := page.Items[q.Limit-1]
+	values := make([]any, len(q.Sort))
+	for i, s := range q.Sort {
+		values[i] = value(last, s.Field)
+	}
+	page.NextCursor = q.p.encodeCursor(q, values)
+	return page
+}
+
+// SetLinks adds Link headers (RFC 8288) to the first page and, unless this
+// is the last page, to the next one. They repeat the query of r.
+func (pg Page[T]) SetLinks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	query.Del("cursor")
+	w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="first"`, linkTo(r, query.Encode())))
+	if pg.NextCursor != "" {
+		query.Set("cursor", pg.NextCursor)
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, linkTo(r, query.Encode())))
+	}
+}
+
+func linkTo(r *http.Request, rawQuery string) string {
+	u := *r.URL
+	u.RawQuery = rawQuery
+	return u.RequestURI()
+}
@@ -0,0 +1,286 @@
+// Package pagination implements the ?limit=&cursor=&sort=&filter= query
+// parameters shared by list endpoints. A Paginator parses and validates them
+// against an allowlist of fields, pages are addressed by opaque, signed
+// keyset cursors and responses use the Page envelope and Link headers.
+//
+// Parameters:
+//
+//	limit=20                     page size, capped by Config.MaxLimit
+//	sort=-created_at,name        comma separated fields, "-" for descending
+//	filter=status:eq:active      repeatable field:op:value, ops eq ne lt lte gt gte in
+//	filter=status:in:active|new  "in" takes "|" separated values
+//	cursor=...                   next_cursor of the previous page
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"template-go/pkg/apperr"
+)
+
+// Kind is the type of a field's values, used to parse filter and cursor
+// values.
+type Kind int
+
+// Kinds.
+const (
+	String Kind = iota
+	Int
+	Float
+	Bool
+	Time // RFC 3339
+)
+
+// Field is a field clients may sort or filter on.
+type Field struct {
+	// Name is the field name in query parameters, e.g. "created_at".
+	Name string
+	// Column is the database column, Name when empty.
+	Column string
+	Kind   Kind
+	// Sortable and Filterable allow the field in sort and filter. Sort
+	// fields must not be NULL, as keyset cursors compare their values.
+	Sortable   bool
+	Filterable bool
+}
+
+// Config describes the parameters accepted by one list endpoint.
+type Config struct {
+	Fields []Field
+	// Key is a sortable field unique across rows, e.g. "id". It ends every
+	// sort so that rows with equal sort values keep a stable order.
+	Key string
+	// DefaultSort applies when the request has no sort, e.g. "-created_at".
+	// It defaults to Key.
+	DefaultSort string
+	// DefaultLimit applies when the request has no limit; it defaults to 20.
+	// Larger limits are capped to MaxLimit, which defaults to 100.
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Op is a filter operator.
+type Op string
+
+// Filter operators.
+const (
+	Eq  Op = "eq"
+	Ne  Op = "ne"
+	Lt  Op = "lt"
+	Lte Op = "lte"
+	Gt  Op = "gt"
+	Gte Op = "gte"
+	In  Op = "in"
+)
+
+var ops = []Op{Eq, Ne, Lt, Lte, Gt, Gte, In}
+
+// Sort orders a page by one field.
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Filter restricts a page to rows whose field matches. Values holds one
+// parsed value, or several for In.
+type Filter struct {
+	Field  string
+	Column string
+	Op     Op
+	Values []any
+}
+
+// Query is a parsed and validated list request.
+type Query struct {
+	Limit   int
+	Sort    []Sort // always ends with the key field
+	Filters []Filter
+	// After holds the sort values of the last row of the previous page, one
+	// per Sort, or nil for the first page.
+	After []any
+
+	p *Paginator
+}
+
+// Paginator parses list requests for one endpoint.
+type Paginator struct {
+	cfg    Config
+	fields map[string]Field
+	key    []byte
+	sort   []Sort
+}
+
+// New returns a Paginator for cfg signing cursors with secret. Use the same
+// secret on every replica so cursors survive load balancing and restarts.
+func New(secret []byte, cfg Config) (*Paginator, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("pagination: empty cursor secret")
+	}
+	if cfg.DefaultLimit <= 0 {
+		cfg.DefaultLimit = 20
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = 100
+	}
+	if cfg.DefaultLimit > cfg.MaxLimit {
+		return nil, fmt.Errorf("pagination: default limit %d exceeds max limit %d", cfg.DefaultLimit, cfg.MaxLimit)
+	}
+	p := &Paginator{cfg: cfg, fields: make(map[string]Field, len(cfg.Fields)), key: secret}
+	for _, f := range cfg.Fields {
+		if f.Column == "" {
+			f.Column = f.Name
+		}
+		p.fields[f.Name] = f
+	}
+	if f, ok := p.fields[cfg.Key]; !ok || !f.Sortable {
+		return nil, fmt.Errorf("pagination: key %q is not a sortable field", cfg.Key)
+	}
+	if cfg.DefaultSort == "" {
+		cfg.DefaultSort = cfg.Key
+	}
+	sort, err := p.parseSort(cfg.DefaultSort)
+	if err != nil {
+		return nil, fmt.Errorf("pagination: default sort: %w", err)
+	}
+	p.sort = sort
+	return p, nil
+}
+
+// Parse reads the pagination parameters of r. Invalid parameters yield an
+// apperr.CodeInvalidArgument error. A cursor is only valid with the sort
+// and filters of the request that produced it.
+func (p *Paginator) Parse(r *http.Request) (Query, error) {
+	values := r.URL.Query()
+	q := Query{Limit: p.cfg.DefaultLimit, Sort: p.sort, p: p}
+
+	if s := values.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return Query{}, apperr.Newf(apperr.CodeInvalidArgument, "Invalid limit %q: must be a positive integer.", s)
+		}
+		q.Limit = min(n, p.cfg.MaxLimit)
+	}
+	if s := values.Get("sort"); s != "" {
+		sort, err := p.parseSort(s)
+		if err != nil {
+			return Query{}, apperr.Newf(apperr.CodeInvalidArgument, "Invalid sort: %v.", err)
+		}
+		q.Sort = sort
+	}
+	for _, s := range values["filter"] {
+		f, err := p.parseFilter(s)
+		if err != nil {
+			return Query{}, apperr.Newf(apperr.CodeInvalidArgument, "Invalid filter %q: %v.", s, err)
+		}
+		q.Filters = append(q.Filters, f)
+	}
+	if s := values.Get("cursor"); s != "" {
+		after, err := p.decodeCursor(s, q)
+		if err != nil {
+			return Query{}, apperr.Wrap(err, apperr.CodeInvalidArgument, "Invalid cursor: request the first page again.")
+		}
+		q.After = after
+	}
+	return q, nil
+}
+
+// parseSort parses "-created_at,name" and appends the key field unless it
+// is already there.
+func (p *Paginator) parseSort(s string) ([]Sort, error) {
+	var sort []Sort
+	for _, name := range strings.Split(s, ",") {
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		f, ok := p.fields[name]
+		if !ok || !f.Sortable {
+			return nil, fmt.Errorf("field %q is not sortable", name)
+		}
+		if slices.ContainsFunc(sort, func(s Sort) bool { return s.Field == name }) {
+			return nil, fmt.Errorf("field %q is repeated", name)
+		}
+		sort = append(sort, Sort{Field: name, Column: f.Column, Desc: desc})
+	}
+	if !slices.ContainsFunc(sort, func(s Sort) bool { return s.Field == p.cfg.Key }) {
+		sort = append(sort, Sort{Field: p.cfg.Key, Column: p.fields[p.cfg.Key].Column, Desc: sort[len(sort)-1].Desc})
+	}
+	return sort, nil
+}
+
+// parseFilter parses "field:op:value".
+func (p *Paginator) parseFilter(s string) (Filter, error) {
+	name, rest, ok1 := strings.Cut(s, ":")
+	op, raw, ok2 := strings.Cut(rest, ":")
+	if !ok1 || !ok2 {
+		return Filter{}, errors.New("want field:op:value")
+	}
+	f, ok := p.fields[name]
+	if !ok || !f.Filterable {
+		return Filter{}, fmt.Errorf("field %q is not filterable", name)
+	}
+	if !slices.Contains(ops, Op(op)) {
+		return Filter{}, fmt.Errorf("unknown operator %q", op)
+	}
+	raws := []string{raw}
+	if Op(op) == In {
+		raws = strings.Split(raw, "|")
+	}
+	values := make([]any, len(raws))
+	for i, raw := range raws {
+		v, err := parseValue(f.Kind, raw)
+		if err != nil {
+			return Filter{}, err
+		}
+		values[i] = v
+	}
+	return Filter{Field: name, Column: f.Column, Op: Op(op), Values: values}, nil
+}
+
+func parseValue(kind Kind, s string) (any, error) {
+	switch kind {
+	case Int:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", s)
+		}
+		return v, nil
+	case Float:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return v, nil
+	case Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", s)
+		}
+		return v, nil
+	case Time:
+		v, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an RFC 3339 time", s)
+		}
+		return v, nil
+	default:
+		return s, nil
+	}
+}
+
+// formatValue is the inverse of parseValue.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
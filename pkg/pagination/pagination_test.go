@@ -0,0 +1,245 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"template-go/pkg/apperr"
+)
+
+func newPaginator(t *testing.T, secret string) *Paginator {
+	t.Helper()
+	p, err := New([]byte(secret), Config{
+		Fields: []Field{
+			{Name: "id", Sortable: true},
+			{Name: "name", Sortable: true, Filterable: true},
+			{Name: "age", Kind: Int, Filterable: true},
+			{Name: "created", Column: "created_at", Kind: Time, Sortable: true},
+		},
+		Key:         "id",
+		DefaultSort: "-created",
+		MaxLimit:    50,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func parse(p *Paginator, query string) (Query, error) {
+	return p.Parse(httptest.NewRequest("GET", "/items?"+query, nil))
+}
+
+func TestParse(t *testing.T) {
+	p := newPaginator(t, "secret")
+
+	t.Run("defaults", func(t *testing.T) {
+		q, err := parse(p, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, 20, q.Limit)
+		assert.Equal(t, []Sort{{Field: "created", Column: "created_at", Desc: true}, {Field: "id", Column: "id", Desc: true}}, q.Sort)
+		assert.Nil(t, q.After)
+	})
+
+	t.Run("parameters", func(t *testing.T) {
+		q, err := parse(p, "limit=500&sort=name,-id&filter=age:gte:18&filter=name:in:ann|bob")
+
+		require.NoError(t, err)
+		assert.Equal(t, 50, q.Limit, "capped to the max limit")
+		assert.Equal(t, []Sort{{Field: "name", Column: "name"}, {Field: "id", Column: "id", Desc: true}}, q.Sort)
+		assert.Equal(t, []Filter{
+			{Field: "age", Column: "age", Op: Gte, Values: []any{int64(18)}},
+			{Field: "name", Column: "name", Op: In, Values: []any{"ann", "bob"}},
+		}, q.Filters)
+	})
+}
+
+func TestParse_Invalid(t *testing.T) {
+	p := newPaginator(t, "secret")
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"zero limit", "limit=0"},
+		{"non-numeric limit", "limit=ten"},
+		{"unknown sort field", "sort=password"},
+		{"unsortable field", "sort=age"},
+		{"repeated sort field", "sort=name,-name"},
+		{"unfilterable field", "filter=id:eq:1"},
+		{"unknown operator", "filter=age:like:1"},
+		{"missing value", "filter=age:eq"},
+		{"mistyped value", "filter=age:eq:old"},
+		{"garbage cursor", "cursor=abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(p, tt.query)
+
+			var appErr *apperr.Error
+			require.True(t, errors.As(err, &appErr), "got %v", err)
+			assert.Equal(t, apperr.CodeInvalidArgument, appErr.Code)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	p := newPaginator(t, "secret")
+	created := time.Date(2024, 5, 1, 8, 30, 0, 123, time.FixedZone("CEST", 2*3600))
+	q, err := parse(p, "sort=-created&filter=name:eq:ann")
+	require.NoError(t, err)
+	cursor := p.encodeCursor(q, []any{created, "item-7"})
+
+	t.Run("round trip", func(t *testing.T) {
+		q, err := parse(p, "sort=-created&filter=name:eq:ann&cursor="+cursor)
+
+		require.NoError(t, err)
+		require.Len(t, q.After, 2)
+		assert.True(t, created.Equal(q.After[0].(time.Time)))
+		assert.Equal(t, "item-7", q.After[1])
+	})
+
+	tests := []struct {
+		name  string
+		p     *Paginator
+		query string
+	}{
+		{"other sort", p, "sort=created&filter=name:eq:ann&cursor=" + cursor},
+		{"other filter", p, "sort=-created&filter=name:eq:bob&cursor=" + cursor},
+		{"other secret", newPaginator(t, "other"), "sort=-created&filter=name:eq:ann&cursor=" + cursor},
+		{"tampered", p, "sort=-created&filter=name:eq:ann&cursor=" + strings.Replace(cursor, "e", "f", 1)},
+		{"not base64", p, "sort=-created&filter=name:eq:ann&cursor=a!.b!"},
+		{"signed garbage", p, "sort=-created&filter=name:eq:ann&cursor=" + signed(p, []byte("{"))},
+		{"signed mistyped value", p, "sort=-created&filter=name:eq:ann&cursor=" + signed(p, []byte(`{"q":"`+fingerprint(q)+`","v":["yesterday","item-7"]}`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.p, tt.query)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+// signed returns a cursor holding payload with a valid signature.
+func signed(p *Paginator, payload []byte) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(p.sign(payload))
+}
+
+func TestParseValue(t *testing.T) {
+	created := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+	tests := []struct {
+		kind    Kind
+		s       string
+		want    any
+		wantErr string
+	}{
+		{String, "ann", "ann", ""},
+		{Int, "42", int64(42), ""},
+		{Int, "4.2", nil, "not an integer"},
+		{Float, "4.2", 4.2, ""},
+		{Float, "four", nil, "not a number"},
+		{Bool, "true", true, ""},
+		{Bool, "yes", nil, "not a boolean"},
+		{Time, "2024-05-01T08:30:00Z", created, ""},
+		{Time, "2024-05-01", nil, "not an RFC 3339 time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseValue(tt.kind, tt.s)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 30, 0, 0, time.FixedZone("CEST", 2*3600))
+
+	assert.Equal(t, "2024-05-01T08:30:00Z", formatValue(created))
+	assert.Equal(t, "2024-05-01T08:30:00Z", formatValue(&created))
+	assert.Equal(t, "42", formatValue(42))
+}
+
+func TestNewPage(t *testing.T) {
+	// GIVEN a query for 2 items and the 3 rows fetched for it
+	p := newPaginator(t, "secret")
+	req := httptest.NewRequest("GET", "/v1/items?limit=2&sort=name&filter=age:gt:3", nil)
+	q, err := p.Parse(req)
+	require.NoError(t, err)
+	rows := []string{"ann", "bob", "cid"}
+
+	// WHEN the page is built
+	page := NewPage(q, rows, func(row, field string) any { return row })
+
+	// THEN it holds 2 items and a cursor after the second
+	assert.Equal(t, []string{"ann", "bob"}, page.Items)
+	assert.Equal(t, 2, page.Limit)
+	next, err := p.Parse(httptest.NewRequest("GET", "/v1/items?limit=2&sort=name&filter=age:gt:3&cursor="+page.NextCursor, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []any{"bob", "bob"}, next.After)
+
+	// AND links repeat the query
+	rec := httptest.NewRecorder()
+	page.SetLinks(rec, req)
+	assert.Equal(t, []string{
+		`</v1/items?filter=age%3Agt%3A3&limit=2&sort=name>; rel="first"`,
+		`</v1/items?cursor=` + page.NextCursor + `&filter=age%3Agt%3A3&limit=2&sort=name>; rel="next"`,
+	}, rec.Header().Values("Link"))
+}
+
+func TestNewPage_LastPage(t *testing.T) {
+	q, err := parse(newPaginator(t, "secret"), "limit=2")
+	require.NoError(t, err)
+
+	page := NewPage[string](q, nil, nil)
+
+	assert.Equal(t, []string{}, page.Items)
+	assert.Empty(t, page.NextCursor)
+	rec := httptest.NewRecorder()
+	page.SetLinks(rec, httptest.NewRequest("GET", "/items?limit=2", nil))
+	assert.Equal(t, []string{`</items?limit=2>; rel="first"`}, rec.Header().Values("Link"))
+}
+
+func TestNew_DefaultSortIsKey(t *testing.T) {
+	p, err := New([]byte("s"), Config{Fields: []Field{{Name: "id", Sortable: true}}, Key: "id"})
+	require.NoError(t, err)
+
+	q, err := parse(p, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, []Sort{{Field: "id", Column: "id"}}, q.Sort)
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	fields := []Field{{Name: "id", Sortable: true}, {Name: "name"}}
+	tests := []struct {
+		name   string
+		secret string
+		cfg    Config
+	}{
+		{"empty secret", "", Config{Fields: fields, Key: "id"}},
+		{"unknown key", "s", Config{Fields: fields, Key: "uuid"}},
+		{"unsortable key", "s", Config{Fields: fields, Key: "name"}},
+		{"bad default sort", "s", Config{Fields: fields, Key: "id", DefaultSort: "name"}},
+		{"default over max", "s", Config{Fields: fields, Key: "id", DefaultLimit: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New([]byte(tt.secret), tt.cfg)
+
+			assert.Error(t, err)
+		})
+	}
+}